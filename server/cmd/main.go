@@ -64,7 +64,7 @@ func main() {
 		middleware.CORS(),
 		middleware.RateLimiter(5, 10),
 		middleware.LimitFileSize(12<<20),
-		middleware.APIKeyGateway([]string{"/api/payments", "/api/payments/notifications", "/api/auth/google", "/api/auth/google/callback"}),
+		middleware.APIKeyGateway([]string{"/api/payments/notifications", "/api/payments/fake/", "/api/auth/google", "/api/auth/google/callback"}),
 	)
 
 	// ========== initialisasi layer ============
@@ -72,8 +72,18 @@ func main() {
 	s := services.InitServices(repo)
 	h := handlers.InitHandlers(s)
 
+	// ========== Search Index ==========
+	if indexed, err := s.ProductService.ReindexProducts(); err != nil {
+		log.Println("Failed to build product search index:", err)
+	} else {
+		log.Printf("Product search index built with %d products\n", indexed)
+	}
+	if _, err := s.SearchService.RefreshPopularQueries(); err != nil {
+		log.Println("Failed to load popular search queries:", err)
+	}
+
 	// ========== Cron Job ==========
-	cronManager := cron.NewCronManager(s.PaymentService, s.NotificationService)
+	cronManager := cron.NewCronManager(s.PaymentService, s.NotificationService, s.ReconciliationService, s.InventoryService, s.StockAlertService, s.CartService, s.WishlistService, s.ProductService, s.SearchService, s.RecommendationService)
 	cronManager.RegisterJobs()
 	cronManager.Start()
 
@@ -83,11 +93,19 @@ func main() {
 	routes.ProfileRoutes(r, h.ProfileHandler)
 	routes.BannerRoutes(r, h.BannerHandler)
 	routes.CartRoutes(r, h.CartHandler)
-	routes.PaymentRoutes(r, h.PaymentHandler)
+	routes.GuestCartRoutes(r, h.GuestCartHandler)
+	routes.PaymentRoutes(r, h.PaymentHandler, config.PaymentGatewayName == services.GatewayFake)
 	routes.ReviewRoutes(r, h.ReviewHandler)
 	routes.OrderRoutes(r, h.OrderHandler)
+	routes.ReturnRoutes(r, h.ReturnHandler)
+	routes.ReconciliationRoutes(r, h.ReconciliationHandler)
+	routes.InventoryRoutes(r, h.InventoryHandler)
+	routes.StockAlertRoutes(r, h.StockAlertHandler)
+	routes.WishlistRoutes(r, h.WishlistHandler)
 	routes.AddressRoutes(r, h.AddressHandler)
 	routes.VoucherRoutes(r, h.VoucherHandler)
+	routes.SearchRoutes(r, h.SearchHandler)
+	routes.RecommendationRoutes(r, h.RecommendationHandler)
 	routes.ProductRoutes(r, h.ProductHandler)
 	routes.CategoryRoutes(r, h.CategoryHandler)
 	routes.LocationRoutes(r, h.LocationHandler)
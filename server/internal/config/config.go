@@ -16,6 +16,21 @@ func InitDependencies() {
 	// Initialize Midtrans
 	InitMidtrans()
 
+	// Initialize COD & manual transfer settings
+	InitManualPayment()
+
+	// Initialize shipping origin
+	InitShipping()
+
+	// Initialize cart stock reservation
+	InitCartReservation()
+
+	// Initialize review moderation
+	InitReviewModeration()
+
+	// Initialize product search backend
+	InitSearch()
+
 	// Initialize Google OAuth Config
 	InitGoogleOAuthConfig()
 
@@ -44,13 +44,42 @@ func InitDatabase() {
 		panic("Failed to connect to database: " + err.Error())
 	}
 
-	// migrate models
-	if err := DB.AutoMigrate(
+	if err := Migrate(DB); err != nil {
+		panic("Migration failed: " + err.Error())
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		panic("Failed to get database connection: " + err.Error())
+	}
+	sqlDB.SetMaxOpenConns(10)
+	sqlDB.SetMaxIdleConns(5)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+
+	fmt.Println("Database connection established successfully.")
+}
+
+// Migrate creates or updates the tables of every model
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(
 		&models.User{},
 		&models.Token{},
 		&models.Profile{},
+		&models.Banner{},
 		&models.Product{},
+		&models.Cart{},
 		&models.ProductGallery{},
+		&models.ProductOption{},
+		&models.ProductOptionValue{},
+		&models.ProductVariant{},
+		&models.ProductVariantValue{},
+		&models.ProductVariantImage{},
+		&models.StockMovement{},
+		&models.RestockSubscription{},
+		&models.StockReservation{},
+		&models.WishlistItem{},
+		&models.SearchQuery{},
+		&models.ProductAffinity{},
 		&models.Category{},
 		&models.Address{},
 		&models.Province{},
@@ -59,25 +88,22 @@ func InitDatabase() {
 		&models.Subdistrict{},
 		&models.PostalCode{},
 		&models.Review{},
+		&models.ReviewImage{},
+		&models.ReviewVote{},
+		&models.ReviewReply{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderStatusHistory{},
+		&models.Reconciliation{},
+		&models.ReturnRequest{},
+		&models.ReturnItem{},
+		&models.ReturnImage{},
+		&models.Shipment{},
 		&models.Voucher{},
 		&models.UsedVoucher{},
 		&models.Notification{},
 		&models.NotificationSetting{},
 		&models.NotificationType{},
 		&models.Payment{},
-	); err != nil {
-		panic("Migration failed: " + err.Error())
-	}
-
-	sqlDB, err := DB.DB()
-	if err != nil {
-		panic("Failed to get database connection: " + err.Error())
-	}
-	sqlDB.SetMaxOpenConns(10)
-	sqlDB.SetMaxIdleConns(5)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	fmt.Println("Database connection established successfully.")
+	)
 }
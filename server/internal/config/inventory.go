@@ -0,0 +1,20 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// reservasi stok keranjang, nonaktif kecuali CART_RESERVATION_ENABLED=true
+var (
+	CartReservationEnabled = false
+	CartReservationTTL     = 15 * time.Minute
+)
+
+func InitCartReservation() {
+	CartReservationEnabled = os.Getenv("CART_RESERVATION_ENABLED") == "true"
+	if minutes, err := strconv.Atoi(os.Getenv("CART_RESERVATION_TTL_MINUTES")); err == nil && minutes > 0 {
+		CartReservationTTL = time.Duration(minutes) * time.Minute
+	}
+}
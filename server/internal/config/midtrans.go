@@ -11,10 +11,31 @@ import (
 
 var CoreClient coreapi.Client
 var SnapClient snap.Client
+var MidtransServerKey string
+
+// MidtransConfirmStatus re-queries CoreAPI before trusting a webhook payload
+var MidtransConfirmStatus bool
+
+// PaymentGatewayName selects the payment gateway: "midtrans" or "fake"
+var PaymentGatewayName string
+
+// FakeGatewaySecret signs fake gateway notifications and pay page links
+var FakeGatewaySecret string
+
+// AppURL is the public base URL of this API, used by the fake gateway pay page
+var AppURL string
 
 func InitMidtrans() {
 	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
 	env := os.Getenv("NODE_ENV")
+	MidtransServerKey = serverKey
+	MidtransConfirmStatus = os.Getenv("MIDTRANS_CONFIRM_STATUS") == "true"
+	FakeGatewaySecret = os.Getenv("FAKE_GATEWAY_SECRET")
+	PaymentGatewayName = getPaymentGateway(env)
+	AppURL = os.Getenv("APP_URL")
+	if AppURL == "" {
+		AppURL = "http://localhost:" + os.Getenv("PORT")
+	}
 
 	snapClient := snap.Client{}
 	snapClient.New(serverKey, getMidtransEnv(env))
@@ -24,7 +45,20 @@ func InitMidtrans() {
 	coreClient.New(serverKey, getMidtransEnv(env))
 	CoreClient = coreClient
 
-	log.Println("Midtrans Snap & CoreAPI client initialized")
+	log.Printf("Midtrans Snap & CoreAPI client initialized, payment gateway: %s\n", PaymentGatewayName)
+}
+
+// ** gateway fake tidak boleh dipakai di production, dan wajib punya secret sendiri
+func getPaymentGateway(env string) string {
+	gateway := os.Getenv("PAYMENT_GATEWAY")
+	if env == "production" || gateway == "" {
+		return "midtrans"
+	}
+	if gateway == "fake" && FakeGatewaySecret == "" {
+		log.Println("FAKE_GATEWAY_SECRET is not set, falling back to midtrans")
+		return "midtrans"
+	}
+	return gateway
 }
 
 func getMidtransEnv(env string) midtrans.EnvironmentType {
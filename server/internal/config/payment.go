@@ -0,0 +1,23 @@
+package config
+
+import (
+	"log"
+	"os"
+)
+
+// rekening tujuan untuk metode transfer bank manual
+var (
+	ManualTransferBank          string
+	ManualTransferAccountNumber string
+	ManualTransferAccountName   string
+)
+
+func InitManualPayment() {
+	ManualTransferBank = os.Getenv("MANUAL_TRANSFER_BANK")
+	ManualTransferAccountNumber = os.Getenv("MANUAL_TRANSFER_ACCOUNT_NUMBER")
+	ManualTransferAccountName = os.Getenv("MANUAL_TRANSFER_ACCOUNT_NAME")
+
+	if ManualTransferAccountNumber == "" {
+		log.Println("⚠️  MANUAL_TRANSFER_ACCOUNT_NUMBER is empty, manual bank transfer is disabled")
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// moderasi ulasan, ulasan yang mengandung kata terlarang selalu masuk antrean admin
+var (
+	ReviewAutoApprove = true
+	ReviewBannedWords []string
+)
+
+func InitReviewModeration() {
+	ReviewAutoApprove = os.Getenv("REVIEW_AUTO_APPROVE") != "false"
+
+	ReviewBannedWords = nil
+	for _, word := range strings.Split(os.Getenv("REVIEW_BANNED_WORDS"), ",") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			ReviewBannedWords = append(ReviewBannedWords, word)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package config
+
+import "os"
+
+// mesin pencarian produk, "mysql" (FULLTEXT) atau "memory" untuk test dan development
+var SearchBackend = "mysql"
+
+func InitSearch() {
+	if backend := os.Getenv("SEARCH_BACKEND"); backend != "" {
+		SearchBackend = backend
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// lokasi gudang asal pengiriman, default Medan (Sumatera Utara)
+var (
+	ShopOriginProvinceID = 2
+	ShopOriginCityID     = 52
+)
+
+func InitShipping() {
+	if id, err := strconv.Atoi(os.Getenv("SHOP_ORIGIN_PROVINCE_ID")); err == nil && id > 0 {
+		ShopOriginProvinceID = id
+	}
+	if id, err := strconv.Atoi(os.Getenv("SHOP_ORIGIN_CITY_ID")); err == nil && id > 0 {
+		ShopOriginCityID = id
+	}
+}
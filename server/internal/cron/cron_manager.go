@@ -9,25 +9,49 @@ import (
 )
 
 type CronManager struct {
-	c                   *cron.Cron
-	paymentService      services.PaymentService
-	notificationService services.NotificationService
+	c                     *cron.Cron
+	paymentService        services.PaymentService
+	notificationService   services.NotificationService
+	reconciliationService services.ReconciliationService
+	inventoryService      services.InventoryService
+	stockAlertService     services.StockAlertService
+	cartService           services.CartService
+	wishlistService       services.WishlistService
+	productService        services.ProductService
+	searchService         services.SearchService
+	recommendationService services.RecommendationService
 }
 
 func NewCronManager(
 	payment services.PaymentService,
 	notification services.NotificationService,
+	reconciliation services.ReconciliationService,
+	inventory services.InventoryService,
+	stockAlert services.StockAlertService,
+	cart services.CartService,
+	wishlist services.WishlistService,
+	product services.ProductService,
+	search services.SearchService,
+	recommendation services.RecommendationService,
 ) *CronManager {
 	return &CronManager{
-		c:                   cron.New(cron.WithSeconds()),
-		paymentService:      payment,
-		notificationService: notification,
+		c:                     cron.New(cron.WithSeconds()),
+		paymentService:        payment,
+		notificationService:   notification,
+		reconciliationService: reconciliation,
+		inventoryService:      inventory,
+		stockAlertService:     stockAlert,
+		cartService:           cart,
+		wishlistService:       wishlist,
+		productService:        product,
+		searchService:         search,
+		recommendationService: recommendation,
 	}
 }
 
 func (cm *CronManager) RegisterJobs() {
 	cm.c.AddFunc("0 0 */2 * * *", func() {
-		log.Println("Cron: Checking expired pending payments...")
+		log.Println("Cron: Checking expired manual transfers...")
 		if err := cm.paymentService.ExpireOldPendingPayments(); err != nil {
 			log.Println("Error expiring payments:", err)
 		} else {
@@ -35,6 +59,105 @@ func (cm *CronManager) RegisterJobs() {
 		}
 	})
 
+	cm.c.AddFunc("0 */30 * * * *", func() {
+		log.Println("Cron: Reconciling payments with gateway...")
+		summary, err := cm.reconciliationService.Reconcile()
+		if err != nil {
+			log.Println("Error reconciling payments:", err)
+			return
+		}
+		log.Printf("Reconciliation %s: %d checked, %d paid, %d failed, %d refunded, %d need review, %d errors\n",
+			summary.RunID, summary.Checked, summary.MarkedPaid, summary.Failed, summary.Refunded, summary.NeedReview, summary.Errors)
+	})
+
+	cm.c.AddFunc("0 */5 * * * *", func() {
+		if err := cm.stockAlertService.CheckAlerts(); err != nil {
+			log.Println("Error checking stock alerts:", err)
+		}
+	})
+
+	cm.c.AddFunc("0 * * * * *", func() {
+		released, err := cm.cartService.ReleaseExpiredReservations()
+		if err != nil {
+			log.Println("Error releasing expired stock reservations:", err)
+			return
+		}
+		if released > 0 {
+			log.Printf("Released %d expired stock reservations\n", released)
+		}
+	})
+
+	cm.c.AddFunc("0 30 3 * * *", func() {
+		purged, err := cm.cartService.PurgeGuestCarts()
+		if err != nil {
+			log.Println("Error purging guest carts:", err)
+			return
+		}
+		log.Printf("Purged %d stale guest cart lines\n", purged)
+	})
+
+	cm.c.AddFunc("0 0 * * * *", func() {
+		notified, err := cm.wishlistService.CheckPriceDrops()
+		if err != nil {
+			log.Println("Error checking wishlist price drops:", err)
+			return
+		}
+		if notified > 0 {
+			log.Printf("Sent %d wishlist price drop notifications\n", notified)
+		}
+	})
+
+	// ** stok, rating dan kosakata index pencarian ikut diperbarui
+	cm.c.AddFunc("0 */10 * * * *", func() {
+		if _, err := cm.productService.ReindexProducts(); err != nil {
+			log.Println("Error rebuilding product search index:", err)
+		}
+	})
+
+	// view produk ditampung di Redis, ditulis ke database sekaligus meluruhkan skor trending
+	cm.c.AddFunc("0 */5 * * * *", func() {
+		if _, err := cm.productService.FlushProductViews(); err != nil {
+			log.Println("Error flushing product views:", err)
+		}
+	})
+
+	cm.c.AddFunc("0 */15 * * * *", func() {
+		if _, err := cm.searchService.RefreshPopularQueries(); err != nil {
+			log.Println("Error refreshing popular search queries:", err)
+		}
+	})
+
+	cm.c.AddFunc("0 45 3 * * *", func() {
+		purged, err := cm.searchService.PurgeQueryLog()
+		if err != nil {
+			log.Println("Error purging search query log:", err)
+			return
+		}
+		log.Printf("Purged %d old search queries\n", purged)
+	})
+
+	// ** pasangan "sering dibeli bersama" dihitung ulang dari riwayat order
+	cm.c.AddFunc("0 15 */6 * * *", func() {
+		saved, err := cm.recommendationService.RebuildAffinities()
+		if err != nil {
+			log.Println("Error rebuilding product recommendations:", err)
+			return
+		}
+		log.Printf("Rebuilt %d product affinities\n", saved)
+	})
+
+	cm.c.AddFunc("0 0 3 * * *", func() {
+		log.Println("Cron: Checking stock against the inventory ledger...")
+		report, err := cm.inventoryService.CheckConsistency()
+		if err != nil {
+			log.Println("Error checking stock ledger:", err)
+			return
+		}
+		for _, m := range report.Mismatches {
+			log.Printf("Stock mismatch %s %s: stock %d, ledger %d\n", m.ProductName, m.SKU, m.Stock, m.LedgerStock)
+		}
+	})
+
 }
 
 func (cm *CronManager) Start() {
@@ -18,6 +18,7 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
+	UserID       string `json:"-"`
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
 }
@@ -126,10 +127,32 @@ type UpdateAddressRequest struct {
 
 // PRODUCT, CATEGORY, BANNER REQUEST & RESPONSE  =====================
 type CategoryResponse struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Slug  string `json:"slug"`
-	Image string `json:"image"`
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug"`
+	Image    string  `json:"image"`
+	ParentID *string `json:"parentId"`
+	Position int     `json:"position"`
+
+	Breadcrumbs []CategoryBreadcrumb   `json:"breadcrumbs"` // dari kategori utama sampai kategori ini
+	Children    []CategoryListResponse `json:"children"`
+}
+
+type CategoryBreadcrumb struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// CategoryTreeResponse: ProductCount includes the active products of every descendant
+type CategoryTreeResponse struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Slug         string                 `json:"slug"`
+	Image        string                 `json:"image"`
+	Position     int                    `json:"position"`
+	ProductCount int                    `json:"productCount"`
+	Children     []CategoryTreeResponse `json:"children"`
 }
 
 type CategoryQueryParam struct {
@@ -140,10 +163,12 @@ type CategoryQueryParam struct {
 }
 
 type CategoryListResponse struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Slug  string `json:"slug"`
-	Image string `json:"image"`
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug"`
+	Image    string  `json:"image"`
+	ParentID *string `json:"parentId"`
+	Position int     `json:"position"`
 }
 
 type BannerRequest struct {
@@ -162,12 +187,22 @@ type CreateCategoryRequest struct {
 	Name     string                `form:"name" binding:"required,min=5"`
 	Image    *multipart.FileHeader `form:"image" binding:"required"`
 	ImageURL string                `form:"-"`
+	ParentID string                `form:"parentId" binding:"omitempty,uuid4"`
+	Position *int                  `form:"position" binding:"omitempty,min=0"` // kosong berarti paling akhir
 }
 
+// UpdateCategoryRequest: an empty parentId moves the category to the top level, leaving it out keeps the parent
 type UpdateCategoryRequest struct {
 	Name     string                `form:"name" binding:"required,min=5"`
 	Image    *multipart.FileHeader `form:"image" binding:"required"`
 	ImageURL string                `form:"-"`
+	ParentID *string               `form:"parentId"`
+	Position *int                  `form:"position" binding:"omitempty,min=0"`
+}
+
+// DeleteCategoryParam: MoveTo receives the products and subcategories of the deleted category
+type DeleteCategoryParam struct {
+	MoveTo string `form:"moveTo" binding:"omitempty,uuid4"`
 }
 
 type CreateProductRequest struct {
@@ -185,7 +220,14 @@ type CreateProductRequest struct {
 	Width       float64                 `form:"width" binding:"required"`
 	Height      float64                 `form:"height" binding:"required"`
 	Images      []*multipart.FileHeader `form:"images" binding:"omitempty"`
+	Options     string                  `form:"options"`  // JSON array of ProductOptionRequest
+	Variants    string                  `form:"variants"` // JSON array of ProductVariantRequest
 	ImageURLs   []string                `form:"-"`
+
+	LowStockThreshold *int `form:"lowStockThreshold" binding:"omitempty,min=0"`
+
+	ParsedOptions  []ProductOptionRequest  `form:"-"`
+	ParsedVariants []ProductVariantRequest `form:"-"`
 }
 
 type UpdateProductRequest struct {
@@ -202,7 +244,48 @@ type UpdateProductRequest struct {
 	Width       float64                 `form:"width" binding:"required"`
 	Height      float64                 `form:"height" binding:"required"`
 	Images      []*multipart.FileHeader `form:"images" binding:"omitempty"`
+	Options     string                  `form:"options"`  // JSON array of ProductOptionRequest
+	Variants    string                  `form:"variants"` // JSON array of ProductVariantRequest
 	ImageURLs   []string                `form:"-"`
+
+	LowStockThreshold *int `form:"lowStockThreshold" binding:"omitempty,min=0"`
+
+	ParsedOptions  []ProductOptionRequest  `form:"-"`
+	ParsedVariants []ProductVariantRequest `form:"-"`
+}
+
+type ProductOptionRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Values []string `json:"values" binding:"required,min=1"`
+}
+
+// ImageIndexes point at the product images, in upload order
+type ProductVariantRequest struct {
+	SKU          string            `json:"sku" binding:"required"`
+	Options      map[string]string `json:"options" binding:"required"`
+	Price        *float64          `json:"price"`
+	Stock        int               `json:"stock" binding:"min=0"`
+	Weight       *float64          `json:"weight"`
+	IsActive     *bool             `json:"isActive"`
+	ImageIndexes []int             `json:"imageIndexes"`
+}
+
+type ProductOptionResponse struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ProductVariantResponse struct {
+	ID         string            `json:"id"`
+	SKU        string            `json:"sku"`
+	Options    map[string]string `json:"options"`
+	Price      float64           `json:"price"`
+	FinalPrice float64           `json:"finalPrice"`
+	Stock      int               `json:"stock"`
+	Available  int               `json:"available"`
+	Weight     float64           `json:"weight"`
+	IsActive   bool              `json:"isActive"`
+	Images     []string          `json:"images"`
 }
 
 type ProductListResponse struct {
@@ -212,8 +295,10 @@ type ProductListResponse struct {
 	Discount      *float64 `json:"discount"`
 	Description   string   `json:"description"`
 	Price         float64  `json:"price"`
+	FinalPrice    float64  `json:"finalPrice"` // harga setelah diskon produk
 	CategoryID    string   `json:"categoryId"`
 	AverageRating float64  `json:"averageRating"`
+	ReviewCount   int      `json:"reviewCount"`
 	Category      string   `json:"category"`
 	IsActive      bool     `json:"isActive"`
 	Height        float64  `json:"height"`
@@ -222,7 +307,16 @@ type ProductListResponse struct {
 	Weight        float64  `json:"weight"`
 	IsFeatured    bool     `json:"isFeatured"`
 	Stock         int      `json:"stock"`
+	Available     int      `json:"available"` // stok dikurangi reservasi keranjang lain
+	WishlistCount int      `json:"wishlistCount"`
+	Sold          int      `json:"sold"`
+	ViewCount     int64    `json:"viewCount"`
 	Images        []string `json:"images"`
+
+	LowStockThreshold *int `json:"lowStockThreshold"`
+
+	Options  []ProductOptionResponse  `json:"options"`
+	Variants []ProductVariantResponse `json:"variants"`
 }
 
 type PaginationResponse struct {
@@ -242,6 +336,72 @@ type GetAllProductsRequest struct {
 	Sort     string  `form:"sort"`
 	Page     int     `form:"page"`
 	Limit    int     `form:"limit"`
+	InStock  *bool   `form:"inStock"`
+}
+
+// SearchFacetsResponse counts the search results per filter value, each facet ignores its own filter
+type SearchFacetsResponse struct {
+	Categories   []CategoryFacetResponse `json:"categories"`
+	PriceRanges  []PriceFacetResponse    `json:"priceRanges"`
+	Ratings      []RatingFacetResponse   `json:"ratings"`
+	Availability AvailabilityFacet       `json:"availability"`
+}
+
+type CategoryFacetResponse struct {
+	Slug  string `json:"slug"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type PriceFacetResponse struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"` // nil untuk rentang harga teratas
+	Count int      `json:"count"`
+}
+
+type RatingFacetResponse struct {
+	MinRating int `json:"minRating"`
+	Count     int `json:"count"`
+}
+
+type AvailabilityFacet struct {
+	InStock    int `json:"inStock"`
+	OutOfStock int `json:"outOfStock"`
+}
+
+type SuggestQueryParam struct {
+	Query string `form:"q" binding:"max=100"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=10"`
+}
+
+type SuggestResponse struct {
+	Products   []ProductSuggestion  `json:"products"`
+	Categories []CategorySuggestion `json:"categories"`
+	Queries    []string             `json:"queries"`
+}
+
+type ProductSuggestion struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type CategorySuggestion struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// ZeroResultQueryParam: Days is the report window, 30 when empty
+type ZeroResultQueryParam struct {
+	Days  int `form:"days" binding:"omitempty,min=1,max=365"`
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1"`
+}
+
+type ZeroResultQueryResponse struct {
+	Query          string    `json:"query"`
+	Searches       int       `json:"searches"`
+	LastSearchedAt time.Time `json:"lastSearchedAt"`
 }
 
 type ProductDetailResponse struct {
@@ -250,7 +410,13 @@ type ProductDetailResponse struct {
 	Slug          string   `json:"slug"`
 	Description   string   `json:"description"`
 	Price         float64  `json:"price"`
+	FinalPrice    float64  `json:"finalPrice"`
 	Stock         int      `json:"stock"`
+	Available     int      `json:"available"`
+	WishlistCount int      `json:"wishlistCount"`
+	ReviewCount   int      `json:"reviewCount"`
+	Sold          int      `json:"sold"`
+	ViewCount     int64    `json:"viewCount"`
 	Discount      *float64 `json:"discount"`
 	CategoryID    string   `json:"categoryId"`
 	Category      string   `json:"category"`
@@ -260,14 +426,40 @@ type ProductDetailResponse struct {
 	Weight        float64  `json:"weight"`
 	AverageRating float64  `json:"averageRating"`
 	Images        []string `json:"images"`
+
+	RatingHistogram map[int]int          `json:"ratingHistogram"` // bintang 1-5 -> jumlah ulasan
+	Breadcrumbs     []CategoryBreadcrumb `json:"breadcrumbs"`
+
+	Options  []ProductOptionResponse  `json:"options"`
+	Variants []ProductVariantResponse `json:"variants"`
+}
+
+type RecommendationQueryParam struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// RecommendedProductResponse is the product card shown in recommendation rows
+type RecommendedProductResponse struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Slug          string   `json:"slug"`
+	Image         string   `json:"image"`
+	Category      string   `json:"category"`
+	Price         float64  `json:"price"`
+	FinalPrice    float64  `json:"finalPrice"`
+	Discount      *float64 `json:"discount"`
+	AverageRating float64  `json:"averageRating"`
+	ReviewCount   int      `json:"reviewCount"`
+	Sold          int      `json:"sold"`
 }
 
 // PRODUCT, CATEGORY, BANNER REQUEST & RESPONSE  =====================
 
 // TRANSACTION REQUEST & RESPONSE  ================
 type CartItemRequest struct {
-	ProductID string `json:"productId" binding:"required,uuid4"`
-	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	ProductID string  `json:"productId" binding:"required,uuid4"`
+	VariantID *string `json:"variantId" binding:"omitempty,uuid4"`
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
 }
 
 type UpdateCartItemRequest struct {
@@ -280,6 +472,9 @@ type UnCheckedRequest struct {
 
 type CartItemResponse struct {
 	ProductID        string  `json:"productId"`
+	VariantID        string  `json:"variantId,omitempty"`
+	Variant          string  `json:"variant,omitempty"`
+	SKU              string  `json:"sku,omitempty"`
 	Name             string  `json:"name"`
 	Price            float64 `json:"price"`
 	Discount         float64 `json:"discount"`
@@ -290,6 +485,10 @@ type CartItemResponse struct {
 	Quantity         int     `json:"quantity"`
 	OriginalSubtotal float64 `json:"originalSubtotal"`
 	Subtotal         float64 `json:"subtotal"`
+
+	ReservedUntil *time.Time `json:"reservedUntil,omitempty"`
+	PriceAtAdd    float64    `json:"priceAtAdd"`
+	Warnings      []string   `json:"warnings,omitempty"`
 }
 
 type CartResponse struct {
@@ -298,17 +497,76 @@ type CartResponse struct {
 	Total float64            `json:"total"`
 }
 
+type CheckoutQuoteRequest struct {
+	AddressID   *string `json:"addressId" binding:"omitempty,uuid4"` // kosong = alamat utama
+	Courier     string  `json:"courier" binding:"required"`
+	Service     string  `json:"service" binding:"required"`
+	VoucherCode *string `json:"voucherCode"`
+
+	// kosong = bayar lewat payment gateway
+	PaymentMethod string `json:"paymentMethod" binding:"omitempty,oneof=gateway cod manual_transfer"`
+}
+
+type CheckoutQuoteItemResponse struct {
+	ProductID string  `json:"productId"`
+	VariantID string  `json:"variantId,omitempty"`
+	Name      string  `json:"name"`
+	Variant   string  `json:"variant,omitempty"`
+	Image     string  `json:"image"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+	Subtotal  float64 `json:"subtotal"`
+}
+
+type CheckoutQuoteResponse struct {
+	QuoteID   string    `json:"quoteId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	Items           []CheckoutQuoteItemResponse `json:"items"`
+	Subtotal        float64                     `json:"subtotal"`
+	VoucherCode     *string                     `json:"voucherCode"`
+	VoucherDiscount float64                     `json:"voucherDiscount"`
+	Courier         string                      `json:"courier"`
+	Service         string                      `json:"service"`
+	ETD             string                      `json:"etd"`
+	Weight          int                         `json:"weight"`
+	ShippingCost    float64                     `json:"shippingCost"`
+	Tax             float64                     `json:"tax"`
+	AmountToPay     float64                     `json:"amountToPay"`
+	PaymentMethod   string                      `json:"paymentMethod"`
+}
+
+// CheckoutRequest only carries a quote, every amount comes from the quote
 type CheckoutRequest struct {
-	Courier      string  `json:"courier" binding:"required"`
-	ShippingCost float64 `json:"shippingCost" binding:"required"`
-	VoucherCode  *string `json:"voucherCode"`
-	Note         *string `json:"note"`
+	QuoteID string  `json:"quoteId" binding:"required"`
+	Note    *string `json:"note"`
 }
 
 type CheckoutResponse struct {
 	PaymentID string `json:"paymentId"`
+	Gateway   string `json:"gateway"`
 	SnapToken string `json:"snapToken"`
 	SnapURL   string `json:"snapUrl"`
+
+	PaymentMethod string               `json:"paymentMethod"`
+	BankAccount   *BankAccountResponse `json:"bankAccount,omitempty"`
+}
+
+type BankAccountResponse struct {
+	Bank          string  `json:"bank"`
+	AccountNumber string  `json:"accountNumber"`
+	AccountName   string  `json:"accountName"`
+	Amount        float64 `json:"amount"`
+}
+
+type UploadReceiptRequest struct {
+	Image    *multipart.FileHeader `form:"image" binding:"required"`
+	ImageURL string                `form:"-"`
+}
+
+type VerifyTransferRequest struct {
+	Approved *bool  `json:"approved" binding:"required"`
+	Note     string `json:"note"`
 }
 
 type CreateVoucherRequest struct {
@@ -370,21 +628,153 @@ type PaymentResponse struct {
 	Method        string  `json:"method"`
 	Status        string  `json:"status"`
 	PaidAt        string  `json:"paidAt"`
+	ReceiptImage  *string `json:"receiptImage,omitempty"`
 }
 
 type MidtransNotificationRequest struct {
 	TransactionStatus string `json:"transaction_status"`
-	OrderID           string `json:"order_id"`
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id" binding:"required"`
+	StatusCode        string `json:"status_code" binding:"required"`
+	GrossAmount       string `json:"gross_amount" binding:"required"`
+	SignatureKey      string `json:"signature_key" binding:"required"`
 	PaymentType       string `json:"payment_type"`
 	FraudStatus       string `json:"fraud_status"`
 }
 
+type FakePaymentRequest struct {
+	Status string `json:"status" form:"status" binding:"required,oneof=settlement pending expire cancel deny"`
+}
+
+type FakePaymentResponse struct {
+	OrderID       string `json:"orderId"`
+	InvoiceNumber string `json:"invoiceNumber"`
+	Amount        int64  `json:"amount"`
+	Status        string `json:"status"`
+}
+
 type PaymentQueryParam struct {
 	Page   int    `form:"page"`
 	Limit  int    `form:"limit"`
 	Search string `form:"q"`
 	Sort   string `form:"sort"`
 	Status string `form:"status"`
+	Method string `form:"method"`
+}
+
+type ReconciliationQueryParam struct {
+	Page             int    `form:"page"`
+	Limit            int    `form:"limit"`
+	RunID            string `form:"runId"`
+	Action           string `form:"action"`
+	IncludeUnchanged bool   `form:"includeUnchanged"`
+	From             string `form:"from"` // YYYY-MM-DD
+	To               string `form:"to"`   // YYYY-MM-DD
+}
+
+type ReconciliationSummary struct {
+	RunID      string `json:"runId"`
+	Checked    int    `json:"checked"`
+	MarkedPaid int    `json:"markedPaid"`
+	Failed     int    `json:"markedFailed"`
+	Refunded   int    `json:"refunded"`
+	NeedReview int    `json:"needReview"`
+	Errors     int    `json:"errors"`
+}
+
+type RestockSubscriptionRequest struct {
+	ProductID string  `json:"productId" binding:"required,uuid"`
+	VariantID *string `json:"variantId"`
+}
+
+type RestockSubscriptionResponse struct {
+	ID          string     `json:"id"`
+	ProductID   string     `json:"productId"`
+	ProductName string     `json:"productName"`
+	ProductSlug string     `json:"productSlug"`
+	VariantID   string     `json:"variantId,omitempty"`
+	Variant     string     `json:"variant,omitempty"`
+	Image       string     `json:"image"`
+	InStock     bool       `json:"inStock"`
+	NotifiedAt  *time.Time `json:"notifiedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type WishlistRequest struct {
+	ProductID string  `json:"productId" binding:"required,uuid"`
+	VariantID *string `json:"variantId"`
+}
+
+// WishlistToCartRequest: VariantID picks a variant when the wishlisted item has none
+type WishlistToCartRequest struct {
+	VariantID *string `json:"variantId"`
+	Quantity  int     `json:"quantity" binding:"omitempty,min=1"`
+}
+
+type WishlistItemResponse struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"productId"`
+	ProductName string    `json:"productName"`
+	ProductSlug string    `json:"productSlug"`
+	VariantID   string    `json:"variantId,omitempty"`
+	Variant     string    `json:"variant,omitempty"`
+	Image       string    `json:"image"`
+	Price       float64   `json:"price"`
+	FinalPrice  float64   `json:"finalPrice"`
+	InStock     bool      `json:"inStock"`
+	IsActive    bool      `json:"isActive"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// StockAdjustmentRequest: Quantity is the signed change for an adjustment
+// and the counted stock for a stock take
+type StockAdjustmentRequest struct {
+	ProductID string  `json:"productId" binding:"required"`
+	VariantID *string `json:"variantId"`
+	Type      string  `json:"type" binding:"required,oneof=adjustment stock_take"`
+	Quantity  int     `json:"quantity"`
+	Reason    string  `json:"reason" binding:"required"`
+}
+
+type StockMovementQueryParam struct {
+	Page      int    `form:"page"`
+	Limit     int    `form:"limit"`
+	ProductID string `form:"productId"`
+	VariantID string `form:"variantId"`
+	Type      string `form:"type"`
+	From      string `form:"from"` // YYYY-MM-DD
+	To        string `form:"to"`   // YYYY-MM-DD
+}
+
+type StockMovementResponse struct {
+	ID            string    `json:"id"`
+	ProductID     string    `json:"productId"`
+	ProductName   string    `json:"productName"`
+	VariantID     string    `json:"variantId,omitempty"`
+	SKU           string    `json:"sku,omitempty"`
+	Type          string    `json:"type"`
+	Quantity      int       `json:"quantity"`
+	StockAfter    int       `json:"stockAfter"`
+	ReferenceType string    `json:"referenceType,omitempty"`
+	ReferenceID   string    `json:"referenceId,omitempty"`
+	ActorType     string    `json:"actorType"`
+	ActorID       string    `json:"actorId,omitempty"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type StockMismatch struct {
+	ProductID   string `json:"productId"`
+	ProductName string `json:"productName"`
+	VariantID   string `json:"variantId,omitempty"`
+	SKU         string `json:"sku,omitempty"`
+	Stock       int    `json:"stock"`
+	LedgerStock int    `json:"ledgerStock"`
+}
+
+type StockConsistencyResponse struct {
+	Consistent bool            `json:"consistent"`
+	Mismatches []StockMismatch `json:"mismatches"`
 }
 
 type OrderQueryParam struct {
@@ -409,6 +799,7 @@ type OrderListResponse struct {
 type ItemsResponse struct {
 	ProductID   string `json:"id"`
 	ProductName string `json:"name"`
+	Variant     string `json:"variant,omitempty"`
 	Quantity    int    `json:"quantity"`
 	Image       string `json:"image"`
 }
@@ -430,15 +821,18 @@ type OrderDetailResponse struct {
 	VoucherDiscount float64 `json:"voucherDiscount"`
 	Tax             float64 `json:"tax"`
 
-	AmountToPay float64               `json:"amountToPay"`
-	CreatedAt   time.Time             `json:"createdAt"`
-	Items       []ItemsDetailResponse `json:"items"`
+	AmountToPay    float64               `json:"amountToPay"`
+	RefundedAmount float64               `json:"refundedAmount"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	Items          []ItemsDetailResponse `json:"items"`
 }
 
 type ItemsDetailResponse struct {
 	ItemID      string  `json:"id"`
 	ProductName string  `json:"name"`
 	ProductSlug string  `json:"slug"`
+	Variant     string  `json:"variant,omitempty"`
+	SKU         string  `json:"sku,omitempty"`
 	Image       string  `json:"image"`
 	IsReviewed  bool    `json:"isReviewed"`
 	Price       float64 `json:"price"`
@@ -467,16 +861,49 @@ type ConfirmDeliveryResponse struct {
 	Delivered time.Time `json:"deliveredAt"`
 }
 
+// ReviewQueryParam: Sort is newest (default), helpful, rating_desc or rating_asc
 type ReviewQueryParam struct {
-	Page  int `form:"page" binding:"omitempty,min=1"`
-	Limit int `form:"limit" binding:"omitempty,min=1"`
+	Page       int    `form:"page" binding:"omitempty,min=1"`
+	Limit      int    `form:"limit" binding:"omitempty,min=1"`
+	Sort       string `form:"sort" binding:"omitempty,oneof=newest helpful rating_desc rating_asc"`
+	Rating     int    `form:"rating" binding:"omitempty,min=1,max=5"`
+	WithPhotos bool   `form:"withPhotos"`
 }
 
 type CreateReviewRequest struct {
-	Rating   int                   `form:"rating" binding:"required,min=1,max=5"`
-	Comment  string                `form:"comment" binding:"omitempty"`
-	Image    *multipart.FileHeader `form:"image" binding:"required"`
-	ImageURL string                `form:"-"`
+	Rating    int                     `form:"rating" binding:"required,min=1,max=5"`
+	Comment   string                  `form:"comment" binding:"omitempty"`
+	Images    []*multipart.FileHeader `form:"images" binding:"omitempty,max=5"`
+	ImageURLs []string                `form:"-"`
+}
+
+// UpdateReviewRequest keeps the current images when no new ones are uploaded
+type UpdateReviewRequest struct {
+	Rating    int                     `form:"rating" binding:"required,min=1,max=5"`
+	Comment   string                  `form:"comment" binding:"omitempty"`
+	Images    []*multipart.FileHeader `form:"images" binding:"omitempty,max=5"`
+	ImageURLs []string                `form:"-"`
+}
+
+type ReviewModerationQueryParam struct {
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1"`
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected"`
+	Reason string `json:"reason"`
+}
+
+type ReviewReplyRequest struct {
+	Body string `json:"body" binding:"required,min=2,max=2000"`
+}
+
+type ReviewReplyResponse struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 type ReviewResponse struct {
@@ -487,8 +914,18 @@ type ReviewResponse struct {
 	ProductID string    `json:"productId"`
 	Rating    int       `json:"rating"`
 	Comment   string    `json:"comment"`
-	Image     *string   `json:"images,omitempty"`
+	Images    []string  `json:"images"`
 	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Verified  bool      `json:"verified"` // ditulis dari pesanan yang sudah diterima
+
+	HelpfulCount int                  `json:"helpfulCount"`
+	Reply        *ReviewReplyResponse `json:"reply"`
+
+	// ? hanya diisi untuk antrean moderasi admin
+	ProductName   string `json:"productName,omitempty"`
+	Status        string `json:"status,omitempty"`
+	FlaggedReason string `json:"flaggedReason,omitempty"`
 }
 
 type ShippingCostRequest struct {
@@ -503,6 +940,82 @@ type CancelOrderResponse struct {
 	Status  string `json:"status"`
 }
 
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=process completed canceled"`
+	Reason string `json:"reason"`
+}
+
+type OrderStatusHistoryResponse struct {
+	FromStatus string    `json:"fromStatus"`
+	ToStatus   string    `json:"toStatus"`
+	ActorType  string    `json:"actorType"`
+	ActorID    *string   `json:"actorId,omitempty"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type CreateReturnRequest struct {
+	OrderID     string                  `form:"orderId" binding:"required,uuid"`
+	Reason      string                  `form:"reason" binding:"required,min=10"`
+	Items       string                  `form:"items" binding:"required"` // JSON array of ReturnItemRequest
+	Images      []*multipart.FileHeader `form:"images" binding:"required"`
+	ImageURLs   []string                `form:"-"`
+	ParsedItems []ReturnItemRequest     `form:"-"`
+}
+
+type ReturnItemRequest struct {
+	OrderItemID string `json:"orderItemId"`
+	Quantity    int    `json:"quantity"`
+}
+
+type ReviewReturnRequest struct {
+	Note string `json:"note"`
+}
+
+type ReceiveReturnRequest struct {
+	Restock *bool `json:"restock"`
+}
+
+type RefundReturnRequest struct {
+	// kosongkan untuk refund penuh sesuai nilai item yang diretur
+	Amount *float64 `json:"amount" binding:"omitempty,gt=0"`
+	Reason string   `json:"reason"`
+}
+
+type ReturnQueryParam struct {
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+	Status string `form:"status"`
+}
+
+type ReturnItemResponse struct {
+	OrderItemID string  `json:"orderItemId"`
+	ProductID   string  `json:"productId"`
+	ProductName string  `json:"name"`
+	Variant     string  `json:"variant,omitempty"`
+	Image       string  `json:"image"`
+	Quantity    int     `json:"quantity"`
+	Amount      float64 `json:"amount"`
+}
+
+type ReturnResponse struct {
+	ID              string               `json:"id"`
+	OrderID         string               `json:"orderId"`
+	InvoiceNumber   string               `json:"invoiceNumber"`
+	UserID          string               `json:"userId"`
+	Status          string               `json:"status"`
+	Reason          string               `json:"reason"`
+	AdminNote       *string              `json:"adminNote,omitempty"`
+	RequestedAmount float64              `json:"requestedAmount"`
+	RefundAmount    float64              `json:"refundAmount"`
+	RefundReference string               `json:"refundReference,omitempty"`
+	Images          []string             `json:"images"`
+	Items           []ReturnItemResponse `json:"items"`
+	ReceivedAt      *time.Time           `json:"receivedAt,omitempty"`
+	RefundedAt      *time.Time           `json:"refundedAt,omitempty"`
+	CreatedAt       time.Time            `json:"createdAt"`
+}
+
 // ORDER, TRANSACTION, REVIEW REQUEST & RESPONSE  ================
 
 // NOTIFICATIONS REQUEST & RESPONSE ================
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"os"
 	"server/internal/dto"
@@ -12,10 +13,31 @@ import (
 
 type AuthHandler struct {
 	authService services.AuthService
+	cartService services.CartService
 }
 
-func NewAuthHandler(authService services.AuthService) *AuthHandler {
-	return &AuthHandler{authService}
+func NewAuthHandler(authService services.AuthService, cartService services.CartService) *AuthHandler {
+	return &AuthHandler{authService, cartService}
+}
+
+// mergeGuestCart carries the visitor's cart over to the account that just signed in
+func (h *AuthHandler) mergeGuestCart(c *gin.Context, userID string) {
+	tokenString, err := c.Cookie("guestCart")
+	if err != nil || tokenString == "" {
+		return
+	}
+	guestID, err := utils.DecodeGuestCartToken(tokenString)
+	if err != nil {
+		utils.ClearGuestCartCookie(c)
+		return
+	}
+
+	// ? login tetap berhasil walaupun merge gagal, keranjang tamu masih tersimpan
+	if err := h.cartService.MergeGuestCart(guestID, userID); err != nil {
+		log.Printf("Failed merging guest cart %s into user %s: %v", guestID, userID, err)
+		return
+	}
+	utils.ClearGuestCartCookie(c)
 }
 
 func (h *AuthHandler) SendOTP(c *gin.Context) {
@@ -47,6 +69,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	utils.SetRefreshTokenCookie(c, tokens.RefreshToken)
 
+	h.mergeGuestCart(c, tokens.UserID)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Register Successfully"})
 }
 
@@ -79,6 +103,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	utils.SetAccessTokenCookie(c, tokens.AccessToken)
 	utils.SetRefreshTokenCookie(c, tokens.RefreshToken)
 
+	h.mergeGuestCart(c, tokens.UserID)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Login Successfully"})
 }
 
@@ -163,5 +189,7 @@ func (h *AuthHandler) GoogleOAuthCallback(c *gin.Context) {
 
 	utils.SetRefreshTokenCookie(c, tokens.RefreshToken)
 
+	h.mergeGuestCart(c, tokens.UserID)
+
 	c.Redirect(http.StatusTemporaryRedirect, os.Getenv("FRONTEND_REDIRECT_URL"))
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"server/internal/dto"
 	"server/internal/services"
@@ -11,14 +12,20 @@ import (
 
 type CartHandler struct {
 	cartService services.CartService
+	ownerID     func(c *gin.Context) string
 }
 
 func NewCartHandler(cartService services.CartService) *CartHandler {
-	return &CartHandler{cartService}
+	return &CartHandler{cartService, utils.MustGetUserID}
+}
+
+// NewGuestCartHandler serves the cart endpoints to visitors identified by the guestCart cookie
+func NewGuestCartHandler(guestCartService services.CartService) *CartHandler {
+	return &CartHandler{guestCartService, utils.MustGetGuestID}
 }
 
 func (h *CartHandler) GetCart(c *gin.Context) {
-	userID := utils.MustGetUserID(c)
+	userID := h.ownerID(c)
 	items, total, err := h.cartService.GetCart(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
@@ -28,12 +35,16 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 }
 
 func (h *CartHandler) AddToCart(c *gin.Context) {
-	userID := utils.MustGetUserID(c)
+	userID := h.ownerID(c)
 	var req dto.CartItemRequest
 	if !utils.BindAndValidateJSON(c, &req) {
 		return
 	}
 	if err := h.cartService.AddToCart(userID, req); err != nil {
+		if errors.Is(err, services.ErrOutOfStock) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error(), "canSubscribeRestock": true})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
@@ -41,14 +52,14 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 }
 
 func (h *CartHandler) UpdateQuantity(c *gin.Context) {
-	userID := utils.MustGetUserID(c)
+	userID := h.ownerID(c)
 	productID := c.Param("productId")
 
 	var req dto.UpdateCartItemRequest
 	if !utils.BindAndValidateJSON(c, &req) {
 		return
 	}
-	if err := h.cartService.UpdateQuantity(userID, productID, req.Quantity); err != nil {
+	if err := h.cartService.UpdateQuantity(userID, productID, c.Query("variantId"), req.Quantity); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
@@ -56,9 +67,9 @@ func (h *CartHandler) UpdateQuantity(c *gin.Context) {
 }
 
 func (h *CartHandler) RemoveItem(c *gin.Context) {
-	userID := utils.MustGetUserID(c)
+	userID := h.ownerID(c)
 	productID := c.Param("productId")
-	if err := h.cartService.RemoveItem(userID, productID); err != nil {
+	if err := h.cartService.RemoveItem(userID, productID, c.Query("variantId")); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
@@ -66,7 +77,7 @@ func (h *CartHandler) RemoveItem(c *gin.Context) {
 }
 
 func (h *CartHandler) ClearCart(c *gin.Context) {
-	userID := utils.MustGetUserID(c)
+	userID := h.ownerID(c)
 	if err := h.cartService.ClearCart(userID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
@@ -75,13 +86,59 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 }
 
 func (h *CartHandler) ToggleChecked(c *gin.Context) {
-	userID := utils.MustGetUserID(c)
+	userID := h.ownerID(c)
 	productID := c.Param("productId")
 
-	if err := h.cartService.ToggleItemChecked(userID, productID); err != nil {
+	if err := h.cartService.ToggleItemChecked(userID, productID, c.Query("variantId")); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Cart item check status updated"})
 }
+
+// AcknowledgePrices clears the price_changed warnings once the customer has seen the new prices
+func (h *CartHandler) AcknowledgePrices(c *gin.Context) {
+	userID := h.ownerID(c)
+	if err := h.cartService.AcknowledgePriceChanges(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Cart prices acknowledged"})
+}
+
+func (h *CartHandler) GetSavedItems(c *gin.Context) {
+	userID := h.ownerID(c)
+	items, err := h.cartService.GetSavedItems(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func (h *CartHandler) SaveForLater(c *gin.Context) {
+	userID := h.ownerID(c)
+	productID := c.Param("productId")
+
+	if err := h.cartService.SaveForLater(userID, productID, c.Query("variantId")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Item saved for later"})
+}
+
+func (h *CartHandler) MoveToCart(c *gin.Context) {
+	userID := h.ownerID(c)
+	productID := c.Param("productId")
+
+	if err := h.cartService.MoveToCart(userID, productID, c.Query("variantId")); err != nil {
+		if errors.Is(err, services.ErrOutOfStock) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error(), "canSubscribeRestock": true})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Item moved to cart"})
+}
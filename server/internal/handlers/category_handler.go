@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"server/internal/dto"
 	"server/internal/services"
@@ -37,6 +38,26 @@ func (h *CategoryHandler) GetAllCategories(c *gin.Context) {
 	})
 }
 
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	tree, err := h.categoryService.GetCategoryTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch categories", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tree})
+}
+
+func (h *CategoryHandler) GetCategoryBySlug(c *gin.Context) {
+	category, err := h.categoryService.GetCategoryBySlug(c.Param("slug"))
+	if err != nil {
+		c.JSON(categoryErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": category})
+}
+
 func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 	var req dto.CreateCategoryRequest
 	if !utils.BindAndValidateForm(c, &req) {
@@ -52,7 +73,7 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 
 	if err := h.categoryService.CreateCategory(req); err != nil {
 		utils.CleanupImageOnError(req.ImageURL)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create category", "error": err.Error()})
+		c.JSON(categoryErrorStatus(err), gin.H{"message": "Failed to create category", "error": err.Error()})
 		return
 	}
 
@@ -78,7 +99,7 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 
 	if err := h.categoryService.UpdateCategory(categoryID, req); err != nil {
 		utils.CleanupImageOnError(req.ImageURL)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update category", "error": err.Error()})
+		c.JSON(categoryErrorStatus(err), gin.H{"message": "Failed to update category", "error": err.Error()})
 		return
 	}
 
@@ -88,10 +109,29 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.categoryService.DeleteCategory(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete category", "error": err.Error()})
+	var param dto.DeleteCategoryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(id, param); err != nil {
+		c.JSON(categoryErrorStatus(err), gin.H{"message": "Failed to delete category", "error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
+
+func categoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrParentNotFound), errors.Is(err, services.ErrCategoryCycle),
+		errors.Is(err, services.ErrInvalidCategoryMove):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrCategoryInUse):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -5,37 +5,53 @@ import (
 )
 
 type Handlers struct {
-	AdminHandler        *AdminHandler
-	AuthHandler         *AuthHandler
-	VoucherHandler      *VoucherHandler
-	ProductHandler      *ProductHandler
-	PaymentHandler      *PaymentHandler
-	ProfileHandler      *ProfileHandler
-	CartHandler         *CartHandler
-	OrderHandler        *OrderHandler
-	AddressHandler      *AddressHandler
-	LocationHandler     *LocationHandler
-	CategoryHandler     *CategoryHandler
-	NotificationHandler *NotificationHandler
-	BannerHandler       *BannerHandler
-	ReviewHandler       *ReviewHandler
+	AdminHandler          *AdminHandler
+	AuthHandler           *AuthHandler
+	VoucherHandler        *VoucherHandler
+	ProductHandler        *ProductHandler
+	PaymentHandler        *PaymentHandler
+	ProfileHandler        *ProfileHandler
+	CartHandler           *CartHandler
+	GuestCartHandler      *CartHandler
+	OrderHandler          *OrderHandler
+	AddressHandler        *AddressHandler
+	LocationHandler       *LocationHandler
+	CategoryHandler       *CategoryHandler
+	NotificationHandler   *NotificationHandler
+	BannerHandler         *BannerHandler
+	ReviewHandler         *ReviewHandler
+	ReturnHandler         *ReturnHandler
+	ReconciliationHandler *ReconciliationHandler
+	InventoryHandler      *InventoryHandler
+	StockAlertHandler     *StockAlertHandler
+	WishlistHandler       *WishlistHandler
+	SearchHandler         *SearchHandler
+	RecommendationHandler *RecommendationHandler
 }
 
-func InitHandlers(s *services.Services) *Handlers{
+func InitHandlers(s *services.Services) *Handlers {
 	return &Handlers{
-		AdminHandler:        NewAdminHandler(s.AdminService),
-		AuthHandler:         NewAuthHandler(s.AuthService),
-		ProductHandler:      NewProductHandler(s.ProductService),
-		VoucherHandler:      NewVoucherHandler(s.VoucherService),
-		PaymentHandler:      NewPaymentHandler(s.PaymentService),
-		ProfileHandler:      NewProfileHandler(s.ProfileService),
-		CartHandler:         NewCartHandler(s.CartService),
-		OrderHandler:        NewOrderHandler(s.OrderService),
-		LocationHandler:     NewLocationHandler(s.LocationService),
-		AddressHandler:      NewAddressHandler(s.AddressService),
-		CategoryHandler:     NewCategoryHandler(s.CategoryService),
-		NotificationHandler: NewNotificationHandler(s.NotificationService),
-		BannerHandler:       NewBannerHandler(s.BannerService),
-		ReviewHandler:       NewReviewHandler(s.ReviewService),
+		AdminHandler:          NewAdminHandler(s.AdminService),
+		AuthHandler:           NewAuthHandler(s.AuthService, s.CartService),
+		ProductHandler:        NewProductHandler(s.ProductService),
+		VoucherHandler:        NewVoucherHandler(s.VoucherService),
+		PaymentHandler:        NewPaymentHandler(s.PaymentService),
+		ProfileHandler:        NewProfileHandler(s.ProfileService),
+		CartHandler:           NewCartHandler(s.CartService),
+		GuestCartHandler:      NewGuestCartHandler(s.GuestCartService),
+		OrderHandler:          NewOrderHandler(s.OrderService),
+		LocationHandler:       NewLocationHandler(s.LocationService),
+		AddressHandler:        NewAddressHandler(s.AddressService),
+		CategoryHandler:       NewCategoryHandler(s.CategoryService),
+		NotificationHandler:   NewNotificationHandler(s.NotificationService),
+		BannerHandler:         NewBannerHandler(s.BannerService),
+		ReviewHandler:         NewReviewHandler(s.ReviewService),
+		ReturnHandler:         NewReturnHandler(s.ReturnService),
+		ReconciliationHandler: NewReconciliationHandler(s.ReconciliationService),
+		InventoryHandler:      NewInventoryHandler(s.InventoryService),
+		StockAlertHandler:     NewStockAlertHandler(s.StockAlertService),
+		WishlistHandler:       NewWishlistHandler(s.WishlistService),
+		SearchHandler:         NewSearchHandler(s.SearchService),
+		RecommendationHandler: NewRecommendationHandler(s.RecommendationService),
 	}
 }
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"server/internal/dto"
+	"server/internal/services"
+	"server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryHandler struct {
+	service services.InventoryService
+}
+
+func NewInventoryHandler(s services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{service: s}
+}
+
+func (h *InventoryHandler) AdjustStock(c *gin.Context) {
+	adminID := utils.MustGetUserID(c)
+
+	var req dto.StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	movement, err := h.service.AdjustStock(adminID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if movement == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Stock already matches the counted quantity"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Stock adjusted successfully", "data": movement})
+}
+
+func (h *InventoryHandler) GetMovements(c *gin.Context) {
+	var param dto.StockMovementQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	result, pagination, err := h.service.GetMovements(param)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       result,
+		"pagination": pagination,
+	})
+}
+
+func (h *InventoryHandler) CheckConsistency(c *gin.Context) {
+	report, err := h.service.CheckConsistency()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"server/internal/config"
 	"server/internal/dto"
 	"server/internal/services"
 	"server/internal/utils"
@@ -17,6 +18,20 @@ func NewOrderHandler(s services.OrderService) *OrderHandler {
 	return &OrderHandler{service: s}
 }
 
+func (h *OrderHandler) Quote(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+	var req dto.CheckoutQuoteRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+	resp, err := h.service.Quote(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 func (h *OrderHandler) Checkout(c *gin.Context) {
 	userID := utils.MustGetUserID(c)
 	var req dto.CheckoutRequest
@@ -71,7 +86,8 @@ func (h *OrderHandler) CreateShipment(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.CreateShipment(orderID, req)
+	adminID := utils.MustGetUserID(c)
+	result, err := h.service.CreateShipment(adminID, orderID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
@@ -82,7 +98,21 @@ func (h *OrderHandler) CreateShipment(c *gin.Context) {
 
 func (h *OrderHandler) UpdateShipmentStatus(c *gin.Context) {
 	orderID := c.Param("orderID")
-	result, err := h.service.ConfirmOrderDelivered(orderID)
+	adminID := utils.MustGetUserID(c)
+	result, err := h.service.ConfirmOrderDelivered(adminID, orderID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	orderID := c.Param("orderID")
+	userID := utils.MustGetUserID(c)
+
+	result, err := h.service.CancelOrder(userID, orderID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
@@ -91,6 +121,37 @@ func (h *OrderHandler) UpdateShipmentStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
+	orderID := c.Param("orderID")
+	adminID := utils.MustGetUserID(c)
+
+	var req dto.UpdateOrderStatusRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	if err := h.service.UpdateOrderStatus(adminID, orderID, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order status updated"})
+}
+
+func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
+	orderID := c.Param("orderID")
+	userID := utils.MustGetUserID(c)
+	role := utils.MustGetRole(c)
+
+	result, err := h.service.GetOrderHistory(userID, role, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
 func (h *OrderHandler) GetShipmentInfo(c *gin.Context) {
 	orderID := c.Param("orderID")
 
@@ -109,12 +170,9 @@ func (h *OrderHandler) CheckShippingCost(c *gin.Context) {
 		return
 	}
 
-	const originCityID = 52
-	const originProvinceID = 2
-
 	costs, err := utils.EstimateShippingRates(
-		originProvinceID,
-		originCityID,
+		config.ShopOriginProvinceID,
+		config.ShopOriginCityID,
 		req.DestinationProvinceID,
 		req.DestinationCityID,
 		req.Weight,
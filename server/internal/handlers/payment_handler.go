@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
 	"net/http"
+	"net/url"
 	"server/internal/dto"
 	"server/internal/services"
 	"server/internal/utils"
@@ -18,12 +23,27 @@ func NewPaymentHandler(paymentService services.PaymentService) *PaymentHandler {
 }
 
 func (h *PaymentHandler) HandlePaymentNotification(c *gin.Context) {
-	var notif dto.MidtransNotificationRequest
-	if !utils.BindAndValidateJSON(c, &notif) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request body"})
 		return
 	}
 
-	if err := h.paymentService.HandlePaymentNotification(notif); err != nil {
+	if err := h.paymentService.HandlePaymentNotification(body); err != nil {
+		if errors.Is(err, services.ErrInvalidSignature) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "Invalid notification signature"})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidNotification) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid notification payload"})
+			return
+		}
+		// ? order milik environment lain atau test ping, dijawab 200 supaya gateway berhenti mengirim ulang
+		if errors.Is(err, services.ErrPaymentNotFound) {
+			log.Printf("Payment notification ignored: %v\n", err)
+			c.JSON(http.StatusOK, gin.H{"message": "Notification ignored, payment not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to process payment notification", "error": err.Error()})
 		return
 	}
@@ -50,3 +70,101 @@ func (h *PaymentHandler) GetAllUserPayments(c *gin.Context) {
 		"pagination": pagination,
 	})
 }
+
+// ** halaman bayar lokal untuk gateway fake, hanya aktif kalau PAYMENT_GATEWAY=fake
+func (h *PaymentHandler) FakePayPage(c *gin.Context) {
+	orderID := c.Param("orderID")
+
+	token := c.Query("token")
+
+	payment, err := h.paymentService.GetFakePayment(orderID, token)
+	if err != nil {
+		c.JSON(fakePaymentErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	action := html.EscapeString(fmt.Sprintf("/api/payments/fake/%s?token=%s", payment.OrderID, url.QueryEscape(token)))
+	page := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><title>Fake Payment</title></head>
+<body>
+<h1>Fake Payment</h1>
+<p>Invoice: %s</p>
+<p>Amount: Rp%d</p>
+<p>Status: %s</p>
+<form method="POST" action="%s"><input type="hidden" name="status" value="settlement"><button>Pay</button></form>
+<form method="POST" action="%s"><input type="hidden" name="status" value="expire"><button>Expire</button></form>
+</body>
+</html>`, html.EscapeString(payment.InvoiceNumber), payment.Amount, html.EscapeString(payment.Status), action, action)
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+}
+
+func (h *PaymentHandler) SimulateFakePayment(c *gin.Context) {
+	var req dto.FakePaymentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request", "error": err.Error()})
+		return
+	}
+
+	if err := h.paymentService.SimulateFakePayment(c.Param("orderID"), c.Query("token"), req.Status); err != nil {
+		c.JSON(fakePaymentErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fake payment notification processed"})
+}
+
+func fakePaymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrFakeGatewayDisabled):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidSignature):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func (h *PaymentHandler) UploadTransferReceipt(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	var req dto.UploadReceiptRequest
+	if !utils.BindAndValidateForm(c, &req) {
+		return
+	}
+
+	uploadedURL, err := utils.UploadImageWithValidation(req.Image)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Image upload failed",
+			"error":   err.Error(),
+		})
+		return
+	}
+	req.ImageURL = uploadedURL
+
+	if err := h.paymentService.UploadTransferReceipt(userID, c.Param("orderID"), req); err != nil {
+		utils.CleanupImageOnError(uploadedURL)
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer receipt uploaded, waiting for verification"})
+}
+
+func (h *PaymentHandler) VerifyTransfer(c *gin.Context) {
+	adminID := utils.MustGetUserID(c)
+
+	var req dto.VerifyTransferRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	if err := h.paymentService.VerifyTransfer(adminID, c.Param("orderID"), req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer verification saved"})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"server/internal/dto"
 	"server/internal/services"
@@ -29,6 +30,21 @@ func extractUploadedImages(c *gin.Context) ([]string, error) {
 	return utils.UploadMultipleImagesWithValidation(files)
 }
 
+// parseVariantFields decodes the JSON form fields, an empty variants field leaves variants untouched
+func parseVariantFields(options, variants string, parsedOptions *[]dto.ProductOptionRequest, parsedVariants *[]dto.ProductVariantRequest) bool {
+	if options != "" {
+		if err := json.Unmarshal([]byte(options), parsedOptions); err != nil {
+			return false
+		}
+	}
+	if variants != "" {
+		if err := json.Unmarshal([]byte(variants), parsedVariants); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var req dto.CreateProductRequest
 
@@ -36,6 +52,11 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	if !parseVariantFields(req.Options, req.Variants, &req.ParsedOptions, &req.ParsedVariants) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid options or variants format"})
+		return
+	}
+
 	req.IsActive, _ = utils.ParseBoolFormField(c, "isActive")
 	req.IsFeatured, _ = utils.ParseBoolFormField(c, "isFeatured")
 
@@ -46,7 +67,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	}
 	req.ImageURLs = uploadedURLs
 
-	if err := h.ProductService.CreateProduct(req); err != nil {
+	if err := h.ProductService.CreateProduct(utils.MustGetUserID(c), req); err != nil {
 		for _, img := range uploadedURLs {
 			utils.CleanupImageOnError(img)
 		}
@@ -65,6 +86,11 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	if !parseVariantFields(req.Options, req.Variants, &req.ParsedOptions, &req.ParsedVariants) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid options or variants format"})
+		return
+	}
+
 	req.IsActive, _ = utils.ParseBoolFormField(c, "isActive")
 	req.IsFeatured, _ = utils.ParseBoolFormField(c, "isFeatured")
 
@@ -75,7 +101,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	}
 	req.ImageURLs = uploadedURLs
 
-	if err := h.ProductService.UpdateProduct(productID, req); err != nil {
+	if err := h.ProductService.UpdateProduct(utils.MustGetUserID(c), productID, req); err != nil {
 		for _, img := range uploadedURLs {
 			utils.CleanupImageOnError(img)
 		}
@@ -103,7 +129,7 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 		return
 	}
 
-	result, pagination, err := h.ProductService.SearchProducts(params)
+	result, pagination, facets, err := h.ProductService.SearchProducts(params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to search products", "error": err.Error()})
 		return
@@ -112,12 +138,15 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"data":       result,
 		"pagination": pagination,
+		"facets":     facets,
 	})
 }
 
 func (h *ProductHandler) GetProductBySlug(c *gin.Context) {
 	slug := c.Param("slug")
-	product, err := h.ProductService.GetProductBySlug(slug)
+	// ? route ini publik, userID hanya ada kalau pengunjung sudah login
+	viewerID := c.GetString("userID")
+	product, err := h.ProductService.GetProductBySlug(slug, viewerID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Product not found"})
 		return
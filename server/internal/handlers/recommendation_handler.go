@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"server/internal/dto"
+	"server/internal/services"
+	"server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecommendationHandler struct {
+	recommendationService services.RecommendationService
+}
+
+func NewRecommendationHandler(recommendationService services.RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{recommendationService}
+}
+
+func (h *RecommendationHandler) GetRelated(c *gin.Context) {
+	var param dto.RecommendationQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	products, err := h.recommendationService.GetRelated(c.Param("slug"), param)
+	if err != nil {
+		c.JSON(recommendationErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": products})
+}
+
+func (h *RecommendationHandler) GetBoughtTogether(c *gin.Context) {
+	var param dto.RecommendationQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	products, err := h.recommendationService.GetBoughtTogether(c.Param("slug"), param)
+	if err != nil {
+		c.JSON(recommendationErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": products})
+}
+
+func (h *RecommendationHandler) GetRecommendedForYou(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	var param dto.RecommendationQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	products, err := h.recommendationService.GetRecommendedForYou(userID, param)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	// ? feed personal, jangan di-cache bersama
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, gin.H{"data": products})
+}
+
+func recommendationErrorStatus(err error) int {
+	if errors.Is(err, services.ErrProductNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"server/internal/dto"
+	"server/internal/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler struct {
+	service services.ReconciliationService
+}
+
+func NewReconciliationHandler(s services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{service: s}
+}
+
+func (h *ReconciliationHandler) RunReconciliation(c *gin.Context) {
+	summary, err := h.service.Reconcile()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+func (h *ReconciliationHandler) GetReports(c *gin.Context) {
+	var param dto.ReconciliationQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	result, pagination, err := h.service.GetReports(param)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       result,
+		"pagination": pagination,
+	})
+}
+
+func (h *ReconciliationHandler) ExportReports(c *gin.Context) {
+	var param dto.ReconciliationQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	data, err := h.service.ExportReports(param)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("reconciliation-%s.csv", time.Now().Format("20060102-150405"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "text/csv", data)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/internal/dto"
+	"server/internal/services"
+	"server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxReturnImages = 5
+
+type ReturnHandler struct {
+	service services.ReturnService
+}
+
+func NewReturnHandler(s services.ReturnService) *ReturnHandler {
+	return &ReturnHandler{service: s}
+}
+
+func (h *ReturnHandler) CreateReturn(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	var req dto.CreateReturnRequest
+	if !utils.BindAndValidateForm(c, &req) {
+		return
+	}
+
+	if err := json.Unmarshal([]byte(req.Items), &req.ParsedItems); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid items format"})
+		return
+	}
+
+	if len(req.Images) > maxReturnImages {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Too many images, maximum is 5"})
+		return
+	}
+
+	for _, image := range req.Images {
+		url, err := utils.UploadImageWithValidation(image)
+		if err != nil {
+			utils.CleanupImagesOnError(req.ImageURLs)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Image upload failed",
+				"error":   err.Error(),
+			})
+			return
+		}
+		req.ImageURLs = append(req.ImageURLs, url)
+	}
+
+	result, err := h.service.CreateReturn(userID, req)
+	if err != nil {
+		utils.CleanupImagesOnError(req.ImageURLs)
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+func (h *ReturnHandler) GetReturns(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+	role := utils.MustGetRole(c)
+
+	var param dto.ReturnQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	result, pagination, err := h.service.GetReturns(userID, role, param)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       result,
+		"pagination": pagination,
+	})
+}
+
+func (h *ReturnHandler) GetReturnDetail(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+	role := utils.MustGetRole(c)
+
+	result, err := h.service.GetReturnDetail(userID, role, c.Param("returnID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *ReturnHandler) ApproveReturn(c *gin.Context) {
+	adminID := utils.MustGetUserID(c)
+
+	var req dto.ReviewReturnRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	result, err := h.service.ApproveReturn(adminID, c.Param("returnID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *ReturnHandler) RejectReturn(c *gin.Context) {
+	adminID := utils.MustGetUserID(c)
+
+	var req dto.ReviewReturnRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	result, err := h.service.RejectReturn(adminID, c.Param("returnID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *ReturnHandler) ReceiveReturn(c *gin.Context) {
+	adminID := utils.MustGetUserID(c)
+
+	var req dto.ReceiveReturnRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	result, err := h.service.ReceiveReturn(adminID, c.Param("returnID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *ReturnHandler) RefundReturn(c *gin.Context) {
+	adminID := utils.MustGetUserID(c)
+
+	var req dto.RefundReturnRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	result, err := h.service.RefundReturn(adminID, c.Param("returnID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
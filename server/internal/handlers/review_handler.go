@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"server/internal/dto"
 	"server/internal/services"
@@ -25,11 +26,9 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	if !utils.BindAndValidateForm(c, &req) {
 		return
 	}
-	var uploadedURL string
 
-	if req.Image != nil && req.Image.Filename != "" {
-		var err error
-		uploadedURL, err = utils.UploadImageWithValidation(req.Image)
+	if len(req.Images) > 0 {
+		uploadedURLs, err := utils.UploadMultipleImagesWithValidation(req.Images)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"message": "Image upload failed",
@@ -37,13 +36,12 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 			})
 			return
 		}
+		req.ImageURLs = uploadedURLs
 	}
 
-	req.ImageURL = uploadedURL
-
 	if err := h.reviewService.CreateReview(userID, itemID, req); err != nil {
-		utils.CleanupImageOnError(uploadedURL)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		utils.CleanupImagesOnError(req.ImageURLs)
+		c.JSON(reviewErrorStatus(err), gin.H{
 			"message": "Failed to create review",
 			"error":   err.Error(),
 		})
@@ -53,6 +51,68 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "Review created successfully"})
 }
 
+func (h *ReviewHandler) UpdateReview(c *gin.Context) {
+	reviewID := c.Param("reviewID")
+	userID := utils.MustGetUserID(c)
+
+	var req dto.UpdateReviewRequest
+	if !utils.BindAndValidateForm(c, &req) {
+		return
+	}
+
+	if len(req.Images) > 0 {
+		uploadedURLs, err := utils.UploadMultipleImagesWithValidation(req.Images)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Image upload failed",
+				"error":   err.Error(),
+			})
+			return
+		}
+		req.ImageURLs = uploadedURLs
+	}
+
+	if err := h.reviewService.UpdateReview(userID, reviewID, req); err != nil {
+		utils.CleanupImagesOnError(req.ImageURLs)
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": "Failed to update review",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review updated successfully"})
+}
+
+func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	reviewID := c.Param("reviewID")
+	userID := utils.MustGetUserID(c)
+
+	if err := h.reviewService.DeleteReview(userID, reviewID); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": "Failed to delete review",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully"})
+}
+
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound), errors.Is(err, services.ErrReviewItemNotFound),
+		errors.Is(err, services.ErrVoteNotFound), errors.Is(err, services.ErrReplyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrReviewNotAllowed), errors.Is(err, services.ErrReviewEditWindow),
+		errors.Is(err, services.ErrOwnReviewVote):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAlreadyReviewed), errors.Is(err, services.ErrAlreadyVoted):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *ReviewHandler) GetProductReviews(c *gin.Context) {
 	productID := c.Param("productID")
 
@@ -80,3 +140,98 @@ func (h *ReviewHandler) GetProductReviews(c *gin.Context) {
 		"pagination": pagination,
 	})
 }
+
+func (h *ReviewHandler) VoteHelpful(c *gin.Context) {
+	reviewID := c.Param("reviewID")
+	userID := utils.MustGetUserID(c)
+
+	if err := h.reviewService.VoteHelpful(userID, reviewID); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review marked as helpful"})
+}
+
+func (h *ReviewHandler) UnvoteHelpful(c *gin.Context) {
+	reviewID := c.Param("reviewID")
+	userID := utils.MustGetUserID(c)
+
+	if err := h.reviewService.UnvoteHelpful(userID, reviewID); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Helpful vote removed"})
+}
+
+func (h *ReviewHandler) GetModerationQueue(c *gin.Context) {
+	var param dto.ReviewModerationQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	if param.Page <= 0 {
+		param.Page = 1
+	}
+	if param.Limit <= 0 {
+		param.Limit = 10
+	}
+
+	result, pagination, err := h.reviewService.GetModerationQueue(param)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get reviews", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       result,
+		"pagination": pagination,
+	})
+}
+
+func (h *ReviewHandler) ModerateReview(c *gin.Context) {
+	reviewID := c.Param("reviewID")
+	adminID := utils.MustGetUserID(c)
+
+	var req dto.ModerateReviewRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	if err := h.reviewService.ModerateReview(adminID, reviewID, req); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review " + req.Status})
+}
+
+func (h *ReviewHandler) ReplyToReview(c *gin.Context) {
+	reviewID := c.Param("reviewID")
+	adminID := utils.MustGetUserID(c)
+
+	var req dto.ReviewReplyRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	if err := h.reviewService.ReplyToReview(adminID, reviewID, req); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply saved successfully"})
+}
+
+func (h *ReviewHandler) DeleteReply(c *gin.Context) {
+	reviewID := c.Param("reviewID")
+
+	if err := h.reviewService.DeleteReply(reviewID); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply deleted successfully"})
+}
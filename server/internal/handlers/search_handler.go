@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"server/internal/dto"
+	"server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	searchService services.SearchService
+}
+
+func NewSearchHandler(searchService services.SearchService) *SearchHandler {
+	return &SearchHandler{searchService}
+}
+
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	var param dto.SuggestQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	// ? dipanggil tiap ketikan, cache sebentar di browser/CDN
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, gin.H{"data": h.searchService.Suggest(param)})
+}
+
+func (h *SearchHandler) GetZeroResultQueries(c *gin.Context) {
+	var param dto.ZeroResultQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query param"})
+		return
+	}
+
+	if param.Page <= 0 {
+		param.Page = 1
+	}
+	if param.Limit <= 0 {
+		param.Limit = 10
+	}
+
+	result, pagination, err := h.searchService.GetZeroResultQueries(param)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get search report", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       result,
+		"pagination": pagination,
+	})
+}
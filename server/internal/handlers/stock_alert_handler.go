@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"server/internal/dto"
+	"server/internal/services"
+	"server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockAlertHandler struct {
+	service services.StockAlertService
+}
+
+func NewStockAlertHandler(s services.StockAlertService) *StockAlertHandler {
+	return &StockAlertHandler{service: s}
+}
+
+func (h *StockAlertHandler) Subscribe(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	var req dto.RestockSubscriptionRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	sub, err := h.service.Subscribe(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "You will be notified when the product is back in stock", "data": sub})
+}
+
+func (h *StockAlertHandler) Unsubscribe(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	if err := h.service.Unsubscribe(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Restock subscription removed"})
+}
+
+func (h *StockAlertHandler) GetSubscriptions(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	subs, err := h.service.GetSubscriptions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subs})
+}
+
+func (h *StockAlertHandler) CheckAlerts(c *gin.Context) {
+	if err := h.service.CheckAlerts(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stock alerts checked"})
+}
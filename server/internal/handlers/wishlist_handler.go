@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"server/internal/dto"
+	"server/internal/services"
+	"server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WishlistHandler struct {
+	service services.WishlistService
+}
+
+func NewWishlistHandler(s services.WishlistService) *WishlistHandler {
+	return &WishlistHandler{service: s}
+}
+
+func (h *WishlistHandler) GetWishlist(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	items, err := h.service.GetWishlist(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+func (h *WishlistHandler) AddItem(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	var req dto.WishlistRequest
+	if !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	item, err := h.service.AddItem(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Product added to wishlist", "data": item})
+}
+
+func (h *WishlistHandler) RemoveItem(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	if err := h.service.RemoveItem(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Product removed from wishlist"})
+}
+
+func (h *WishlistHandler) AddToCart(c *gin.Context) {
+	userID := utils.MustGetUserID(c)
+
+	// ? body boleh kosong, default satu item
+	var req dto.WishlistToCartRequest
+	if c.Request.ContentLength > 0 && !utils.BindAndValidateJSON(c, &req) {
+		return
+	}
+
+	if err := h.service.AddToCart(userID, c.Param("id"), req); err != nil {
+		if errors.Is(err, services.ErrOutOfStock) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error(), "canSubscribeRestock": true})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item added to cart"})
+}
@@ -30,6 +30,19 @@ func AuthRequired() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuth sets the user of a valid access token like AuthRequired, but lets anonymous visitors through
+func OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenString, err := c.Cookie("accessToken"); err == nil && tokenString != "" {
+			if claims, err := utils.DecodeAccessToken(tokenString); err == nil {
+				c.Set("role", claims.Role)
+				c.Set("userID", claims.UserID)
+			}
+		}
+		c.Next()
+	}
+}
+
 func RoleOnly(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role := utils.MustGetRole(c)
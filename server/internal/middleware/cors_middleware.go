@@ -30,7 +30,7 @@ func CORS() gin.HandlerFunc {
 
 		// Updated headers list - more comprehensive
 		c.Writer.Header().Set("Access-Control-Allow-Headers",
-			"Accept, Authorization, Content-Type, Content-Length, X-CSRF-Token, X-API-KEY, Origin, Cache-Control, X-Requested-With, Accept-Encoding, User-Agent, Referer")
+			"Accept, Authorization, Content-Type, Content-Length, X-CSRF-Token, X-API-KEY, Idempotency-Key, Origin, Cache-Control, X-Requested-With, Accept-Encoding, User-Agent, Referer")
 
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
 
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GuestCart identifies an anonymous visitor by the signed guestCart cookie,
+// a new guest ID is issued when the cookie is missing or tampered with
+func GuestCart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var guestID string
+		if tokenString, err := c.Cookie("guestCart"); err == nil && tokenString != "" {
+			guestID, _ = utils.DecodeGuestCartToken(tokenString)
+		}
+
+		if guestID == "" {
+			guestID = uuid.NewString()
+		}
+
+		// ** cookie diperbarui tiap request supaya keranjang aktif tidak kedaluwarsa
+		token, err := utils.GenerateGuestCartToken(guestID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Failed to create guest cart"})
+			return
+		}
+		utils.SetGuestCartCookie(c, token)
+
+		c.Set("guestID", guestID)
+		c.Next()
+	}
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"server/internal/config"
+	"server/internal/utils"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const idempotencyInFlight = "in_flight"
+
+// ? lock selama request diproses, cukup pendek supaya request yang crash tidak mengunci key seharian.
+// Selama handler masih jalan lock terus diperpanjang, jadi checkout yang menunggu gateway tetap terkunci.
+var idempotencyLockTTL = 45 * time.Second
+
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotency replays the first stored response for a repeated Idempotency-Key, ttl is how long
+// that response is kept. Must be registered after AuthRequired so keys are scoped per user.
+func Idempotency(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		bodyBytes, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+
+		sum := sha256.Sum256(bodyBytes)
+		fingerprint := hex.EncodeToString(sum[:])
+		redisKey := "ecommerce_app:idempotency:" + utils.MustGetUserID(c) + ":" + c.Request.Method + ":" + c.FullPath() + ":" + key
+
+		acquired, err := config.RedisClient.SetNX(config.Ctx, redisKey, idempotencyInFlight, idempotencyLockTTL).Result()
+		if err != nil {
+			log.Printf("Idempotency check skipped for key %s: %v\n", key, err)
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			stored, err := config.RedisClient.Get(config.Ctx, redisKey).Result()
+			if err != nil || stored == idempotencyInFlight {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": "A request with this Idempotency-Key is still being processed"})
+				return
+			}
+
+			var record idempotencyRecord
+			if err := json.Unmarshal([]byte(stored), &record); err != nil {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": "A request with this Idempotency-Key is still being processed"})
+				return
+			}
+
+			if record.Fingerprint != fingerprint {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"message": "Idempotency-Key was already used with a different request body"})
+				return
+			}
+
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.Status, record.ContentType, record.Body)
+			c.Abort()
+			return
+		}
+
+		// ** lock dilepas kalau response tidak tersimpan, termasuk saat handler panic
+		stored := false
+		stopRefresh := refreshLock(redisKey)
+		defer func() {
+			stopRefresh()
+			if !stored {
+				config.RedisClient.Del(config.Ctx, redisKey)
+			}
+		}()
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		c.Next()
+		stopRefresh()
+
+		// server error boleh di-retry, jadi key dilepas
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		record, _ := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err := config.RedisClient.Set(config.Ctx, redisKey, record, ttl).Err(); err != nil {
+			log.Printf("Failed to store idempotent response for key %s: %v\n", key, err)
+			return
+		}
+		stored = true
+	}
+}
+
+// refreshLock keeps extending the in-flight lock until the returned stop is called, stop waits
+// for the last refresh so it cannot overwrite the stored response afterwards
+func refreshLock(redisKey string) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(idempotencyLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := config.RedisClient.Expire(config.Ctx, redisKey, idempotencyLockTTL).Err(); err != nil {
+					log.Printf("Failed to extend idempotency lock %s: %v\n", redisKey, err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"server/internal/config"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// ? butuh Redis, jalankan dengan TEST_REDIS_ADDR=host:port
+func testRedis(t *testing.T) {
+	t.Helper()
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR is not set")
+	}
+
+	previous := config.RedisClient
+	config.RedisClient = redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() {
+		config.RedisClient.Close()
+		config.RedisClient = previous
+	})
+}
+
+func TestIdempotencyLockOutlivesItsTTL(t *testing.T) {
+	testRedis(t)
+	gin.SetMode(gin.TestMode)
+
+	lockTTL := idempotencyLockTTL
+	idempotencyLockTTL = time.Second
+	t.Cleanup(func() { idempotencyLockTTL = lockTTL })
+
+	// ! handler sengaja lebih lama dari TTL lock, seperti checkout yang menunggu gateway
+	var calls atomic.Int32
+	r := gin.New()
+	r.POST("/orders", func(c *gin.Context) { c.Set("userID", "user-1") }, Idempotency(time.Minute), func(c *gin.Context) {
+		calls.Add(1)
+		time.Sleep(3 * idempotencyLockTTL)
+		c.JSON(http.StatusCreated, gin.H{"message": "Order created"})
+	})
+
+	key := uuid.NewString()
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"quoteId":"q-1"}`))
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- send() }()
+
+	time.Sleep(2 * idempotencyLockTTL)
+	if w := send(); w.Code != http.StatusConflict {
+		t.Errorf("retry while the first request runs past the lock TTL = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	if w := <-first; w.Code != http.StatusCreated {
+		t.Fatalf("first request = %d, want %d", w.Code, http.StatusCreated)
+	}
+	w := send()
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after completion = %d replayed %q, want the stored %d", w.Code, w.Header().Get("Idempotent-Replayed"), http.StatusCreated)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want once", n)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -113,14 +114,18 @@ type Category struct {
 	CreatedAt time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// pohon kategori: ParentID nil berarti kategori utama, Position mengurutkan kategori bersaudara
+	ParentID *uuid.UUID `gorm:"type:char(36);index" json:"parentId"`
+	Position int        `gorm:"default:0" json:"position"`
 }
 
 type Product struct {
 	ID            uuid.UUID      `gorm:"type:char(36);primaryKey"`
 	CategoryID    uuid.UUID      `gorm:"type:char(36);not null"`
-	Name          string         `gorm:"type:varchar(255);not null"`
+	Name          string         `gorm:"type:varchar(255);not null;index:idx_products_search,class:FULLTEXT"`
 	Slug          string         `gorm:"type:varchar(255);uniqueIndex"`
-	Description   string         `gorm:"type:text"`
+	Description   string         `gorm:"type:text;index:idx_products_search,class:FULLTEXT"`
 	Stock         int            `gorm:"default:0" json:"stock"`
 	Sold          int            `gorm:"default:0"`
 	Price         float64        `gorm:"type:decimal(10,2);default:0" json:"price"`
@@ -136,9 +141,24 @@ type Product struct {
 	UpdatedAt     time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt     gorm.DeletedAt `gorm:"index"`
 
+	// admin diberi notifikasi sekali saat stok <= threshold, nil berarti tidak dipantau
+	LowStockThreshold *int       `json:"lowStockThreshold"`
+	LowStockAlertedAt *time.Time `json:"-"`
+
+	// ringkasan ulasan, dihitung ulang di transaksi yang sama dengan perubahan ulasan
+	ReviewCount     int            `gorm:"default:0" json:"reviewCount"`
+	RatingHistogram datatypes.JSON `json:"ratingHistogram"` // jumlah ulasan bintang 1 sampai 5
+
+	// popularitas: view dari Redis di-flush cron, skor trending meluruh seiring waktu
+	ViewCount     int64      `gorm:"default:0" json:"viewCount"`
+	TrendingScore float64    `gorm:"default:0;index" json:"-"`
+	TrendingAt    *time.Time `json:"-"`
+
 	Category       Category         `gorm:"foreignKey:CategoryID"`
 	Review         []Review         `gorm:"foreignKey:ProductID"`
 	ProductGallery []ProductGallery `gorm:"foreignKey:ProductID"`
+	Options        []ProductOption  `gorm:"foreignKey:ProductID"`
+	Variants       []ProductVariant `gorm:"foreignKey:ProductID"`
 }
 
 type ProductGallery struct {
@@ -147,10 +167,136 @@ type ProductGallery struct {
 	Image     string    `gorm:"type:varchar(255)" json:"image"`
 }
 
+// ProductOption is an option type such as Size or Color
+type ProductOption struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey"`
+	ProductID uuid.UUID `gorm:"type:char(36);not null;index"`
+	Name      string    `gorm:"type:varchar(50);not null"`
+	Position  int       `gorm:"default:0"`
+
+	Values []ProductOptionValue `gorm:"foreignKey:OptionID"`
+}
+
+type ProductOptionValue struct {
+	ID       uuid.UUID `gorm:"type:char(36);primaryKey"`
+	OptionID uuid.UUID `gorm:"type:char(36);not null;index"`
+	Value    string    `gorm:"type:varchar(50);not null"`
+	Position int       `gorm:"default:0"`
+
+	Option ProductOption `gorm:"foreignKey:OptionID"`
+}
+
+// ProductVariant is one sellable combination of option values.
+// Price and Weight are nil when the variant uses the product values.
+type ProductVariant struct {
+	ID        uuid.UUID      `gorm:"type:char(36);primaryKey"`
+	ProductID uuid.UUID      `gorm:"type:char(36);not null;index"`
+	SKU       string         `gorm:"type:varchar(150);uniqueIndex;not null"`
+	Price     *float64       `gorm:"type:decimal(10,2)"`
+	Stock     int            `gorm:"default:0"`
+	Weight    *float64       `gorm:"type:decimal(10,2)"`
+	IsActive  bool           `gorm:"default:true"`
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	Values []ProductVariantValue `gorm:"foreignKey:VariantID"`
+	Images []ProductVariantImage `gorm:"foreignKey:VariantID"`
+}
+
+type ProductVariantValue struct {
+	ID            uuid.UUID `gorm:"type:char(36);primaryKey"`
+	VariantID     uuid.UUID `gorm:"type:char(36);not null;index"`
+	OptionValueID uuid.UUID `gorm:"type:char(36);not null;index"`
+
+	OptionValue ProductOptionValue `gorm:"foreignKey:OptionValueID"`
+}
+
+type ProductVariantImage struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey"`
+	VariantID uuid.UUID `gorm:"type:char(36);not null;index"`
+	Image     string    `gorm:"type:varchar(255)"`
+}
+
+// StockMovement is one line of the inventory ledger. Quantity is signed, the sum of
+// a product's movements equals Product.Stock and the sum of a variant's equals its Stock.
+type StockMovement struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	ProductID     uuid.UUID  `gorm:"type:char(36);not null;index" json:"productId"`
+	VariantID     *uuid.UUID `gorm:"type:char(36);index" json:"variantId,omitempty"`
+	Type          string     `gorm:"type:varchar(20);not null;index;check:type IN ('initial','sale','cancel','return','adjustment','stock_take')" json:"type"`
+	Quantity      int        `gorm:"not null" json:"quantity"`
+	StockAfter    int        `json:"stockAfter"`
+	ReferenceType string     `gorm:"type:varchar(20)" json:"referenceType,omitempty"` // order, return
+	ReferenceID   *uuid.UUID `gorm:"type:char(36);index" json:"referenceId,omitempty"`
+	ActorType     string     `gorm:"type:varchar(20);not null;check:actor_type IN ('customer','admin','system','gateway')" json:"actorType"`
+	ActorID       *uuid.UUID `gorm:"type:char(36)" json:"actorId,omitempty"`
+	Reason        string     `gorm:"type:text" json:"reason"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime;index" json:"createdAt"`
+
+	Product Product         `gorm:"foreignKey:ProductID" json:"-"`
+	Variant *ProductVariant `gorm:"foreignKey:VariantID" json:"-"`
+}
+
+// StockReservation holds cart stock for a customer until ExpiresAt, one row per cart line
+type StockReservation struct {
+	ID        uuid.UUID  `gorm:"type:char(36);primaryKey"`
+	UserID    uuid.UUID  `gorm:"type:char(36);not null;index"`
+	ProductID uuid.UUID  `gorm:"type:char(36);not null;index"`
+	VariantID *uuid.UUID `gorm:"type:char(36);index"`
+	Quantity  int        `gorm:"not null"`
+	ExpiresAt time.Time  `gorm:"not null;index"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime"`
+}
+
+// RestockSubscription asks for one notification when an out of stock product or variant is back
+type RestockSubscription struct {
+	ID         uuid.UUID  `gorm:"type:char(36);primaryKey"`
+	UserID     uuid.UUID  `gorm:"type:char(36);not null;index"`
+	ProductID  uuid.UUID  `gorm:"type:char(36);not null;index"`
+	VariantID  *uuid.UUID `gorm:"type:char(36);index"`
+	NotifiedAt *time.Time `gorm:"index"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+
+	Product Product         `gorm:"foreignKey:ProductID"`
+	Variant *ProductVariant `gorm:"foreignKey:VariantID"`
+}
+
+// WishlistItem remembers the net price the user last saw so price drops can be announced
+type WishlistItem struct {
+	ID         uuid.UUID  `gorm:"type:char(36);primaryKey"`
+	UserID     uuid.UUID  `gorm:"type:char(36);not null;index"`
+	ProductID  uuid.UUID  `gorm:"type:char(36);not null;index"`
+	VariantID  *uuid.UUID `gorm:"type:char(36);index"`
+	KnownPrice float64    `gorm:"not null"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+
+	Product Product         `gorm:"foreignKey:ProductID"`
+	Variant *ProductVariant `gorm:"foreignKey:VariantID"`
+}
+
+// SearchQuery logs one storefront search, zero-result searches are reported to admins
+type SearchQuery struct {
+	ID          uuid.UUID `gorm:"type:char(36);primaryKey"`
+	Query       string    `gorm:"type:varchar(255);not null;index"`
+	ResultCount int       `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;index"`
+}
+
+// ProductAffinity is how strongly a product is bought together with another one, rebuilt by cron
+type ProductAffinity struct {
+	ID               uuid.UUID `gorm:"type:char(36);primaryKey"`
+	ProductID        uuid.UUID `gorm:"type:char(36);not null;index"`
+	RelatedProductID uuid.UUID `gorm:"type:char(36);not null"`
+	Orders           int       `gorm:"not null"` // jumlah order yang memuat kedua produk
+	Score            float64   `gorm:"not null"`
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+}
+
 type Review struct {
 	ID        uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
 	UserID    uuid.UUID `gorm:"type:char(36);not null" json:"userId"`
-	Image     *string   `gorm:"type:varchar(255)" json:"image"`
 	ProductID uuid.UUID `gorm:"type:char(36);not null" json:"productId"`
 	Rating    int       `gorm:"not null" json:"rating"`
 	Comment   string    `gorm:"type:text" json:"comment"`
@@ -158,6 +304,45 @@ type Review struct {
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 
 	User User `gorm:"foreignKey:UserID" json:"user"`
+
+	// ? satu ulasan per item pesanan, nil untuk ulasan lama tanpa bukti pembelian
+	OrderItemID *uuid.UUID `gorm:"type:char(36);uniqueIndex" json:"orderItemId"`
+
+	// moderasi: hanya ulasan approved yang tampil dan dihitung di rating produk
+	Status        string     `gorm:"type:varchar(20);default:'approved';index;check:status IN ('pending','approved','rejected')" json:"status"`
+	FlaggedReason string     `gorm:"type:varchar(255)" json:"flaggedReason"`
+	ModeratedBy   *uuid.UUID `gorm:"type:char(36)" json:"moderatedBy"`
+	ModeratedAt   *time.Time `json:"moderatedAt"`
+	HelpfulCount  int        `gorm:"default:0" json:"helpfulCount"`
+
+	Images  []ReviewImage `gorm:"foreignKey:ReviewID" json:"images"`
+	Reply   *ReviewReply  `gorm:"foreignKey:ReviewID" json:"reply"`
+	Product Product       `gorm:"foreignKey:ProductID" json:"-"`
+}
+
+type ReviewImage struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey"`
+	ReviewID  uuid.UUID `gorm:"type:char(36);not null;index"`
+	Image     string    `gorm:"type:varchar(255);not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// ReviewVote is one customer marking someone else's review as helpful
+type ReviewVote struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey"`
+	ReviewID  uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_review_vote"`
+	UserID    uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_review_vote"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// ReviewReply is the merchant's single public answer to a review
+type ReviewReply struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey"`
+	ReviewID  uuid.UUID `gorm:"type:char(36);not null;uniqueIndex"`
+	AdminID   uuid.UUID `gorm:"type:char(36);not null"`
+	Body      string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 }
 
 type Banner struct {
@@ -172,12 +357,19 @@ type Banner struct {
 
 // TRANSACTION SERVICES MODEL ================================
 type Cart struct {
-	ID        uuid.UUID `gorm:"type:char(36);primaryKey"`
-	UserID    uuid.UUID `gorm:"type:char(36);index"`
-	ProductID uuid.UUID `gorm:"type:char(36);not null"`
-	Quantity  int       `gorm:"default:1"`
-	IsChecked bool      `gorm:"default:true"`
-	Product   Product   `gorm:"foreignKey:ProductID"`
+	ID        uuid.UUID       `gorm:"type:char(36);primaryKey"`
+	UserID    *uuid.UUID      `gorm:"type:char(36);index"`
+	GuestID   *uuid.UUID      `gorm:"type:char(36);index"` // keranjang pengunjung yang belum login
+	ProductID uuid.UUID       `gorm:"type:char(36);not null"`
+	VariantID *uuid.UUID      `gorm:"type:char(36);index"`
+	Quantity  int             `gorm:"default:1"`
+	IsChecked bool            `gorm:"default:true"`
+	Product   Product         `gorm:"foreignKey:ProductID"`
+	Variant   *ProductVariant `gorm:"foreignKey:VariantID"`
+
+	PriceAtAdd    float64 // harga satuan setelah diskon saat dimasukkan ke keranjang
+	SavedForLater bool    `gorm:"default:false;index"`
+	UpdatedAt     time.Time
 }
 
 type Order struct {
@@ -187,7 +379,7 @@ type Order struct {
 	InvoiceNumber   string         `gorm:"type:varchar(100);uniqueIndex;not null"`
 	Note            *string        `gorm:"type:text"`
 	Courier         string         `gorm:"type:varchar(100)"`
-	Status          string         `gorm:"type:varchar(20);default:'waiting_payment';check:status IN ('waiting_payment','canceled', 'pending', 'process', 'success')" json:"status"`
+	Status          string         `gorm:"type:varchar(20);default:'waiting_payment';check:status IN ('waiting_payment','canceled', 'pending', 'process', 'shipped', 'delivered', 'completed', 'refunded')" json:"status"`
 	Total           float64        `gorm:"type:decimal(10,2);not null"`
 	ShippingCost    float64        `gorm:"type:decimal(10,2);default:0"`
 	RecipientName   string         `gorm:"type:char(36);not null"`
@@ -197,12 +389,25 @@ type Order struct {
 	VoucherCode     *string        `gorm:"type:varchar(100)" json:"voucherCode,omitempty"`
 	VoucherDiscount float64        `gorm:"default:0" json:"voucherDiscount"`
 	AmountToPay     float64        `gorm:"type:decimal(10,2);not null"`
+	RefundedAmount  float64        `gorm:"type:decimal(10,2);default:0" json:"refundedAmount"`
 	CreatedAt       time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt       time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt       gorm.DeletedAt `gorm:"index"`
 
-	Shipment Shipment    `gorm:"foreignKey:OrderID"`
-	Items    []OrderItem `gorm:"foreignKey:OrderID"`
+	Shipment      Shipment             `gorm:"foreignKey:OrderID"`
+	Items         []OrderItem          `gorm:"foreignKey:OrderID"`
+	StatusHistory []OrderStatusHistory `gorm:"foreignKey:OrderID" json:"-"`
+}
+
+type OrderStatusHistory struct {
+	ID         uuid.UUID  `gorm:"type:char(36);primaryKey"`
+	OrderID    uuid.UUID  `gorm:"type:char(36);not null;index"`
+	FromStatus string     `gorm:"type:varchar(20)"`
+	ToStatus   string     `gorm:"type:varchar(20);not null"`
+	ActorType  string     `gorm:"type:varchar(20);not null;check:actor_type IN ('customer','admin','system','gateway')"`
+	ActorID    *uuid.UUID `gorm:"type:char(36)"`
+	Reason     string     `gorm:"type:text"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
 }
 
 type Shipment struct {
@@ -222,19 +427,44 @@ type Payment struct {
 	Email    string    `gorm:"type:varchar(255);not null" json:"email"`
 	OrderID  uuid.UUID `gorm:"type:char(36);not null" json:"orderId"`
 	Method   string    `gorm:"type:varchar(50);not null" json:"method"`
-	Status   string    `gorm:"type:varchar(20);default:'pending';check:status IN ('success', 'pending', 'failed')" json:"status"`
+	Status   string    `gorm:"type:varchar(20);default:'pending';check:status IN ('success', 'pending', 'failed', 'refunded')" json:"status"`
 	PaidAt   time.Time `gorm:"autoCreateTime" json:"paidAt"`
 	Total    float64   `gorm:"type:decimal(10,2);not null"`
 
+	RefundedAmount float64    `gorm:"type:decimal(10,2);default:0" json:"refundedAmount"`
+	ReceiptImage   *string    `gorm:"type:varchar(255)" json:"receiptImage,omitempty"`
+	VerifiedBy     *uuid.UUID `gorm:"type:char(36)" json:"verifiedBy,omitempty"`
+	VerifiedAt     *time.Time `json:"verifiedAt,omitempty"`
+	Note           *string    `gorm:"type:text" json:"note,omitempty"`
+
 	Order Order `gorm:"foreignKey:OrderID" json:"package"`
 	User  User  `gorm:"foreignKey:UserID" json:"user"`
 }
 
+type Reconciliation struct {
+	ID               uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	RunID            uuid.UUID `gorm:"type:char(36);not null;index" json:"runId"`
+	PaymentID        uuid.UUID `gorm:"type:char(36);not null;index" json:"paymentId"`
+	OrderID          uuid.UUID `gorm:"type:char(36);not null" json:"orderId"`
+	Method           string    `gorm:"type:varchar(50)" json:"method"`
+	LocalStatus      string    `gorm:"type:varchar(20);not null" json:"localStatus"`
+	GatewayStatus    string    `gorm:"type:varchar(20)" json:"gatewayStatus"`
+	GatewayRawStatus string    `gorm:"type:varchar(50)" json:"gatewayRawStatus"`
+	Amount           float64   `gorm:"type:decimal(10,2)" json:"amount"`
+	GatewayAmount    string    `gorm:"type:varchar(30)" json:"gatewayAmount"`
+	Action           string    `gorm:"type:varchar(30);not null;index;check:action IN ('unchanged','marked_paid','marked_failed','refunded','amount_mismatch','manual_review','error')" json:"action"`
+	Note             string    `gorm:"type:text" json:"note"`
+	CreatedAt        time.Time `gorm:"autoCreateTime;index" json:"createdAt"`
+}
+
 type OrderItem struct {
 	ID          uuid.UUID      `gorm:"type:char(36);primaryKey"`
 	OrderID     uuid.UUID      `gorm:"type:char(36);not null;index"`
 	IsReviewed  bool           `gorm:"default:false"`
 	ProductID   uuid.UUID      `gorm:"type:char(36);not null"`
+	VariantID   *uuid.UUID     `gorm:"type:char(36);index"`
+	VariantName string         `gorm:"type:varchar(255)"`
+	SKU         string         `gorm:"type:varchar(150)"`
 	ProductName string         `gorm:"type:varchar(255);not null"`
 	ProductSlug string         `gorm:"type:varchar(255);not null"`
 	Image       string         `gorm:"type:varchar(255)"`
@@ -244,6 +474,46 @@ type OrderItem struct {
 	Subtotal    float64        `gorm:"type:decimal(10,2)"`
 	CreatedAt   time.Time      `gorm:"autoCreateTime"`
 	DeletedAt   gorm.DeletedAt `gorm:"index"`
+
+	// ? unit item ini yang sedang terhitung di Product.Sold, supaya pembalikan tidak dobel
+	SoldQuantity int `gorm:"default:0"`
+}
+
+type ReturnRequest struct {
+	ID              uuid.UUID `gorm:"type:char(36);primaryKey"`
+	OrderID         uuid.UUID `gorm:"type:char(36);not null;index"`
+	UserID          uuid.UUID `gorm:"type:char(36);not null;index"`
+	Status          string    `gorm:"type:varchar(20);default:'requested';check:status IN ('requested','approved','rejected','received','refunded')" json:"status"`
+	Reason          string    `gorm:"type:text;not null"`
+	AdminNote       *string   `gorm:"type:text"`
+	RequestedAmount float64   `gorm:"type:decimal(10,2);not null"`
+	RefundAmount    float64   `gorm:"type:decimal(10,2);default:0"`
+	RefundReference string    `gorm:"type:varchar(100)"`
+	ReceivedAt      *time.Time
+	RefundedAt      *time.Time
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime"`
+
+	Order  Order         `gorm:"foreignKey:OrderID"`
+	Items  []ReturnItem  `gorm:"foreignKey:ReturnRequestID"`
+	Images []ReturnImage `gorm:"foreignKey:ReturnRequestID"`
+}
+
+type ReturnItem struct {
+	ID              uuid.UUID `gorm:"type:char(36);primaryKey"`
+	ReturnRequestID uuid.UUID `gorm:"type:char(36);not null;index"`
+	OrderItemID     uuid.UUID `gorm:"type:char(36);not null;index"`
+	ProductID       uuid.UUID `gorm:"type:char(36);not null"`
+	Quantity        int       `gorm:"not null"`
+	Amount          float64   `gorm:"type:decimal(10,2);not null"`
+
+	OrderItem OrderItem `gorm:"foreignKey:OrderItemID"`
+}
+
+type ReturnImage struct {
+	ID              uuid.UUID `gorm:"type:char(36);primaryKey"`
+	ReturnRequestID uuid.UUID `gorm:"type:char(36);not null;index"`
+	Image           string    `gorm:"type:varchar(255);not null"`
 }
 
 type Voucher struct {
@@ -331,8 +601,27 @@ func (p *Payment) BeforeCreate(tx *gorm.DB) error              { setUUIDIfNil(&p
 func (c *Category) BeforeCreate(tx *gorm.DB) error             { setUUIDIfNil(&c.ID); return nil }
 func (s *Shipment) BeforeCreate(tx *gorm.DB) error             { setUUIDIfNil(&s.ID); return nil }
 func (oi *OrderItem) BeforeCreate(tx *gorm.DB) error           { setUUIDIfNil(&oi.ID); return nil }
+func (h *OrderStatusHistory) BeforeCreate(tx *gorm.DB) error   { setUUIDIfNil(&h.ID); return nil }
+func (r *Reconciliation) BeforeCreate(tx *gorm.DB) error       { setUUIDIfNil(&r.ID); return nil }
+func (r *ReturnRequest) BeforeCreate(tx *gorm.DB) error        { setUUIDIfNil(&r.ID); return nil }
+func (ri *ReturnItem) BeforeCreate(tx *gorm.DB) error          { setUUIDIfNil(&ri.ID); return nil }
+func (ri *ReturnImage) BeforeCreate(tx *gorm.DB) error         { setUUIDIfNil(&ri.ID); return nil }
 func (n *Notification) BeforeCreate(tx *gorm.DB) error         { setUUIDIfNil(&n.ID); return nil }
 func (uv *UsedVoucher) BeforeCreate(tx *gorm.DB) error         { setUUIDIfNil(&uv.ID); return nil }
 func (g *ProductGallery) BeforeCreate(tx *gorm.DB) error       { setUUIDIfNil(&g.ID); return nil }
+func (o *ProductOption) BeforeCreate(tx *gorm.DB) error        { setUUIDIfNil(&o.ID); return nil }
+func (v *ProductVariant) BeforeCreate(tx *gorm.DB) error       { setUUIDIfNil(&v.ID); return nil }
+func (ov *ProductOptionValue) BeforeCreate(tx *gorm.DB) error  { setUUIDIfNil(&ov.ID); return nil }
+func (vv *ProductVariantValue) BeforeCreate(tx *gorm.DB) error { setUUIDIfNil(&vv.ID); return nil }
+func (vi *ProductVariantImage) BeforeCreate(tx *gorm.DB) error { setUUIDIfNil(&vi.ID); return nil }
+func (sm *StockMovement) BeforeCreate(tx *gorm.DB) error       { setUUIDIfNil(&sm.ID); return nil }
+func (rs *RestockSubscription) BeforeCreate(tx *gorm.DB) error { setUUIDIfNil(&rs.ID); return nil }
+func (sr *StockReservation) BeforeCreate(tx *gorm.DB) error    { setUUIDIfNil(&sr.ID); return nil }
+func (wi *WishlistItem) BeforeCreate(tx *gorm.DB) error        { setUUIDIfNil(&wi.ID); return nil }
+func (sq *SearchQuery) BeforeCreate(tx *gorm.DB) error         { setUUIDIfNil(&sq.ID); return nil }
+func (pa *ProductAffinity) BeforeCreate(tx *gorm.DB) error     { setUUIDIfNil(&pa.ID); return nil }
+func (ri *ReviewImage) BeforeCreate(tx *gorm.DB) error         { setUUIDIfNil(&ri.ID); return nil }
+func (rv *ReviewVote) BeforeCreate(tx *gorm.DB) error          { setUUIDIfNil(&rv.ID); return nil }
+func (rr *ReviewReply) BeforeCreate(tx *gorm.DB) error         { setUUIDIfNil(&rr.ID); return nil }
 func (nt *NotificationType) BeforeCreate(tx *gorm.DB) error    { setUUIDIfNil(&nt.ID); return nil }
 func (ns *NotificationSetting) BeforeCreate(tx *gorm.DB) error { setUUIDIfNil(&ns.ID); return nil }
@@ -0,0 +1,234 @@
+// Package pricing is the single place where unit prices, product discounts,
+// order promotions, vouchers and tax are calculated.
+//
+// Amounts are held as Money: whole rupiah in an int64, IDR has no minor unit in use.
+// Every rounding step rounds half away from zero, and amounts that are split over
+// lines (promotions, vouchers) always add up exactly to the original amount.
+package pricing
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+)
+
+type Money int64
+
+// FromFloat converts a stored decimal amount, rounding half away from zero
+func FromFloat(v float64) Money {
+	return Money(math.Round(v))
+}
+
+func (m Money) Float() float64 { return float64(m) }
+
+func (m Money) Int64() int64 { return int64(m) }
+
+// Percent returns pct percent of m, e.g. Money(1000).Percent(12.5) == 125
+func (m Money) Percent(pct float64) Money {
+	return FromFloat(float64(m) * pct / 100)
+}
+
+// UnitPrice is what one unit costs after the product discount.
+// Product.Discount is a percentage of the list price.
+type UnitPrice struct {
+	List            Money
+	DiscountPercent float64
+	Discount        Money
+	Net             Money
+}
+
+func PriceUnit(listPrice float64, discountPercent *float64) UnitPrice {
+	unit := UnitPrice{List: FromFloat(listPrice)}
+	if unit.List < 0 {
+		unit.List = 0
+	}
+
+	if discountPercent != nil {
+		unit.DiscountPercent = math.Min(math.Max(*discountPercent, 0), 100)
+	}
+	unit.Discount = unit.List.Percent(unit.DiscountPercent)
+	unit.Net = unit.List - unit.Discount
+	return unit
+}
+
+type Line struct {
+	ID       string
+	Unit     UnitPrice
+	Quantity int
+
+	Gross    Money // harga list x qty
+	Discount Money // diskon produk x qty
+	Subtotal Money // harga setelah diskon produk x qty
+
+	// OrderDiscount is this line's share of promotions and voucher
+	OrderDiscount Money
+}
+
+func NewLine(id string, unit UnitPrice, quantity int) Line {
+	qty := Money(quantity)
+	return Line{
+		ID:       id,
+		Unit:     unit,
+		Quantity: quantity,
+		Gross:    unit.List * qty,
+		Discount: unit.Discount * qty,
+		Subtotal: unit.Net * qty,
+	}
+}
+
+// Adjustment is an order level discount, used for promotions and vouchers
+type Adjustment struct {
+	Label       string
+	Percentage  bool
+	Value       float64 // persen kalau Percentage, rupiah kalau tidak
+	MaxDiscount *float64
+}
+
+// Amount is the discount the adjustment gives on base, never more than base
+func (a Adjustment) Amount(base Money) Money {
+	if base <= 0 || a.Value <= 0 {
+		return 0
+	}
+
+	var amount Money
+	if a.Percentage {
+		amount = base.Percent(a.Value)
+		if a.MaxDiscount != nil && *a.MaxDiscount > 0 {
+			amount = min(amount, FromFloat(*a.MaxDiscount))
+		}
+	} else {
+		amount = FromFloat(a.Value)
+	}
+
+	return min(amount, base)
+}
+
+type Order struct {
+	Lines []Line
+
+	// Promotions apply in order before the voucher, each on what is left
+	Promotions []Adjustment
+	Voucher    *Adjustment
+
+	Shipping Money
+	TaxRate  float64 // 0.11 = 11%
+}
+
+type Summary struct {
+	Lines []Line
+
+	Gross             Money
+	ProductDiscount   Money
+	Subtotal          Money
+	PromotionDiscount Money
+	VoucherDiscount   Money
+	Total             Money // dasar pengenaan pajak, tanpa ongkir
+	Shipping          Money
+	Tax               Money
+	AmountToPay       Money
+}
+
+// Calculate prices an order. Tax is charged once on the discounted goods total,
+// shipping is not taxed.
+func Calculate(o Order) Summary {
+	s := Summary{
+		Lines:    append([]Line(nil), o.Lines...),
+		Shipping: max(o.Shipping, 0),
+	}
+
+	for _, l := range s.Lines {
+		s.Gross += l.Gross
+		s.ProductDiscount += l.Discount
+		s.Subtotal += l.Subtotal
+	}
+
+	remaining := s.Subtotal
+	for _, p := range o.Promotions {
+		amount := p.Amount(remaining)
+		s.PromotionDiscount += amount
+		remaining -= amount
+	}
+	if o.Voucher != nil {
+		s.VoucherDiscount = o.Voucher.Amount(remaining)
+		remaining -= s.VoucherDiscount
+	}
+
+	weights := make([]Money, len(s.Lines))
+	for i, l := range s.Lines {
+		weights[i] = l.Subtotal
+	}
+	for i, share := range Allocate(s.PromotionDiscount+s.VoucherDiscount, weights) {
+		s.Lines[i].OrderDiscount = share
+	}
+
+	s.Total = remaining
+	if o.TaxRate > 0 {
+		s.Tax = FromFloat(float64(s.Total) * o.TaxRate)
+	}
+	s.AmountToPay = s.Total + s.Shipping + s.Tax
+
+	return s
+}
+
+// Allocate splits amount over weights proportionally. Parts are floored and the
+// leftover rupiah go to the largest remainders, so the parts always sum to amount.
+func Allocate(amount Money, weights []Money) []Money {
+	parts := make([]Money, len(weights))
+
+	// ? dijumlah sebagai uint64, dua bobot besar sudah bisa melewati batas int64
+	var total uint64
+	for _, w := range weights {
+		if w > 0 {
+			total += uint64(w)
+		}
+	}
+	if amount <= 0 || total == 0 {
+		return parts
+	}
+
+	remainders := make([]uint64, len(weights))
+	var allocated Money
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		q, r := mulDiv(uint64(amount), uint64(w), total)
+		parts[i] = Money(q)
+		remainders[i] = r
+		allocated += parts[i]
+	}
+
+	order := make([]int, 0, len(weights))
+	for i, w := range weights {
+		if w > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for i := 0; allocated < amount; i++ {
+		parts[order[i%len(order)]]++
+		allocated++
+	}
+
+	return parts
+}
+
+// Prorate returns part/whole of amount, rounded down so refunds never exceed what was paid
+func Prorate(amount Money, part, whole int64) Money {
+	if amount <= 0 || part <= 0 || whole <= 0 {
+		return 0
+	}
+	if part >= whole {
+		return amount
+	}
+	q, _ := mulDiv(uint64(amount), uint64(part), uint64(whole))
+	return Money(q)
+}
+
+// mulDiv computes a*b/c and its remainder without overflowing, b must not exceed c
+func mulDiv(a, b, c uint64) (uint64, uint64) {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Div64(hi, lo, c)
+}
@@ -0,0 +1,243 @@
+package pricing
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestPriceUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     float64
+		discount *float64
+		want     UnitPrice
+	}{
+		{"no discount", 10000, nil, UnitPrice{List: 10000, Discount: 0, Net: 10000}},
+		{"zero discount", 10000, ptr(0), UnitPrice{List: 10000, Discount: 0, Net: 10000}},
+		{"full discount", 10000, ptr(100), UnitPrice{List: 10000, DiscountPercent: 100, Discount: 10000, Net: 0}},
+		{"discount above 100 is capped", 10000, ptr(150), UnitPrice{List: 10000, DiscountPercent: 100, Discount: 10000, Net: 0}},
+		{"negative discount is ignored", 10000, ptr(-10), UnitPrice{List: 10000, Discount: 0, Net: 10000}},
+		{"half rupiah rounds away from zero", 999, ptr(50), UnitPrice{List: 999, DiscountPercent: 50, Discount: 500, Net: 499}},
+		{"fractional list price", 1000.5, ptr(10), UnitPrice{List: 1001, DiscountPercent: 10, Discount: 100, Net: 901}},
+		{"negative list price", -500, ptr(10), UnitPrice{List: 0, DiscountPercent: 10, Discount: 0, Net: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PriceUnit(tt.list, tt.discount); got != tt.want {
+				t.Errorf("PriceUnit(%v, %v) = %+v, want %+v", tt.list, tt.discount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjustmentAmount(t *testing.T) {
+	tests := []struct {
+		name string
+		adj  Adjustment
+		base Money
+		want Money
+	}{
+		{"zero percent", Adjustment{Percentage: true, Value: 0}, 10000, 0},
+		{"percent", Adjustment{Percentage: true, Value: 12.5}, 10000, 1250},
+		{"hundred percent", Adjustment{Percentage: true, Value: 100}, 10000, 10000},
+		{"percent capped by max discount", Adjustment{Percentage: true, Value: 50, MaxDiscount: ptr(2000)}, 10000, 2000},
+		{"zero max discount means no cap", Adjustment{Percentage: true, Value: 50, MaxDiscount: ptr(0)}, 10000, 5000},
+		{"fixed", Adjustment{Value: 3000}, 10000, 3000},
+		{"fixed above base", Adjustment{Value: 15000}, 10000, 10000},
+		{"empty base", Adjustment{Value: 3000}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.adj.Amount(tt.base); got != tt.want {
+				t.Errorf("Amount(%d) = %d, want %d", tt.base, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	shirt := PriceUnit(100000, ptr(10))
+	hat := PriceUnit(33333, nil)
+
+	tests := []struct {
+		name  string
+		order Order
+		want  Summary
+	}{
+		{
+			name:  "no discounts",
+			order: Order{Lines: []Line{NewLine("a", hat, 3)}, Shipping: 10000, TaxRate: 0.11},
+			want: Summary{
+				Gross: 99999, Subtotal: 99999, Total: 99999,
+				Shipping: 10000, Tax: 11000, AmountToPay: 120999,
+			},
+		},
+		{
+			name: "promotion then voucher on what is left",
+			order: Order{
+				Lines:      []Line{NewLine("a", shirt, 2), NewLine("b", hat, 1)},
+				Promotions: []Adjustment{{Percentage: true, Value: 10}},
+				Voucher:    &Adjustment{Value: 5000},
+				TaxRate:    0.11,
+			},
+			want: Summary{
+				Gross: 233333, ProductDiscount: 20000, Subtotal: 213333,
+				PromotionDiscount: 21333, VoucherDiscount: 5000, Total: 187000,
+				Tax: 20570, AmountToPay: 207570,
+			},
+		},
+		{
+			name: "hundred percent voucher leaves only shipping",
+			order: Order{
+				Lines:    []Line{NewLine("a", shirt, 1)},
+				Voucher:  &Adjustment{Percentage: true, Value: 100},
+				Shipping: 15000,
+				TaxRate:  0.11,
+			},
+			want: Summary{
+				Gross: 100000, ProductDiscount: 10000, Subtotal: 90000,
+				VoucherDiscount: 90000, Shipping: 15000, AmountToPay: 15000,
+			},
+		},
+		{
+			name:  "negative shipping is ignored",
+			order: Order{Lines: []Line{NewLine("a", hat, 1)}, Shipping: -5000},
+			want:  Summary{Gross: 33333, Subtotal: 33333, Total: 33333, AmountToPay: 33333},
+		},
+		{
+			name:  "empty order",
+			order: Order{Voucher: &Adjustment{Value: 5000}, TaxRate: 0.11},
+			want:  Summary{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Calculate(tt.order)
+			lines := got.Lines
+			got.Lines = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Calculate() = %+v, want %+v", got, tt.want)
+			}
+
+			// potongan promo dan voucher per baris harus pas dengan total potongan
+			var shared Money
+			for _, l := range lines {
+				if l.OrderDiscount < 0 || l.OrderDiscount > l.Subtotal {
+					t.Errorf("line %s order discount %d outside 0..%d", l.ID, l.OrderDiscount, l.Subtotal)
+				}
+				shared += l.OrderDiscount
+			}
+			if want := got.PromotionDiscount + got.VoucherDiscount; shared != want {
+				t.Errorf("line order discounts sum to %d, want %d", shared, want)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	const maxMoney = Money(math.MaxInt64)
+
+	tests := []struct {
+		name    string
+		amount  Money
+		weights []Money
+		want    []Money
+	}{
+		{"even split", 900, []Money{1, 1, 1}, []Money{300, 300, 300}},
+		{"uneven split goes to largest remainders", 100, []Money{1, 1, 1}, []Money{34, 33, 33}},
+		{"proportional", 1000, []Money{1, 2, 7}, []Money{100, 200, 700}},
+		{"remainder to the biggest fraction", 10, []Money{3, 3, 4}, []Money{3, 3, 4}},
+		{"one rupiah over many lines", 1, []Money{5, 5, 5, 5}, []Money{1, 0, 0, 0}},
+		{"zero weights get nothing", 100, []Money{0, 1, 0, 1}, []Money{0, 50, 0, 50}},
+		{"negative weights get nothing", 101, []Money{-5, 1, 1}, []Money{0, 51, 50}},
+		{"all weights zero", 100, []Money{0, 0}, []Money{0, 0}},
+		{"all weights negative", 100, []Money{-1, -2}, []Money{0, 0}},
+		{"zero amount", 0, []Money{1, 2}, []Money{0, 0}},
+		{"negative amount", -100, []Money{1, 2}, []Money{0, 0}},
+		{"no weights", 100, nil, []Money{}},
+		{"amount near int64 limit", maxMoney, []Money{1, 1}, []Money{maxMoney/2 + 1, maxMoney / 2}},
+		{"weights near int64 limit", 3, []Money{maxMoney, maxMoney - 1}, []Money{2, 1}},
+		{"amount and weight near int64 limit", maxMoney, []Money{maxMoney, 1}, []Money{maxMoney - 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Allocate(tt.amount, tt.weights)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("Allocate(%d, %v) = %v, want %v", tt.amount, tt.weights, got, tt.want)
+			}
+
+			var sum Money
+			for _, p := range got {
+				sum += p
+			}
+			if tt.amount > 0 && slices.ContainsFunc(tt.weights, func(w Money) bool { return w > 0 }) && sum != tt.amount {
+				t.Errorf("parts sum to %d, want %d", sum, tt.amount)
+			}
+		})
+	}
+}
+
+func TestProrate(t *testing.T) {
+	const maxMoney = Money(math.MaxInt64)
+
+	tests := []struct {
+		name        string
+		amount      Money
+		part, whole int64
+		want        Money
+	}{
+		{"half", 1000, 1, 2, 500},
+		{"rounds down", 1000, 1, 3, 333},
+		{"full", 1000, 3, 3, 1000},
+		{"more than whole is capped", 1000, 4, 3, 1000},
+		{"zero part", 1000, 0, 3, 0},
+		{"negative part", 1000, -1, 3, 0},
+		{"zero whole", 1000, 1, 0, 0},
+		{"negative whole", 1000, 1, -3, 0},
+		{"zero amount", 0, 1, 2, 0},
+		{"negative amount", -1000, 1, 2, 0},
+		{"amount near int64 limit", maxMoney, math.MaxInt64 - 1, math.MaxInt64, maxMoney - 1},
+		{"part and whole near int64 limit", 1000, math.MaxInt64 / 2, math.MaxInt64, 499},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Prorate(tt.amount, tt.part, tt.whole); got != tt.want {
+				t.Errorf("Prorate(%d, %d, %d) = %d, want %d", tt.amount, tt.part, tt.whole, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	const maxUint = math.MaxUint64
+
+	tests := []struct {
+		name    string
+		a, b, c uint64
+		q, r    uint64
+	}{
+		{"small", 10, 3, 4, 7, 2},
+		{"exact", 12, 5, 6, 10, 0},
+		{"product overflows 64 bits", maxUint, maxUint - 1, maxUint, maxUint - 1, 0},
+		{"int64 limits", math.MaxInt64, math.MaxInt64, math.MaxInt64, math.MaxInt64, 0},
+		{"int64 limit with remainder", math.MaxInt64, 2, 3, 6148914691236517204, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, r := mulDiv(tt.a, tt.b, tt.c)
+			if q != tt.q || r != tt.r {
+				t.Errorf("mulDiv(%d, %d, %d) = (%d, %d), want (%d, %d)", tt.a, tt.b, tt.c, q, r, tt.q, tt.r)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"server/internal/dto"
 	"server/internal/models"
+	"server/internal/pricing"
 
 	"gorm.io/gorm"
 )
@@ -10,12 +11,17 @@ import (
 type AdminRepository interface {
 	CountProducts() (int64, error)
 	CountOrders() (int64, error)
-	SumRevenue() (float64, error)
+	SumRevenue() (pricing.Money, error)
 	FindCustomerByID(id string) (*models.User, error)
 	CountCustomerByGender(gender string) (int64, error)
-	GetRevenueStatsByRange(rangeType string) ([]dto.RevenueStat, float64, error)
+	GetRevenueStatsByRange(rangeType string) ([]dto.RevenueStat, pricing.Money, error)
 	FindAllCustomers(params dto.CustomerQueryParam) ([]models.User, int64, error)
 }
+
+// ** revenue bersih per pembayaran dibulatkan ke rupiah seperti pricing.FromFloat sebelum dijumlahkan,
+// ** ROUND pada kolom decimal membulatkan setengah menjauhi nol sama seperti pricing
+const netRevenueSQL = "ROUND(total) - ROUND(refunded_amount)"
+
 type adminRepository struct {
 	db *gorm.DB
 }
@@ -111,24 +117,27 @@ func (r *adminRepository) CountProducts() (int64, error) {
 func (r *adminRepository) CountOrders() (int64, error) {
 	var count int64
 	err := r.db.Model(&models.Order{}).
-		Where("status IN ?", []string{"pending", "success"}).
+		Where("status IN ?", []string{"pending", "process", "shipped", "delivered", "completed"}).
 		Count(&count).Error
 	return count, err
 }
 
-func (r *adminRepository) SumRevenue() (float64, error) {
+func (r *adminRepository) SumRevenue() (pricing.Money, error) {
 	var total float64
 	err := r.db.Model(&models.Payment{}).
-		Where("status = ?", "success").
-		Select("SUM(total)").Scan(&total).Error
-	return total, err
+		Where("status IN ?", []string{"success", "refunded"}).
+		Select("COALESCE(SUM(" + netRevenueSQL + "), 0)").Scan(&total).Error
+	return pricing.FromFloat(total), err
 }
 
-func (r *adminRepository) GetRevenueStatsByRange(rangeType string) ([]dto.RevenueStat, float64, error) {
-	var stats []dto.RevenueStat
-	var total float64
+func (r *adminRepository) GetRevenueStatsByRange(rangeType string) ([]dto.RevenueStat, pricing.Money, error) {
+	var rows []struct {
+		Date  string
+		Total float64
+	}
 
-	query := r.db.Model(&models.Payment{}).Where("status = ?", "success")
+	// ** revenue bersih, refund dikurangi dari pembayaran yang sukses
+	query := r.db.Model(&models.Payment{}).Where("status IN ?", []string{"success", "refunded"})
 
 	selectClause := ""
 	groupClause := ""
@@ -154,14 +163,21 @@ func (r *adminRepository) GetRevenueStatsByRange(rangeType string) ([]dto.Revenu
 	}
 
 	err := query.
-		Select(selectClause + ", SUM(total) as total").
+		Select(selectClause + ", SUM(" + netRevenueSQL + ") as total").
 		Group(groupClause).
 		Order(orderClause + " ASC").
-		Scan(&stats).Error
+		Scan(&rows).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
-	err = query.Select("SUM(total)").Scan(&total).Error
-	return stats, total, err
+	// ? total diambil dari seri yang sama supaya selalu sama dengan jumlah grafiknya
+	var total pricing.Money
+	stats := make([]dto.RevenueStat, len(rows))
+	for i, row := range rows {
+		amount := pricing.FromFloat(row.Total)
+		stats[i] = dto.RevenueStat{Date: row.Date, Total: amount.Float()}
+		total += amount
+	}
+	return stats, total, nil
 }
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"server/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -9,61 +10,132 @@ import (
 
 type CartRepository interface {
 	Clear(userID uuid.UUID) error
-	AddOrUpdate(cart *models.Cart) error
-	RemoveItem(userID, productID uuid.UUID) error
+	AddOrUpdate(userID uuid.UUID, cart *models.Cart) error
+	RemoveItem(userID, productID uuid.UUID, variantID *uuid.UUID) error
 	GetByUserID(userID uuid.UUID) ([]models.Cart, error)
-	UpdateQuantity(userID, productID uuid.UUID, quantity int) error
-	ToggleIsChecked(userID, productID uuid.UUID) error
+	GetSavedByUserID(userID uuid.UUID) ([]models.Cart, error)
+	SetSavedForLater(userID, productID uuid.UUID, variantID *uuid.UUID, saved bool) error
+	GetItem(userID, productID uuid.UUID, variantID *uuid.UUID) (*models.Cart, error)
+	UpdateQuantity(userID, productID uuid.UUID, variantID *uuid.UUID, quantity int) error
+	UpdatePriceAtAdd(userID, productID uuid.UUID, variantID *uuid.UUID, price float64) error
+	ToggleIsChecked(userID, productID uuid.UUID, variantID *uuid.UUID) error
+	DeleteStale(before time.Time) (int64, error)
 }
 
-type cartRepository struct{ db *gorm.DB }
+type cartRepository struct {
+	db    *gorm.DB
+	owner string // ? kolom pemilik keranjang, user_id atau guest_id
+}
 
 func NewCartRepository(db *gorm.DB) CartRepository {
-	return &cartRepository{db}
+	return &cartRepository{db, "user_id"}
+}
+
+// NewGuestCartRepository works on the carts of anonymous visitors, userID arguments are guest IDs
+func NewGuestCartRepository(db *gorm.DB) CartRepository {
+	return &cartRepository{db, "guest_id"}
+}
+
+// cartItem scopes a query to one cart line, the same product can sit in the cart once per variant
+func (r *cartRepository) cartItem(userID, productID uuid.UUID, variantID *uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where(r.owner+" = ? AND product_id = ?", userID, productID)
+		if variantID == nil {
+			return db.Where("variant_id IS NULL")
+		}
+		return db.Where("variant_id = ?", *variantID)
+	}
 }
 
 func (r *cartRepository) GetByUserID(userID uuid.UUID) ([]models.Cart, error) {
+	return r.getLines(userID, false)
+}
+
+// GetSavedByUserID returns the lines the user moved out of the cart to buy later
+func (r *cartRepository) GetSavedByUserID(userID uuid.UUID) ([]models.Cart, error) {
+	return r.getLines(userID, true)
+}
+
+func (r *cartRepository) getLines(userID uuid.UUID, saved bool) ([]models.Cart, error) {
 	var carts []models.Cart
 	err := r.db.Preload("Product.ProductGallery").
-		Where("user_id = ?", userID).
+		Preload("Variant.Values.OptionValue.Option").
+		Preload("Variant.Images").
+		Where(r.owner+" = ? AND saved_for_later = ?", userID, saved).
+		Order("updated_at desc").
 		Find(&carts).Error
 	return carts, err
 }
 
-func (r *cartRepository) AddOrUpdate(cart *models.Cart) error {
+func (r *cartRepository) GetItem(userID, productID uuid.UUID, variantID *uuid.UUID) (*models.Cart, error) {
+	var cart models.Cart
+	if err := r.db.Scopes(r.cartItem(userID, productID, variantID)).First(&cart).Error; err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (r *cartRepository) AddOrUpdate(userID uuid.UUID, cart *models.Cart) error {
 	var existing models.Cart
-	err := r.db.Where("user_id = ? AND product_id = ?", cart.UserID, cart.ProductID).
+	err := r.db.Scopes(r.cartItem(userID, cart.ProductID, cart.VariantID)).
 		First(&existing).Error
 	if err == nil {
+		// ** item yang disimpan untuk nanti kembali ke keranjang
 		existing.Quantity += cart.Quantity
+		existing.PriceAtAdd = cart.PriceAtAdd
+		existing.SavedForLater = false
 		return r.db.Save(&existing).Error
 	}
+
+	if r.owner == "guest_id" {
+		cart.GuestID = &userID
+	} else {
+		cart.UserID = &userID
+	}
 	return r.db.Create(cart).Error
 }
 
-func (r *cartRepository) UpdateQuantity(userID, productID uuid.UUID, quantity int) error {
+func (r *cartRepository) UpdateQuantity(userID, productID uuid.UUID, variantID *uuid.UUID, quantity int) error {
 	return r.db.Model(&models.Cart{}).
-		Where("user_id = ? AND product_id = ?", userID, productID).
+		Scopes(r.cartItem(userID, productID, variantID)).
 		Update("quantity", quantity).Error
 }
 
-func (r *cartRepository) RemoveItem(userID, productID uuid.UUID) error {
-	return r.db.Where("user_id = ? AND product_id = ?", userID, productID).
+func (r *cartRepository) UpdatePriceAtAdd(userID, productID uuid.UUID, variantID *uuid.UUID, price float64) error {
+	return r.db.Model(&models.Cart{}).
+		Scopes(r.cartItem(userID, productID, variantID)).
+		Update("price_at_add", price).Error
+}
+
+func (r *cartRepository) RemoveItem(userID, productID uuid.UUID, variantID *uuid.UUID) error {
+	return r.db.Scopes(r.cartItem(userID, productID, variantID)).
 		Delete(&models.Cart{}).Error
 }
 
 func (r *cartRepository) Clear(userID uuid.UUID) error {
-	return r.db.Where("user_id = ?", userID).
+	return r.db.Where(r.owner+" = ? AND saved_for_later = ?", userID, false).
 		Delete(&models.Cart{}).Error
 }
 
-func (r *cartRepository) ToggleIsChecked(userID, productID uuid.UUID) error {
-	var cart models.Cart
-	if err := r.db.Where("user_id = ? AND product_id = ?", userID, productID).First(&cart).Error; err != nil {
+func (r *cartRepository) SetSavedForLater(userID, productID uuid.UUID, variantID *uuid.UUID, saved bool) error {
+	return r.db.Model(&models.Cart{}).
+		Scopes(r.cartItem(userID, productID, variantID)).
+		Update("saved_for_later", saved).Error
+}
+
+func (r *cartRepository) ToggleIsChecked(userID, productID uuid.UUID, variantID *uuid.UUID) error {
+	cart, err := r.GetItem(userID, productID, variantID)
+	if err != nil {
 		return err
 	}
 
 	return r.db.Model(&models.Cart{}).
-		Where("user_id = ? AND product_id = ?", userID, productID).
+		Where("id = ?", cart.ID).
 		Update("is_checked", !cart.IsChecked).Error
 }
+
+// DeleteStale removes cart lines untouched since before
+func (r *cartRepository) DeleteStale(before time.Time) (int64, error) {
+	result := r.db.Where(r.owner+" IS NOT NULL AND updated_at < ?", before).Delete(&models.Cart{})
+	return result.RowsAffected, result.Error
+}
@@ -4,6 +4,7 @@ import (
 	"server/internal/dto"
 	"server/internal/models"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +14,15 @@ type CategoryRepository interface {
 	CreateCategory(category *models.Category) error
 	UpdateCategory(category *models.Category) error
 	GetCategoryByID(CategoryID string) (*models.Category, error)
+	GetCategoryBySlug(slug string) (*models.Category, error)
+
+	GetCategoryNodes() ([]models.Category, error)
+	CountProductsByCategory() (map[uuid.UUID]int, error)
+	CountProducts(categoryID uuid.UUID) (int64, error)
+	NextPosition(parentID *uuid.UUID) (int, error)
+	MoveProducts(fromID, toID uuid.UUID) error
+	MoveChildren(fromID uuid.UUID, toID *uuid.UUID) error
+	WithTx(fn func(tx *gorm.DB) error) error
 }
 
 type categoryRepository struct {
@@ -57,6 +67,8 @@ func (r *categoryRepository) GetAllCategories(param dto.CategoryQueryParam) ([]m
 		sort = "name asc"
 	case "name_desc":
 		sort = "name desc"
+	case "position_asc":
+		sort = "position asc, name asc"
 	}
 	db = db.Order(sort)
 
@@ -92,3 +104,81 @@ func (r *categoryRepository) GetCategoryByID(CategoryID string) (*models.Categor
 	}
 	return &category, nil
 }
+
+func (r *categoryRepository) GetCategoryBySlug(slug string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.First(&category, "slug = ?", slug).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// GetCategoryNodes loads every category, the tree is small enough to be built in memory
+func (r *categoryRepository) GetCategoryNodes() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Order("position asc, name asc").Find(&categories).Error
+	return categories, err
+}
+
+// CountProductsByCategory counts the active products directly in each category
+func (r *categoryRepository) CountProductsByCategory() (map[uuid.UUID]int, error) {
+	var rows []struct {
+		CategoryID uuid.UUID
+		Total      int
+	}
+	err := r.db.Model(&models.Product{}).
+		Select("category_id, COUNT(*) AS total").
+		Where("is_active = ?", true).
+		Group("category_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Total
+	}
+	return counts, nil
+}
+
+// CountProducts counts every product in the category, inactive ones included
+func (r *categoryRepository) CountProducts(categoryID uuid.UUID) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.Product{}).Where("category_id = ?", categoryID).Count(&total).Error
+	return total, err
+}
+
+// NextPosition is the position after the last child of parentID, nil for top-level categories
+func (r *categoryRepository) NextPosition(parentID *uuid.UUID) (int, error) {
+	db := r.db.Model(&models.Category{})
+	if parentID == nil {
+		db = db.Where("parent_id IS NULL")
+	} else {
+		db = db.Where("parent_id = ?", *parentID)
+	}
+
+	var last *int
+	if err := db.Select("MAX(position)").Scan(&last).Error; err != nil {
+		return 0, err
+	}
+	if last == nil {
+		return 0, nil
+	}
+	return *last + 1, nil
+}
+
+func (r *categoryRepository) MoveProducts(fromID, toID uuid.UUID) error {
+	return r.db.Model(&models.Product{}).Where("category_id = ?", fromID).
+		Update("category_id", toID).Error
+}
+
+// MoveChildren re-parents the direct children of fromID, nil makes them top-level categories
+func (r *categoryRepository) MoveChildren(fromID uuid.UUID, toID *uuid.UUID) error {
+	return r.db.Model(&models.Category{}).Where("parent_id = ?", fromID).
+		Update("parent_id", toID).Error
+}
+
+func (r *categoryRepository) WithTx(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
@@ -1,41 +1,75 @@
 package repositories
 
 import (
+	"log"
+	"server/internal/config"
+	"server/internal/search"
+
 	"gorm.io/gorm"
 )
 
 type Repositories struct {
-	AdminRepository        AdminRepository
-	AuthRepository         AuthRepository
-	VoucherRepository      VoucherRepository
-	ProductRepository      ProductRepository
-	PaymentRepository      PaymentRepository
-	ProfileRepository      ProfileRepository
-	CartRepository         CartRepository
-	OrderRepository        OrderRepository
-	LocationRepository     LocationRepository
-	AddressRepository      AddressRepository
-	CategoryRepository     CategoryRepository
-	NotificationRepository NotificationRepository
-	BannerRepository       BannerRepository
-	ReviewRepository       ReviewRepository
+	AdminRepository          AdminRepository
+	AuthRepository           AuthRepository
+	VoucherRepository        VoucherRepository
+	ProductRepository        ProductRepository
+	PaymentRepository        PaymentRepository
+	ProfileRepository        ProfileRepository
+	CartRepository           CartRepository
+	GuestCartRepository      CartRepository
+	OrderRepository          OrderRepository
+	LocationRepository       LocationRepository
+	AddressRepository        AddressRepository
+	CategoryRepository       CategoryRepository
+	NotificationRepository   NotificationRepository
+	BannerRepository         BannerRepository
+	ReviewRepository         ReviewRepository
+	ReturnRepository         ReturnRepository
+	ReconciliationRepository ReconciliationRepository
+	InventoryRepository      InventoryRepository
+	StockAlertRepository     StockAlertRepository
+	ReservationRepository    ReservationRepository
+	WishlistRepository       WishlistRepository
+	SearchQueryRepository    SearchQueryRepository
+	RecommendationRepository RecommendationRepository
+	SearchIndex              search.SearchIndex
+	Suggester                *search.Suggester
 }
 
 func InitRepositories(db *gorm.DB) *Repositories {
 	return &Repositories{
-		AdminRepository:        NewAdminRepository(db),
-		AuthRepository:         NewAuthRepository(db),
-		ProductRepository:      NewProductRepository(db),
-		PaymentRepository:      NewPaymentRepository(db),
-		VoucherRepository:      NewVoucherRepository(db),
-		ProfileRepository:      NewProfileRepository(db),
-		CartRepository:         NewCartRepository(db),
-		OrderRepository:        NewOrderRepository(db),
-		LocationRepository:     NewLocationRepository(db),
-		AddressRepository:      NewAddressRepository(db),
-		CategoryRepository:     NewCategoryRepository(db),
-		NotificationRepository: NewNotificationRepository(db),
-		BannerRepository:       NewBannerRepository(db),
-		ReviewRepository:       NewReviewRepository(db),
+		AdminRepository:          NewAdminRepository(db),
+		AuthRepository:           NewAuthRepository(db),
+		ProductRepository:        NewProductRepository(db),
+		PaymentRepository:        NewPaymentRepository(db),
+		VoucherRepository:        NewVoucherRepository(db),
+		ProfileRepository:        NewProfileRepository(db),
+		CartRepository:           NewCartRepository(db),
+		GuestCartRepository:      NewGuestCartRepository(db),
+		OrderRepository:          NewOrderRepository(db),
+		LocationRepository:       NewLocationRepository(db),
+		AddressRepository:        NewAddressRepository(db),
+		CategoryRepository:       NewCategoryRepository(db),
+		NotificationRepository:   NewNotificationRepository(db),
+		BannerRepository:         NewBannerRepository(db),
+		ReviewRepository:         NewReviewRepository(db),
+		ReturnRepository:         NewReturnRepository(db),
+		ReconciliationRepository: NewReconciliationRepository(db),
+		InventoryRepository:      NewInventoryRepository(db),
+		StockAlertRepository:     NewStockAlertRepository(db),
+		ReservationRepository:    NewReservationRepository(db),
+		WishlistRepository:       NewWishlistRepository(db),
+		SearchQueryRepository:    NewSearchQueryRepository(db),
+		RecommendationRepository: NewRecommendationRepository(db),
+		SearchIndex:              newSearchIndex(config.SearchBackend, db),
+		Suggester:                search.NewSuggester(),
+	}
+}
+
+func newSearchIndex(backend string, db *gorm.DB) search.SearchIndex {
+	if backend == search.BackendMemory {
+		log.Println("⚠️  Using in-memory product search index, it is rebuilt from the database on start")
+		return search.NewMemoryIndex()
 	}
+	return search.NewMySQLIndex(db)
 }
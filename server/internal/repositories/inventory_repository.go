@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"server/internal/dto"
+	"server/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type InventoryRepository interface {
+	GetMovements(param dto.StockMovementQueryParam) ([]models.StockMovement, int64, error)
+	GetProductMismatches() ([]dto.StockMismatch, error)
+	GetVariantMismatches() ([]dto.StockMismatch, error)
+}
+
+type inventoryRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryRepository(db *gorm.DB) InventoryRepository {
+	return &inventoryRepository{db}
+}
+
+func (r *inventoryRepository) GetMovements(param dto.StockMovementQueryParam) ([]models.StockMovement, int64, error) {
+	var movements []models.StockMovement
+	var total int64
+
+	page := param.Page
+	if page <= 0 {
+		page = 1
+	}
+	limit := param.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	query := r.db.Model(&models.StockMovement{})
+
+	if param.ProductID != "" {
+		query = query.Where("product_id = ?", param.ProductID)
+	}
+	if param.VariantID != "" {
+		query = query.Where("variant_id = ?", param.VariantID)
+	}
+	if param.Type != "" {
+		query = query.Where("type = ?", param.Type)
+	}
+	if param.From != "" {
+		query = query.Where("DATE(created_at) >= ?", param.From)
+	}
+	if param.To != "" {
+		query = query.Where("DATE(created_at) <= ?", param.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// produk/varian yang sudah dihapus tetap tampil di riwayat
+	err := query.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Variant", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at desc").
+		Offset(offset).Limit(limit).
+		Find(&movements).Error
+	return movements, total, err
+}
+
+// GetProductMismatches compares Product.Stock with the sum of all its movements
+func (r *inventoryRepository) GetProductMismatches() ([]dto.StockMismatch, error) {
+	var rows []dto.StockMismatch
+	err := r.db.Table("products p").
+		Select("p.id AS product_id, p.name AS product_name, p.stock AS stock, COALESCE(SUM(m.quantity), 0) AS ledger_stock").
+		Joins("LEFT JOIN stock_movements m ON m.product_id = p.id").
+		Where("p.deleted_at IS NULL").
+		Group("p.id, p.name, p.stock").
+		Having("p.stock <> COALESCE(SUM(m.quantity), 0)").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetVariantMismatches compares each active variant's stock with its own movements
+func (r *inventoryRepository) GetVariantMismatches() ([]dto.StockMismatch, error) {
+	var rows []dto.StockMismatch
+	err := r.db.Table("product_variants v").
+		Select("v.product_id AS product_id, p.name AS product_name, v.id AS variant_id, v.sku AS sku, v.stock AS stock, COALESCE(SUM(m.quantity), 0) AS ledger_stock").
+		Joins("JOIN products p ON p.id = v.product_id AND p.deleted_at IS NULL").
+		Joins("LEFT JOIN stock_movements m ON m.variant_id = v.id").
+		Where("v.deleted_at IS NULL").
+		Group("v.id, v.product_id, p.name, v.sku, v.stock").
+		Having("v.stock <> COALESCE(SUM(m.quantity), 0)").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -19,6 +19,7 @@ type NotificationRepository interface {
 	GetNotificationSettingsByUser(userID uuid.UUID) ([]models.NotificationSetting, error)
 	GetUsersWithEnabledNotification(typeCode string) ([]models.NotificationSetting, error)
 	FindSetting(userID, typeID uuid.UUID, channel string) (*models.NotificationSetting, error)
+	GetUserIDsByRole(role string) ([]uuid.UUID, error)
 }
 
 type notificationRepository struct {
@@ -99,3 +100,9 @@ func (r *notificationRepository) GetTypeByCode(code string) (*models.Notificatio
 	err := r.db.Where("code = ?", code).First(&nt).Error
 	return &nt, err
 }
+
+func (r *notificationRepository) GetUserIDsByRole(role string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.User{}).Where("role = ?", role).Pluck("id", &ids).Error
+	return ids, err
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"fmt"
 	"server/internal/dto"
 	"server/internal/models"
 	"time"
@@ -12,16 +13,25 @@ import (
 type OrderRepository interface {
 	GetUserCart(userID uuid.UUID) ([]models.Cart, error)
 	GetMainAddress(userID uuid.UUID) (*models.Address, error)
+	GetUserAddress(userID, addressID uuid.UUID) (*models.Address, error)
 	CreateOrder(order *models.Order) error
 	ClearUserCart(userID uuid.UUID) error
 	UpdateOrder(order *models.Order) error
+	SetPaymentLink(orderID uuid.UUID, link string) error
 	GetOrderDetail(orderID string) (*models.Order, error)
 	GetShipmentByOrderID(orderID uuid.UUID) (*models.Shipment, error)
 	GetOrdersByUserID(userID string, param dto.OrderQueryParam) ([]models.Order, int64, error)
 	GetAllOrders(param dto.OrderQueryParam) ([]models.Order, int64, error)
 	CreateOrderItems(items []models.OrderItem) error
+	GetOrderItems(orderID uuid.UUID) ([]models.OrderItem, error)
+	SetItemSoldQuantity(itemID uuid.UUID, quantity int) error
 
 	MarkOrderDelivered(orderID uuid.UUID) error
+	MarkShipmentReturned(orderID uuid.UUID) error
+	AddRefundedAmount(orderID uuid.UUID, amount float64) error
+	UpdateOrderStatus(orderID uuid.UUID, from, to string) error
+	CreateStatusHistory(history *models.OrderStatusHistory) error
+	GetStatusHistory(orderID uuid.UUID) ([]models.OrderStatusHistory, error)
 	WithTx(fn func(tx *gorm.DB) error) error
 	CreateShipment(shipment *models.Shipment) error
 }
@@ -42,22 +52,41 @@ func (r *orderRepository) CreateOrderItems(items []models.OrderItem) error {
 	return r.db.Create(&items).Error
 }
 
+func (r *orderRepository) GetOrderItems(orderID uuid.UUID) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	err := r.db.Where("order_id = ?", orderID).Find(&items).Error
+	return items, err
+}
+
+func (r *orderRepository) SetItemSoldQuantity(itemID uuid.UUID, quantity int) error {
+	return r.db.Model(&models.OrderItem{}).Where("id = ?", itemID).
+		Update("sold_quantity", quantity).Error
+}
+
 func (r *orderRepository) GetMainAddress(userID uuid.UUID) (*models.Address, error) {
 	var addr models.Address
 	err := r.db.Where("user_id = ? AND is_main = ?", userID, true).First(&addr).Error
 	return &addr, err
 }
 
+func (r *orderRepository) GetUserAddress(userID, addressID uuid.UUID) (*models.Address, error) {
+	var addr models.Address
+	err := r.db.Where("id = ? AND user_id = ?", addressID, userID).First(&addr).Error
+	return &addr, err
+}
+
 func (r *orderRepository) GetUserCart(userID uuid.UUID) ([]models.Cart, error) {
 	var carts []models.Cart
 	err := r.db.Preload("Product.ProductGallery").
-		Where("user_id = ? AND is_checked = ?", userID, true).
+		Preload("Variant.Values.OptionValue.Option").
+		Preload("Variant.Images").
+		Where("user_id = ? AND is_checked = ? AND saved_for_later = ?", userID, true, false).
 		Find(&carts).Error
 	return carts, err
 }
 
 func (r *orderRepository) ClearUserCart(userID uuid.UUID) error {
-	return r.db.Where("user_id = ?", userID).Delete(&models.Cart{}).Error
+	return r.db.Where("user_id = ? AND is_checked = ? AND saved_for_later = ?", userID, true, false).Delete(&models.Cart{}).Error
 }
 
 func (r *orderRepository) GetAllOrders(param dto.OrderQueryParam) ([]models.Order, int64, error) {
@@ -211,8 +240,60 @@ func (r *orderRepository) MarkOrderDelivered(orderID uuid.UUID) error {
 	})
 }
 
+func (r *orderRepository) MarkShipmentReturned(orderID uuid.UUID) error {
+	return r.db.Model(&models.Shipment{}).
+		Where("order_id = ?", orderID).
+		Update("status", "returned").Error
+}
+
+// ** total refund tidak boleh melebihi yang dibayar customer
+func (r *orderRepository) AddRefundedAmount(orderID uuid.UUID, amount float64) error {
+	result := r.db.Model(&models.Order{}).
+		Where("id = ? AND refunded_amount + ? <= amount_to_pay", orderID, amount).
+		Update("refunded_amount", gorm.Expr("refunded_amount + ?", amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("refund amount exceeds the amount paid for order %s", orderID)
+	}
+	return nil
+}
+
 func (r *orderRepository) UpdateOrder(order *models.Order) error {
 	return r.db.Model(&models.Order{}).
 		Where("id = ?", order.ID).
 		Updates(order).Error
 }
+
+// ? hanya kolom payment_link, status order bisa sudah diubah webhook gateway
+func (r *orderRepository) SetPaymentLink(orderID uuid.UUID, link string) error {
+	return r.db.Model(&models.Order{}).Where("id = ?", orderID).
+		Update("payment_link", link).Error
+}
+
+// ** update bersyarat, gagal kalau status sudah diubah proses lain
+func (r *orderRepository) UpdateOrderStatus(orderID uuid.UUID, from, to string) error {
+	result := r.db.Model(&models.Order{}).
+		Where("id = ? AND status = ?", orderID, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("order %s is no longer in status %s", orderID, from)
+	}
+	return nil
+}
+
+func (r *orderRepository) CreateStatusHistory(history *models.OrderStatusHistory) error {
+	return r.db.Create(history).Error
+}
+
+func (r *orderRepository) GetStatusHistory(orderID uuid.UUID) ([]models.OrderStatusHistory, error) {
+	var histories []models.OrderStatusHistory
+	err := r.db.Where("order_id = ?", orderID).
+		Order("created_at asc").
+		Find(&histories).Error
+	return histories, err
+}
@@ -1,18 +1,28 @@
 package repositories
 
 import (
+	"fmt"
 	"server/internal/dto"
 	"server/internal/models"
+	"server/internal/pricing"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepository interface {
 	CreatePayment(payment *models.Payment) error
 	UpdatePayment(payment *models.Payment) error
+	AddRefundedAmount(orderID uuid.UUID, amount pricing.Money) error
+	MarkCODPaid(orderID uuid.UUID) error
+	MarkTransferVerified(orderID, adminID uuid.UUID, note *string) error
+	UpdateReceipt(orderID uuid.UUID, imageURL, note *string) error
 	GetPaymentByID(id string) (*models.Payment, error)
 	GetExpiredPendingPayments() ([]models.Payment, error)
+	GetStaleGatewayPayments(status string, from, to time.Time) ([]models.Payment, error)
+	UpdatePaymentStatus(orderID uuid.UUID, from, to string) error
 	GetPaymentByOrderID(orderID string) (*models.Payment, error)
 	GetAllUserPayments(param dto.PaymentQueryParam) ([]models.Payment, int64, error)
 }
@@ -51,6 +61,66 @@ func (r *paymentRepository) UpdatePayment(payment *models.Payment) error {
 	return r.db.Save(payment).Error
 }
 
+func (r *paymentRepository) MarkCODPaid(orderID uuid.UUID) error {
+	now := time.Now()
+	return r.db.Model(&models.Payment{}).
+		Where("order_id = ? AND method = ? AND status = ?", orderID, "cod", "pending").
+		Updates(map[string]interface{}{
+			"status":  "success",
+			"paid_at": now,
+		}).Error
+}
+
+// ** update bersyarat, transfer yang sudah diverifikasi admin lain tidak diproses ulang
+func (r *paymentRepository) MarkTransferVerified(orderID, adminID uuid.UUID, note *string) error {
+	now := time.Now()
+	result := r.db.Model(&models.Payment{}).
+		Where("order_id = ? AND method = ? AND status = ? AND receipt_image IS NOT NULL", orderID, "manual_transfer", "pending").
+		Updates(map[string]interface{}{
+			"status":      "success",
+			"paid_at":     now,
+			"verified_by": adminID,
+			"verified_at": now,
+			"note":        note,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no transfer receipt waiting for verification on order %s", orderID)
+	}
+	return nil
+}
+
+func (r *paymentRepository) UpdateReceipt(orderID uuid.UUID, imageURL, note *string) error {
+	return r.db.Model(&models.Payment{}).
+		Where("order_id = ?", orderID).
+		Updates(map[string]interface{}{
+			"receipt_image": imageURL,
+			"note":          note,
+		}).Error
+}
+
+// ** status jadi refunded kalau seluruh pembayaran sudah dikembalikan, dibandingkan dalam rupiah bulat
+// karena gateway hanya menerima nominal bulat
+func (r *paymentRepository) AddRefundedAmount(orderID uuid.UUID, amount pricing.Money) error {
+	var payment models.Payment
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&payment, "order_id = ?", orderID).Error; err != nil {
+		return err
+	}
+
+	refunded := pricing.FromFloat(payment.RefundedAmount) + amount
+	updates := map[string]interface{}{"refunded_amount": refunded.Float()}
+	if refunded >= pricing.Money(int64(payment.Total)) {
+		updates["status"] = "refunded"
+	}
+
+	return r.db.Model(&models.Payment{}).
+		Where("id = ?", payment.ID).
+		Updates(updates).Error
+}
+
 func (r *paymentRepository) GetAllUserPayments(param dto.PaymentQueryParam) ([]models.Payment, int64, error) {
 	var payments []models.Payment
 	var count int64
@@ -83,6 +153,11 @@ func (r *paymentRepository) GetAllUserPayments(param dto.PaymentQueryParam) ([]m
 		db = db.Where("payments.status = ?", param.Status)
 	}
 
+	// Filter method
+	if param.Method != "" && param.Method != "all" {
+		db = db.Where("payments.method = ?", param.Method)
+	}
+
 	// Sorting
 	sort := "paid_at desc"
 	switch param.Sort {
@@ -110,15 +185,50 @@ func (r *paymentRepository) GetAllUserPayments(param dto.PaymentQueryParam) ([]m
 	return payments, count, nil
 }
 
-// ** khusus cron job update status ke failed
+// ** khusus cron job, transfer manual yang tidak pernah upload bukti dalam 24 jam
+// pembayaran lewat gateway ditangani oleh job rekonsiliasi
 func (r *paymentRepository) GetExpiredPendingPayments() ([]models.Payment, error) {
 	var payments []models.Payment
 	threshold := time.Now().Add(-24 * time.Hour)
 
 	err := r.db.
 		Preload("Order.Items").
-		Where("status = ? AND paid_at <= ?", "waiting_payment", threshold).
+		Where("status = ? AND paid_at <= ?", "pending", threshold).
+		Where("method = ? AND receipt_image IS NULL", "manual_transfer").
 		Find(&payments).Error
 
 	return payments, err
 }
+
+// ** pembayaran gateway dengan status tertentu yang dibuat di antara from dan to
+func (r *paymentRepository) GetStaleGatewayPayments(status string, from, to time.Time) ([]models.Payment, error) {
+	var payments []models.Payment
+
+	err := r.db.
+		Preload("Order.Items").
+		Where("status = ? AND paid_at BETWEEN ? AND ?", status, from, to).
+		Where("method NOT IN ?", []string{"cod", "manual_transfer"}).
+		Order("paid_at asc").
+		Find(&payments).Error
+
+	return payments, err
+}
+
+// ** update bersyarat supaya tidak bentrok dengan webhook yang masuk bersamaan
+func (r *paymentRepository) UpdatePaymentStatus(orderID uuid.UUID, from, to string) error {
+	updates := map[string]interface{}{"status": to}
+	if to == "success" {
+		updates["paid_at"] = time.Now()
+	}
+
+	result := r.db.Model(&models.Payment{}).
+		Where("order_id = ? AND status = ?", orderID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("payment for order %s is no longer %s", orderID, from)
+	}
+	return nil
+}
@@ -2,13 +2,17 @@ package repositories
 
 import (
 	"fmt"
-	"server/internal/dto"
 	"server/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ? skor trending di bawah ini dianggap nol, supaya peluruhan berhenti menyentuh produknya
+const minTrendingScore = 0.01
+
 type ProductRepository interface {
 	DeleteProduct(id uuid.UUID) error
 	UpdateProduct(product *models.Product) error
@@ -17,9 +21,23 @@ type ProductRepository interface {
 	CreateProductGallery(image *models.ProductGallery) error
 	DeleteProductGalleryByProductID(productID uuid.UUID) error
 	GetProductBySlug(slug string) (*models.Product, error)
-	DecreaseProductStock(productID uuid.UUID, qty int) error
-	RestoreStockOnPaymentFailure(order *models.Order) error
-	SearchProducts(param dto.GetAllProductsRequest) ([]models.Product, int64, error)
+	MoveStock(movement *models.StockMovement) error
+	LockProductsForUpdate(ids []uuid.UUID) ([]models.Product, error)
+	LockVariantsForUpdate(ids []uuid.UUID) ([]models.ProductVariant, error)
+	GetProductsByIDs(ids []uuid.UUID) ([]models.Product, error)
+	GetSearchableProducts() ([]models.Product, error)
+	AddSold(productID uuid.UUID, delta int, trending float64) error
+	AddViews(productID uuid.UUID, views int64, trending float64) error
+	DecayTrendingScores(halfLife time.Duration) error
+
+	CreateProductOptions(options []models.ProductOption) error
+	DeleteProductOptions(productID uuid.UUID) error
+	GetVariantsBySKU(skus []string) ([]models.ProductVariant, error)
+	CreateVariant(variant *models.ProductVariant) error
+	UpdateVariant(variant *models.ProductVariant) error
+	DeleteVariants(ids []uuid.UUID) error
+	DeleteVariantImagesByProductID(productID uuid.UUID) error
+	WithTx(fn func(tx *gorm.DB) error) error
 }
 
 type productRepository struct {
@@ -39,12 +57,24 @@ func (r *productRepository) CreateProductGallery(image *models.ProductGallery) e
 }
 
 func (r *productRepository) UpdateProduct(product *models.Product) error {
-	return r.db.Save(product).Error
+	// gallery, opsi dan varian disimpan lewat method masing-masing,
+	// stok hanya berubah lewat MoveStock supaya tercatat di ledger
+	return r.db.Omit(clause.Associations, "Stock").Save(product).Error
+}
+
+// preloadVariants loads the option and variant matrix in display order
+func preloadVariants(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Options.Values", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, sku") }).
+		Preload("Variants.Values.OptionValue.Option").
+		Preload("Variants.Images")
 }
 
 func (r *productRepository) GetProductByID(id uuid.UUID) (*models.Product, error) {
 	var product models.Product
-	if err := r.db.Preload("ProductGallery").Preload("Category").First(&product, "id = ?", id).Error; err != nil {
+	if err := r.db.Preload("ProductGallery").Preload("Category").Scopes(preloadVariants).First(&product, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &product, nil
@@ -60,121 +90,217 @@ func (r *productRepository) DeleteProduct(id uuid.UUID) error {
 
 func (r *productRepository) GetProductBySlug(slug string) (*models.Product, error) {
 	var product models.Product
-	err := r.db.Preload("ProductGallery").Preload("Category").
+	err := r.db.Preload("ProductGallery").Preload("Category").Scopes(preloadVariants).
 		Where("slug = ?", slug).First(&product).Error
 	return &product, err
 }
 
-func (r *productRepository) SearchProducts(param dto.GetAllProductsRequest) ([]models.Product, int64, error) {
+// GetProductsByIDs loads the listing data of the products, in no particular order
+func (r *productRepository) GetProductsByIDs(ids []uuid.UUID) ([]models.Product, error) {
 	var products []models.Product
-	var total int64
-
-	// Pagination
-	page := param.Page
-	if page <= 0 {
-		page = 1
-	}
-	limit := param.Limit
-	if limit <= 0 {
-		limit = 10
+	if len(ids) == 0 {
+		return products, nil
 	}
-	offset := (page - 1) * limit
+	err := r.db.Preload("ProductGallery").
+		Preload("Category").
+		Scopes(preloadVariants).
+		Where("id IN ?", ids).
+		Find(&products).Error
+	return products, err
+}
+
+// GetSearchableProducts returns every product with its category for rebuilding the search index
+func (r *productRepository) GetSearchableProducts() ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Preload("Category").Find(&products).Error
+	return products, err
+}
 
-	// Base query
-	db := r.db.Model(&models.Product{}).
-		Preload("ProductGallery").
-		Preload("Category")
+// AddSold never takes Sold below zero, trending is added to the product's trending score
+func (r *productRepository) AddSold(productID uuid.UUID, delta int, trending float64) error {
+	return r.db.Model(&models.Product{}).Where("id = ?", productID).
+		UpdateColumns(map[string]interface{}{
+			"sold":           gorm.Expr("GREATEST(sold + ?, 0)", delta),
+			"trending_score": gorm.Expr("trending_score + ?", trending),
+		}).Error
+}
 
-	// Keyword search
-	if param.Search != "" {
-		likeQuery := "%" + param.Search + "%"
-		db = db.Where("products.name LIKE ? OR products.description LIKE ?", likeQuery, likeQuery)
-	}
+func (r *productRepository) AddViews(productID uuid.UUID, views int64, trending float64) error {
+	return r.db.Model(&models.Product{}).Where("id = ?", productID).
+		UpdateColumns(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + ?", views),
+			"trending_score": gorm.Expr("trending_score + ?", trending),
+		}).Error
+}
+
+// DecayTrendingScores halves every trending score once per halfLife since it was last decayed.
+// Only products that still trend are touched, scores that decay below minTrendingScore drop to
+// zero and lose trending_at so the next bump starts decaying from the following run.
+func (r *productRepository) DecayTrendingScores(halfLife time.Duration) error {
+	decayed := "trending_score * POW(0.5, TIMESTAMPDIFF(SECOND, COALESCE(trending_at, NOW()), NOW()) / ?)"
+	// ! MySQL assigns left to right, trending_score must be decayed before trending_at moves
+	return r.db.Exec(
+		"UPDATE products SET "+
+			"trending_score = CASE WHEN "+decayed+" < ? THEN 0 ELSE "+decayed+" END, "+
+			"trending_at = CASE WHEN trending_score > 0 THEN NOW() END "+
+			"WHERE (trending_score > 0 OR trending_at IS NOT NULL) AND deleted_at IS NULL",
+		halfLife.Seconds(), minTrendingScore, halfLife.Seconds(),
+	).Error
+}
 
-	// Category slug filter
-	if param.Category != "" {
-		db = db.Joins("JOIN categories ON categories.id = products.category_id").
-			Where("categories.slug = ?", param.Category)
+// MoveStock applies the signed movement to the variant and product stock and writes
+// it to the ledger. Outgoing movements fail instead of taking stock below zero.
+// Incoming movements also apply to soft deleted products and variants, every
+// movement either lands in the ledger or returns an error.
+func (r *productRepository) MoveStock(m *models.StockMovement) error {
+	if m.Quantity == 0 {
+		return nil
 	}
 
-	// Status filter
-	if param.Status != "" && param.Status != "all" {
-		switch param.Status {
-		case "active":
-			db = db.Where("products.is_active = ?", true)
-		case "inactive":
-			db = db.Where("products.is_active = ?", false)
-		case "featured":
-			db = db.Where("products.is_featured = ?", true)
-		case "unfeatured":
-			db = db.Where("products.is_featured = ?", false)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// ? stok yang kembali dari order batal atau retur tetap dicatat walau produknya sudah dihapus admin
+		rows := func() *gorm.DB {
+			if m.Quantity > 0 {
+				return tx.Unscoped()
+			}
+			return tx
 		}
-	}
 
-	// Price range filter
-	if param.MinPrice > 0 {
-		db = db.Where("products.price >= ?", param.MinPrice)
-	}
-	if param.MaxPrice > 0 {
-		db = db.Where("products.price <= ?", param.MaxPrice)
-	}
+		if m.VariantID != nil {
+			result := rows().Model(&models.ProductVariant{}).
+				Where("id = ? AND product_id = ? AND stock + ? >= 0", *m.VariantID, m.ProductID, m.Quantity).
+				Update("stock", gorm.Expr("stock + ?", m.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				if m.Quantity > 0 {
+					return fmt.Errorf("variant ID %s of product %s not found", *m.VariantID, m.ProductID)
+				}
+				return fmt.Errorf("insufficient stock for variant ID %s", *m.VariantID)
+			}
+		}
 
-	// Minimum rating filter
-	if param.Rating > 0 {
-		db = db.Where("products.average_rating >= ?", param.Rating)
-	}
+		// ** stok produk adalah total stok semua varian
+		result := rows().Model(&models.Product{}).
+			Where("id = ? AND stock + ? >= 0", m.ProductID, m.Quantity).
+			Update("stock", gorm.Expr("stock + ?", m.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if m.Quantity > 0 {
+				return fmt.Errorf("product ID %s not found", m.ProductID)
+			}
+			return fmt.Errorf("insufficient stock for product ID %s", m.ProductID)
+		}
+
+		balance := rows().Model(&models.Product{}).Where("id = ?", m.ProductID)
+		if m.VariantID != nil {
+			balance = rows().Model(&models.ProductVariant{}).Where("id = ?", *m.VariantID)
+		}
+		if err := balance.Select("stock").Scan(&m.StockAfter).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(m).Error
+	})
+}
+
+// ** dipanggil di dalam transaksi checkout supaya stok tidak oversell
+func (r *productRepository) LockProductsForUpdate(ids []uuid.UUID) ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&products).Error
+	return products, err
+}
 
-	// Sorting
-	sort := "products.created_at asc"
-	switch param.Sort {
-	case "price_asc":
-		sort = "products.price asc"
-	case "price_desc":
-		sort = "products.price desc"
-	case "stock_asc":
-		sort = "products.stock asc"
-	case "stock_desc":
-		sort = "products.stock desc"
-	case "created_at_asc":
-		sort = "products.created_at asc"
-	case "created_at_desc":
-		sort = "products.created_at desc"
-	case "rating_asc":
-		sort = "products.average_rating asc"
-	case "rating_desc":
-		sort = "products.average_rating desc"
-	case "name_desc":
-		sort = "products.name desc"
+func (r *productRepository) LockVariantsForUpdate(ids []uuid.UUID) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if len(ids) == 0 {
+		return variants, nil
 	}
-	db = db.Order(sort)
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&variants).Error
+	return variants, err
+}
 
-	// Total count
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
+func (r *productRepository) CreateProductOptions(options []models.ProductOption) error {
+	if len(options) == 0 {
+		return nil
 	}
+	return r.db.Create(&options).Error
+}
 
-	// Paginated result
-	if err := db.Offset(offset).Limit(limit).Find(&products).Error; err != nil {
-		return nil, 0, err
+func (r *productRepository) DeleteProductOptions(productID uuid.UUID) error {
+	optionIDs := r.db.Model(&models.ProductOption{}).Select("id").Where("product_id = ?", productID)
+	if err := r.db.Where("option_id IN (?)", optionIDs).Delete(&models.ProductOptionValue{}).Error; err != nil {
+		return err
 	}
+	return r.db.Where("product_id = ?", productID).Delete(&models.ProductOption{}).Error
+}
 
-	return products, total, nil
+// GetVariantsBySKU includes deleted variants, the SKU stays reserved after a soft delete
+func (r *productRepository) GetVariantsBySKU(skus []string) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if len(skus) == 0 {
+		return variants, nil
+	}
+	err := r.db.Unscoped().Where("sku IN ?", skus).Find(&variants).Error
+	return variants, err
 }
 
-func (r *productRepository) DecreaseProductStock(productID uuid.UUID, qty int) error {
-	return r.db.Model(&models.Product{}).
-		Where("id = ? AND stock >= ?", productID, qty).
-		Update("stock", gorm.Expr("stock - ?", qty)).Error
+func (r *productRepository) CreateVariant(variant *models.ProductVariant) error {
+	return r.db.Create(variant).Error
 }
 
-func (r *productRepository) RestoreStockOnPaymentFailure(order *models.Order) error {
-	for _, item := range order.Items {
-		err := r.db.Model(&models.Product{}).
-			Where("id = ?", item.ProductID).
-			Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error
-		if err != nil {
-			return fmt.Errorf("failed to restore stock for product ID %s: %w", item.ProductID, err)
+// UpdateVariant saves the variant fields and replaces its option values and images.
+// Stock is left alone, use MoveStock.
+func (r *productRepository) UpdateVariant(variant *models.ProductVariant) error {
+	if err := r.db.Where("variant_id = ?", variant.ID).Delete(&models.ProductVariantValue{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("variant_id = ?", variant.ID).Delete(&models.ProductVariantImage{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Unscoped().Omit(clause.Associations, "Stock").Save(variant).Error; err != nil {
+		return err
+	}
+
+	for i := range variant.Values {
+		variant.Values[i].VariantID = variant.ID
+	}
+	for i := range variant.Images {
+		variant.Images[i].VariantID = variant.ID
+	}
+	if len(variant.Values) > 0 {
+		if err := r.db.Omit(clause.Associations).Create(&variant.Values).Error; err != nil {
+			return err
 		}
 	}
+	if len(variant.Images) > 0 {
+		return r.db.Create(&variant.Images).Error
+	}
 	return nil
 }
+
+// DeleteVariants soft deletes, order items keep pointing at the old rows
+func (r *productRepository) DeleteVariants(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Where("id IN ?", ids).Delete(&models.ProductVariant{}).Error
+}
+
+func (r *productRepository) DeleteVariantImagesByProductID(productID uuid.UUID) error {
+	variantIDs := r.db.Unscoped().Model(&models.ProductVariant{}).Select("id").Where("product_id = ?", productID)
+	return r.db.Where("variant_id IN (?)", variantIDs).Delete(&models.ProductVariantImage{}).Error
+}
+
+func (r *productRepository) WithTx(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"server/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// purchasedOrderStatuses are the orders that count as a purchase for recommendations
+var purchasedOrderStatuses = []string{"pending", "process", "shipped", "delivered", "completed"}
+
+type RecommendationRepository interface {
+	GetCoPurchases(since time.Time) ([]models.ProductAffinity, error)
+	GetProductOrderCounts(since time.Time) (map[uuid.UUID]int, error)
+	ReplaceAffinities(affinities []models.ProductAffinity) error
+	GetAffinities(productIDs []uuid.UUID) ([]models.ProductAffinity, error)
+
+	GetPurchasedProductIDs(userID uuid.UUID, limit int) ([]uuid.UUID, error)
+	GetTopRatedProducts(categoryIDs []uuid.UUID, exclude []uuid.UUID, limit int) ([]models.Product, error)
+	GetTrendingProducts(exclude []uuid.UUID, limit int) ([]models.Product, error)
+}
+
+type recommendationRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationRepository(db *gorm.DB) RecommendationRepository {
+	return &recommendationRepository{db}
+}
+
+// GetCoPurchases counts, for every ordered pair of products, the orders since the given time containing both.
+// Score is left empty, the service weighs the counts.
+func (r *recommendationRepository) GetCoPurchases(since time.Time) ([]models.ProductAffinity, error) {
+	var pairs []models.ProductAffinity
+	err := r.db.Table("order_items a").
+		Select("a.product_id AS product_id, b.product_id AS related_product_id, COUNT(DISTINCT a.order_id) AS orders").
+		Joins("JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id AND b.deleted_at IS NULL").
+		Joins("JOIN orders o ON o.id = a.order_id AND o.deleted_at IS NULL").
+		Where("a.deleted_at IS NULL AND o.status IN ? AND o.created_at >= ?", purchasedOrderStatuses, since).
+		Group("a.product_id, b.product_id").
+		Scan(&pairs).Error
+	return pairs, err
+}
+
+// GetProductOrderCounts counts the orders since the given time each product appears in
+func (r *recommendationRepository) GetProductOrderCounts(since time.Time) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		ProductID uuid.UUID
+		Orders    int
+	}
+	err := r.db.Table("order_items oi").
+		Select("oi.product_id AS product_id, COUNT(DISTINCT oi.order_id) AS orders").
+		Joins("JOIN orders o ON o.id = oi.order_id AND o.deleted_at IS NULL").
+		Where("oi.deleted_at IS NULL AND o.status IN ? AND o.created_at >= ?", purchasedOrderStatuses, since).
+		Group("oi.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		counts[row.ProductID] = row.Orders
+	}
+	return counts, nil
+}
+
+// ReplaceAffinities swaps the whole affinity table in one transaction, readers never see it half built
+func (r *recommendationRepository) ReplaceAffinities(affinities []models.ProductAffinity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.ProductAffinity{}).Error; err != nil {
+			return err
+		}
+		if len(affinities) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&affinities, 500).Error
+	})
+}
+
+func (r *recommendationRepository) GetAffinities(productIDs []uuid.UUID) ([]models.ProductAffinity, error) {
+	var affinities []models.ProductAffinity
+	if len(productIDs) == 0 {
+		return affinities, nil
+	}
+	err := r.db.Where("product_id IN ?", productIDs).
+		Order("score DESC, orders DESC").
+		Find(&affinities).Error
+	return affinities, err
+}
+
+// GetPurchasedProductIDs returns the products the user bought, most recent purchase first
+func (r *recommendationRepository) GetPurchasedProductIDs(userID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var rows []struct {
+		ProductID uuid.UUID
+	}
+	err := r.db.Table("order_items oi").
+		Select("oi.product_id AS product_id, MAX(o.created_at) AS last_ordered_at").
+		Joins("JOIN orders o ON o.id = oi.order_id AND o.deleted_at IS NULL").
+		Where("oi.deleted_at IS NULL AND o.user_id = ? AND o.status IN ?", userID, purchasedOrderStatuses).
+		Group("oi.product_id").
+		Order("last_ordered_at DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ProductID)
+	}
+	return ids, nil
+}
+
+// GetTopRatedProducts returns active products in stock, best rated first. No categories means any category.
+func (r *recommendationRepository) GetTopRatedProducts(categoryIDs []uuid.UUID, exclude []uuid.UUID, limit int) ([]models.Product, error) {
+	db := r.recommendable(exclude)
+	if len(categoryIDs) > 0 {
+		db = db.Where("category_id IN ?", categoryIDs)
+	}
+
+	var products []models.Product
+	err := db.Order("average_rating DESC, review_count DESC, sold DESC, id").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+func (r *recommendationRepository) GetTrendingProducts(exclude []uuid.UUID, limit int) ([]models.Product, error) {
+	var products []models.Product
+	err := r.recommendable(exclude).
+		Order("trending_score DESC, sold DESC, id").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+func (r *recommendationRepository) recommendable(exclude []uuid.UUID) *gorm.DB {
+	db := r.db.Preload("ProductGallery").
+		Preload("Category").
+		Where("is_active = ? AND stock > 0", true)
+	if len(exclude) > 0 {
+		db = db.Where("id NOT IN ?", exclude)
+	}
+	return db
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"server/internal/dto"
+	"server/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepository interface {
+	CreateReports(reports []models.Reconciliation) error
+	GetReports(param dto.ReconciliationQueryParam) ([]models.Reconciliation, int64, error)
+	GetAllReports(param dto.ReconciliationQueryParam) ([]models.Reconciliation, error)
+	HasReport(paymentID uuid.UUID, action, gatewayAmount string) (bool, error)
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db}
+}
+
+func (r *reconciliationRepository) CreateReports(reports []models.Reconciliation) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	return r.db.Create(&reports).Error
+}
+
+func (r *reconciliationRepository) GetReports(param dto.ReconciliationQueryParam) ([]models.Reconciliation, int64, error) {
+	var reports []models.Reconciliation
+	var total int64
+
+	page := param.Page
+	if page <= 0 {
+		page = 1
+	}
+	limit := param.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	query := r.filter(param)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at desc").
+		Offset(offset).Limit(limit).
+		Find(&reports).Error
+	return reports, total, err
+}
+
+// ** tanpa pagination, dipakai untuk export CSV
+func (r *reconciliationRepository) GetAllReports(param dto.ReconciliationQueryParam) ([]models.Reconciliation, error) {
+	var reports []models.Reconciliation
+	err := r.filter(param).Order("created_at desc").Find(&reports).Error
+	return reports, err
+}
+
+// HasReport reports whether an earlier run already recorded the action for the payment at that gateway amount
+func (r *reconciliationRepository) HasReport(paymentID uuid.UUID, action, gatewayAmount string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Reconciliation{}).
+		Where("payment_id = ? AND action = ? AND gateway_amount = ?", paymentID, action, gatewayAmount).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *reconciliationRepository) filter(param dto.ReconciliationQueryParam) *gorm.DB {
+	query := r.db.Model(&models.Reconciliation{})
+
+	if param.RunID != "" {
+		query = query.Where("run_id = ?", param.RunID)
+	}
+
+	if param.Action != "" && param.Action != "all" {
+		query = query.Where("action = ?", param.Action)
+	}
+
+	// by default only rows that needed attention are shown
+	if param.Action == "" && !param.IncludeUnchanged {
+		query = query.Where("action <> ?", "unchanged")
+	}
+
+	if param.From != "" {
+		query = query.Where("DATE(created_at) >= ?", param.From)
+	}
+	if param.To != "" {
+		query = query.Where("DATE(created_at) <= ?", param.To)
+	}
+
+	return query
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"server/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReservationRepository interface {
+	Reserve(reservation *models.StockReservation) error
+	Release(userID, productID uuid.UUID, variantID *uuid.UUID) error
+	ReleaseAll(userID uuid.UUID) error
+	GetReservedByOthers(userID, productID uuid.UUID, variantID *uuid.UUID) (int, error)
+	GetReservedQuantities(productIDs []uuid.UUID) (map[uuid.UUID]int, map[uuid.UUID]int, error)
+	GetUserReservations(userID uuid.UUID) ([]models.StockReservation, error)
+	DeleteExpired() (int64, error)
+}
+
+type reservationRepository struct {
+	db *gorm.DB
+}
+
+func NewReservationRepository(db *gorm.DB) ReservationRepository {
+	return &reservationRepository{db}
+}
+
+// reservationLine scopes a query to one cart line, like cartItem
+func reservationLine(userID, productID uuid.UUID, variantID *uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ? AND product_id = ?", userID, productID)
+		if variantID == nil {
+			return db.Where("variant_id IS NULL")
+		}
+		return db.Where("variant_id = ?", *variantID)
+	}
+}
+
+func activeReservation(db *gorm.DB) *gorm.DB {
+	return db.Where("expires_at > ?", time.Now())
+}
+
+// Reserve replaces the user's hold on the cart line with the new quantity and expiry
+func (r *reservationRepository) Reserve(reservation *models.StockReservation) error {
+	var existing models.StockReservation
+	err := r.db.Scopes(reservationLine(reservation.UserID, reservation.ProductID, reservation.VariantID)).
+		First(&existing).Error
+	if err == nil {
+		return r.db.Model(&existing).Updates(map[string]interface{}{
+			"quantity":   reservation.Quantity,
+			"expires_at": reservation.ExpiresAt,
+		}).Error
+	}
+	return r.db.Create(reservation).Error
+}
+
+func (r *reservationRepository) Release(userID, productID uuid.UUID, variantID *uuid.UUID) error {
+	return r.db.Scopes(reservationLine(userID, productID, variantID)).
+		Delete(&models.StockReservation{}).Error
+}
+
+func (r *reservationRepository) ReleaseAll(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.StockReservation{}).Error
+}
+
+// GetReservedByOthers sums the live holds of other customers, on the variant when given
+// and on the whole product otherwise
+func (r *reservationRepository) GetReservedByOthers(userID, productID uuid.UUID, variantID *uuid.UUID) (int, error) {
+	var reserved int
+	query := r.db.Model(&models.StockReservation{}).
+		Scopes(activeReservation).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("user_id <> ? AND product_id = ?", userID, productID)
+	if variantID != nil {
+		query = query.Where("variant_id = ?", *variantID)
+	}
+	err := query.Scan(&reserved).Error
+	return reserved, err
+}
+
+// GetReservedQuantities returns live holds per product and per variant
+func (r *reservationRepository) GetReservedQuantities(productIDs []uuid.UUID) (map[uuid.UUID]int, map[uuid.UUID]int, error) {
+	byProduct := make(map[uuid.UUID]int)
+	byVariant := make(map[uuid.UUID]int)
+	if len(productIDs) == 0 {
+		return byProduct, byVariant, nil
+	}
+
+	var rows []struct {
+		ProductID uuid.UUID
+		VariantID *uuid.UUID
+		Quantity  int
+	}
+	err := r.db.Model(&models.StockReservation{}).
+		Scopes(activeReservation).
+		Select("product_id, variant_id, SUM(quantity) AS quantity").
+		Where("product_id IN ?", productIDs).
+		Group("product_id, variant_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, row := range rows {
+		byProduct[row.ProductID] += row.Quantity
+		if row.VariantID != nil {
+			byVariant[*row.VariantID] += row.Quantity
+		}
+	}
+	return byProduct, byVariant, nil
+}
+
+func (r *reservationRepository) GetUserReservations(userID uuid.UUID) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	err := r.db.Scopes(activeReservation).Where("user_id = ?", userID).Find(&reservations).Error
+	return reservations, err
+}
+
+func (r *reservationRepository) DeleteExpired() (int64, error) {
+	result := r.db.Where("expires_at <= ?", time.Now()).Delete(&models.StockReservation{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"fmt"
+	"server/internal/dto"
+	"server/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReturnRepository interface {
+	CreateReturn(ret *models.ReturnRequest) error
+	GetReturnByID(id string) (*models.ReturnRequest, error)
+	GetReturns(userID *uuid.UUID, param dto.ReturnQueryParam) ([]models.ReturnRequest, int64, error)
+	GetReturnedQuantities(orderID uuid.UUID) (map[uuid.UUID]int, error)
+	UpdateReturnStatus(id uuid.UUID, from, to string, fields map[string]interface{}) error
+	WithTx(fn func(tx *gorm.DB) error) error
+}
+
+type returnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) ReturnRepository {
+	return &returnRepository{db}
+}
+
+func (r *returnRepository) CreateReturn(ret *models.ReturnRequest) error {
+	return r.db.Create(ret).Error
+}
+
+func (r *returnRepository) GetReturnByID(id string) (*models.ReturnRequest, error) {
+	var ret models.ReturnRequest
+	err := r.db.
+		Preload("Order").
+		Preload("Items.OrderItem").
+		Preload("Images").
+		First(&ret, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (r *returnRepository) GetReturns(userID *uuid.UUID, param dto.ReturnQueryParam) ([]models.ReturnRequest, int64, error) {
+	var returns []models.ReturnRequest
+	var total int64
+
+	page := param.Page
+	if page <= 0 {
+		page = 1
+	}
+	limit := param.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	query := r.db.Model(&models.ReturnRequest{})
+
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	if param.Status != "" && param.Status != "all" {
+		query = query.Where("status = ?", param.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Preload("Order").
+		Preload("Items.OrderItem").
+		Preload("Images").
+		Order("created_at desc").
+		Offset(offset).Limit(limit).
+		Find(&returns).Error
+	return returns, total, err
+}
+
+// ** jumlah item yang sedang/sudah diretur per order item, retur yang ditolak tidak dihitung
+func (r *returnRepository) GetReturnedQuantities(orderID uuid.UUID) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		OrderItemID uuid.UUID
+		Quantity    int
+	}
+
+	err := r.db.Model(&models.ReturnItem{}).
+		Select("return_items.order_item_id, SUM(return_items.quantity) as quantity").
+		Joins("JOIN return_requests ON return_requests.id = return_items.return_request_id").
+		Where("return_requests.order_id = ? AND return_requests.status <> ?", orderID, "rejected").
+		Group("return_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		result[row.OrderItemID] = row.Quantity
+	}
+	return result, nil
+}
+
+// ** update bersyarat, gagal kalau status retur sudah diubah admin lain
+func (r *returnRepository) UpdateReturnStatus(id uuid.UUID, from, to string, fields map[string]interface{}) error {
+	updates := map[string]interface{}{"status": to}
+	for k, v := range fields {
+		updates[k] = v
+	}
+
+	result := r.db.Model(&models.ReturnRequest{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("return %s is no longer in status %s", id, from)
+	}
+	return nil
+}
+
+func (r *returnRepository) WithTx(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
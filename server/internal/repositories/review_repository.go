@@ -1,18 +1,40 @@
 package repositories
 
 import (
+	"math"
 	"server/internal/dto"
 	"server/internal/models"
+	"server/internal/utils"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReviewRepository interface {
 	CreateReview(review *models.Review) error
+	UpdateReview(review *models.Review) error
+	DeleteReview(id uuid.UUID) error
+	GetReviewByID(id uuid.UUID) (*models.Review, error)
+	ReplaceReviewImages(reviewID uuid.UUID, images []string) error
+	GetModerationQueue(param dto.ReviewModerationQueryParam) ([]models.Review, int64, error)
+	UpdateModeration(id uuid.UUID, status string, adminID uuid.UUID, reason string) error
+
+	HasVoted(reviewID, userID uuid.UUID) (bool, error)
+	CreateVote(vote *models.ReviewVote) error
+	DeleteVote(reviewID, userID uuid.UUID) error
+	RefreshHelpfulCount(reviewID uuid.UUID) error
+
+	SaveReply(reply *models.ReviewReply) error
+	DeleteReply(reviewID uuid.UUID) error
+
 	MarkItemAsReviewed(itemID uuid.UUID) error
+	UnmarkItemAsReviewed(itemID uuid.UUID) error
 	GetOrderItemByID(itemID string) (*models.OrderItem, error)
 	GetReviewsByProductID(productID uuid.UUID, param dto.ReviewQueryParam) ([]models.Review, int64, error)
+	RecalculateProductRating(productID uuid.UUID) error
+	WithTx(fn func(tx *gorm.DB) error) error
 }
 
 type reviewRepository struct {
@@ -27,9 +49,145 @@ func (r *reviewRepository) CreateReview(review *models.Review) error {
 	return r.db.Create(review).Error
 }
 
+func (r *reviewRepository) UpdateReview(review *models.Review) error {
+	return r.db.Model(review).Select("rating", "comment", "status", "flagged_reason").Updates(review).Error
+}
+
+// DeleteReview also removes the review's images, votes and reply
+func (r *reviewRepository) DeleteReview(id uuid.UUID) error {
+	for _, child := range []interface{}{&models.ReviewImage{}, &models.ReviewVote{}, &models.ReviewReply{}} {
+		if err := r.db.Where("review_id = ?", id).Delete(child).Error; err != nil {
+			return err
+		}
+	}
+	return r.db.Where("id = ?", id).Delete(&models.Review{}).Error
+}
+
+func (r *reviewRepository) GetReviewByID(id uuid.UUID) (*models.Review, error) {
+	var review models.Review
+	if err := r.db.Preload("Images").Preload("Reply").Where("id = ?", id).First(&review).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) ReplaceReviewImages(reviewID uuid.UUID, images []string) error {
+	if err := r.db.Where("review_id = ?", reviewID).Delete(&models.ReviewImage{}).Error; err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		return nil
+	}
+
+	rows := make([]models.ReviewImage, 0, len(images))
+	for _, image := range images {
+		rows = append(rows, models.ReviewImage{ReviewID: reviewID, Image: image})
+	}
+	return r.db.Create(&rows).Error
+}
+
+func (r *reviewRepository) GetModerationQueue(param dto.ReviewModerationQueryParam) ([]models.Review, int64, error) {
+	var reviews []models.Review
+	var total int64
+
+	db := r.db.Model(&models.Review{}).Where("status = ?", param.Status)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Preload("User.Profile").
+		Preload("Images").
+		Preload("Reply").
+		Preload("Product").
+		Order("created_at ASC").
+		Offset((param.Page - 1) * param.Limit).Limit(param.Limit).
+		Find(&reviews).Error
+
+	return reviews, total, err
+}
+
+func (r *reviewRepository) UpdateModeration(id uuid.UUID, status string, adminID uuid.UUID, reason string) error {
+	return r.db.Model(&models.Review{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"flagged_reason": reason,
+			"moderated_by":   adminID,
+			"moderated_at":   time.Now(),
+		}).Error
+}
+
+func (r *reviewRepository) HasVoted(reviewID, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ReviewVote{}).
+		Where("review_id = ? AND user_id = ?", reviewID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *reviewRepository) CreateVote(vote *models.ReviewVote) error {
+	return r.db.Create(vote).Error
+}
+
+func (r *reviewRepository) DeleteVote(reviewID, userID uuid.UUID) error {
+	result := r.db.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&models.ReviewVote{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *reviewRepository) RefreshHelpfulCount(reviewID uuid.UUID) error {
+	return r.db.Model(&models.Review{}).
+		Where("id = ?", reviewID).
+		UpdateColumn("helpful_count", r.db.Model(&models.ReviewVote{}).Select("COUNT(*)").Where("review_id = ?", reviewID)).Error
+}
+
+// SaveReply creates the merchant reply or overwrites the existing one
+func (r *reviewRepository) SaveReply(reply *models.ReviewReply) error {
+	var existing models.ReviewReply
+	if err := r.db.Where("review_id = ?", reply.ReviewID).First(&existing).Error; err == nil {
+		reply.ID = existing.ID
+		reply.CreatedAt = existing.CreatedAt
+		return r.db.Model(&existing).Updates(map[string]interface{}{
+			"admin_id": reply.AdminID,
+			"body":     reply.Body,
+		}).Error
+	}
+	return r.db.Create(reply).Error
+}
+
+func (r *reviewRepository) DeleteReply(reviewID uuid.UUID) error {
+	result := r.db.Where("review_id = ?", reviewID).Delete(&models.ReviewReply{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkItemAsReviewed only flips an unreviewed item, a concurrent second review gets ErrRecordNotFound
 func (r *reviewRepository) MarkItemAsReviewed(itemID uuid.UUID) error {
+	result := r.db.Model(&models.OrderItem{}).
+		Where("id = ? AND is_reviewed = ?", itemID, false).
+		Update("is_reviewed", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *reviewRepository) UnmarkItemAsReviewed(itemID uuid.UUID) error {
 	return r.db.Model(&models.OrderItem{}).Where("id = ?", itemID).
-		Update("is_reviewed", true).Error
+		Update("is_reviewed", false).Error
 }
 
 func (r *reviewRepository) GetOrderItemByID(itemID string) (*models.OrderItem, error) {
@@ -41,23 +199,92 @@ func (r *reviewRepository) GetOrderItemByID(itemID string) (*models.OrderItem, e
 	return &item, nil
 }
 
-
 func (r *reviewRepository) GetReviewsByProductID(productID uuid.UUID, param dto.ReviewQueryParam) ([]models.Review, int64, error) {
 	var reviews []models.Review
 	var total int64
 
 	offset := (param.Page - 1) * param.Limit
 
-	db := r.db.Model(&models.Review{}).Where("product_id = ?", productID)
+	db := r.db.Model(&models.Review{}).Where("product_id = ? AND status = ?", productID, "approved")
+	if param.Rating > 0 {
+		db = db.Where("rating = ?", param.Rating)
+	}
+	if param.WithPhotos {
+		db = db.Where("EXISTS (SELECT 1 FROM review_images ri WHERE ri.review_id = reviews.id)")
+	}
 
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
+	sort := "created_at DESC"
+	switch param.Sort {
+	case "helpful":
+		sort = "helpful_count DESC, created_at DESC"
+	case "rating_desc":
+		sort = "rating DESC, created_at DESC"
+	case "rating_asc":
+		sort = "rating ASC, created_at DESC"
+	}
+
 	err := db.Preload("User.Profile").
-		Order("created_at DESC").
+		Preload("Images").
+		Preload("Reply").
+		Order(sort).
 		Offset(offset).Limit(param.Limit).
 		Find(&reviews).Error
 
 	return reviews, total, err
 }
+
+// RecalculateProductRating rebuilds average, count and star histogram of the product from its approved reviews.
+// Baris produk dikunci supaya dua perubahan ulasan bersamaan tidak saling menimpa.
+func (r *reviewRepository) RecalculateProductRating(productID uuid.UUID) error {
+	var product models.Product
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&product, "id = ?", productID).Error; err != nil {
+		return err
+	}
+
+	var rows []struct {
+		Rating int
+		Total  int
+	}
+	err := r.db.Model(&models.Review{}).
+		Select("rating, COUNT(*) AS total").
+		Where("product_id = ? AND status = ?", productID, "approved").
+		Group("rating").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	histogram := make([]int, 5)
+	count, sum := 0, 0
+	for _, row := range rows {
+		if row.Rating < 1 || row.Rating > 5 {
+			continue
+		}
+		histogram[row.Rating-1] = row.Total
+		count += row.Total
+		sum += row.Rating * row.Total
+	}
+
+	average := 0.0
+	if count > 0 {
+		average = math.Round(float64(sum)/float64(count)*100) / 100
+	}
+
+	return r.db.Model(&models.Product{}).
+		Where("id = ?", productID).
+		UpdateColumns(map[string]interface{}{
+			"average_rating":   average,
+			"review_count":     count,
+			"rating_histogram": utils.IntSliceToJSON(histogram),
+		}).Error
+}
+
+func (r *reviewRepository) WithTx(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
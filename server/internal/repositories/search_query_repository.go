@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/search"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SearchQueryRepository interface {
+	Create(query *models.SearchQuery) error
+	GetPopularQueries(since time.Time, limit int) ([]search.PopularQuery, error)
+	GetZeroResultQueries(since time.Time, param dto.ZeroResultQueryParam) ([]dto.ZeroResultQueryResponse, int64, error)
+	DeleteOlderThan(before time.Time) (int64, error)
+}
+
+type searchQueryRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchQueryRepository(db *gorm.DB) SearchQueryRepository {
+	return &searchQueryRepository{db}
+}
+
+func (r *searchQueryRepository) Create(query *models.SearchQuery) error {
+	return r.db.Create(query).Error
+}
+
+// GetPopularQueries ranks the searches that found something by how often they were made
+func (r *searchQueryRepository) GetPopularQueries(since time.Time, limit int) ([]search.PopularQuery, error) {
+	var queries []search.PopularQuery
+	err := r.db.Model(&models.SearchQuery{}).
+		Select("query, COUNT(*) AS count").
+		Where("created_at >= ? AND result_count > 0", since).
+		Group("query").
+		Order("count DESC, query").
+		Limit(limit).
+		Scan(&queries).Error
+	return queries, err
+}
+
+func (r *searchQueryRepository) GetZeroResultQueries(since time.Time, param dto.ZeroResultQueryParam) ([]dto.ZeroResultQueryResponse, int64, error) {
+	var rows []dto.ZeroResultQueryResponse
+	var total int64
+
+	db := r.db.Model(&models.SearchQuery{}).
+		Where("created_at >= ? AND result_count = 0", since)
+
+	if err := db.Distinct("query").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.db.Model(&models.SearchQuery{}).
+		Select("query, COUNT(*) AS searches, MAX(created_at) AS last_searched_at").
+		Where("created_at >= ? AND result_count = 0", since).
+		Group("query").
+		Order("searches DESC, last_searched_at DESC").
+		Offset((param.Page - 1) * param.Limit).Limit(param.Limit).
+		Scan(&rows).Error
+
+	return rows, total, err
+}
+
+func (r *searchQueryRepository) DeleteOlderThan(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.SearchQuery{})
+	return result.RowsAffected, result.Error
+}
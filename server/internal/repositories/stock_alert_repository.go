@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"server/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StockAlertRepository interface {
+	GetLowStockProducts() ([]models.Product, error)
+	MarkLowStockAlerted(ids []uuid.UUID) error
+	ResetRecoveredLowStock() error
+
+	CreateSubscription(sub *models.RestockSubscription) error
+	GetPendingSubscription(userID, productID uuid.UUID, variantID *uuid.UUID) (*models.RestockSubscription, error)
+	GetUserSubscriptions(userID uuid.UUID) ([]models.RestockSubscription, error)
+	DeleteSubscription(userID, id uuid.UUID) error
+	GetRestockedSubscriptions() ([]models.RestockSubscription, error)
+	MarkSubscriptionsNotified(ids []uuid.UUID) error
+}
+
+type stockAlertRepository struct {
+	db *gorm.DB
+}
+
+func NewStockAlertRepository(db *gorm.DB) StockAlertRepository {
+	return &stockAlertRepository{db}
+}
+
+// GetLowStockProducts returns products at or below their threshold that admins were not told about yet
+func (r *stockAlertRepository) GetLowStockProducts() ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Where("low_stock_threshold IS NOT NULL AND stock <= low_stock_threshold AND low_stock_alerted_at IS NULL").
+		Find(&products).Error
+	return products, err
+}
+
+func (r *stockAlertRepository) MarkLowStockAlerted(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Product{}).
+		Where("id IN ?", ids).
+		Update("low_stock_alerted_at", time.Now()).Error
+}
+
+// ResetRecoveredLowStock re-arms the alert once stock is back above the threshold
+func (r *stockAlertRepository) ResetRecoveredLowStock() error {
+	return r.db.Model(&models.Product{}).
+		Where("low_stock_alerted_at IS NOT NULL AND (low_stock_threshold IS NULL OR stock > low_stock_threshold)").
+		Update("low_stock_alerted_at", nil).Error
+}
+
+func (r *stockAlertRepository) CreateSubscription(sub *models.RestockSubscription) error {
+	return r.db.Create(sub).Error
+}
+
+func (r *stockAlertRepository) GetPendingSubscription(userID, productID uuid.UUID, variantID *uuid.UUID) (*models.RestockSubscription, error) {
+	var sub models.RestockSubscription
+	query := r.db.Where("user_id = ? AND product_id = ? AND notified_at IS NULL", userID, productID)
+	if variantID == nil {
+		query = query.Where("variant_id IS NULL")
+	} else {
+		query = query.Where("variant_id = ?", *variantID)
+	}
+	if err := query.First(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *stockAlertRepository) GetUserSubscriptions(userID uuid.UUID) ([]models.RestockSubscription, error) {
+	var subs []models.RestockSubscription
+	err := r.db.Preload("Product.ProductGallery").
+		Preload("Variant.Values.OptionValue.Option").
+		Preload("Variant.Images").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&subs).Error
+	return subs, err
+}
+
+func (r *stockAlertRepository) DeleteSubscription(userID, id uuid.UUID) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.RestockSubscription{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetRestockedSubscriptions returns pending subscriptions whose product or variant has stock again
+func (r *stockAlertRepository) GetRestockedSubscriptions() ([]models.RestockSubscription, error) {
+	var subs []models.RestockSubscription
+	err := r.db.Preload("Product").
+		Preload("Variant.Values.OptionValue.Option").
+		Joins("JOIN products p ON p.id = restock_subscriptions.product_id AND p.deleted_at IS NULL AND p.is_active = ?", true).
+		Joins("LEFT JOIN product_variants v ON v.id = restock_subscriptions.variant_id AND v.deleted_at IS NULL").
+		Where("restock_subscriptions.notified_at IS NULL").
+		Where("(restock_subscriptions.variant_id IS NULL AND p.stock > 0) OR (v.is_active = ? AND v.stock > 0)", true).
+		Find(&subs).Error
+	return subs, err
+}
+
+func (r *stockAlertRepository) MarkSubscriptionsNotified(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.RestockSubscription{}).
+		Where("id IN ?", ids).
+		Update("notified_at", time.Now()).Error
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"errors"
 	"server/internal/models"
 	"time"
 
@@ -12,10 +13,12 @@ type VoucherRepository interface {
 	Create(v *models.Voucher) error
 	GetAll() ([]models.Voucher, error)
 	UpdateVoucher(v *models.Voucher) error
+	DecreaseQuota(id uuid.UUID) error
 	GetByCode(code string) (*models.Voucher, error)
 	GetByID(id uuid.UUID) (*models.Voucher, error)
 	DeleteByID(id uuid.UUID) error
 	InsertUsedVoucher(userID, voucherID uuid.UUID) error
+	DeleteUsedVoucher(userID, voucherID uuid.UUID) error
 	CheckVoucherUsed(userID, voucherID uuid.UUID) (bool, error)
 	GetValidVoucherByCode(code string) (*models.Voucher, error)
 }
@@ -36,6 +39,19 @@ func (r *voucherRepository) UpdateVoucher(v *models.Voucher) error {
 	return r.db.Save(v).Error
 }
 
+func (r *voucherRepository) DecreaseQuota(id uuid.UUID) error {
+	result := r.db.Model(&models.Voucher{}).
+		Where("id = ? AND quota > 0", id).
+		Update("quota", gorm.Expr("quota - 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("voucher quota exhausted")
+	}
+	return nil
+}
+
 func (r *voucherRepository) GetAll() ([]models.Voucher, error) {
 	var vouchers []models.Voucher
 	err := r.db.Order("created_at desc").Find(&vouchers).Error
@@ -76,6 +92,11 @@ func (r *voucherRepository) InsertUsedVoucher(userID, voucherID uuid.UUID) error
 	}).Error
 }
 
+func (r *voucherRepository) DeleteUsedVoucher(userID, voucherID uuid.UUID) error {
+	return r.db.Where("user_id = ? AND voucher_id = ?", userID, voucherID).
+		Delete(&models.UsedVoucher{}).Error
+}
+
 func (r *voucherRepository) GetByID(id uuid.UUID) (*models.Voucher, error) {
 	var v models.Voucher
 	err := r.db.First(&v, "id = ?", id).Error
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"server/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WishlistRepository interface {
+	Create(item *models.WishlistItem) error
+	Delete(userID, id uuid.UUID) error
+	GetByID(userID, id uuid.UUID) (*models.WishlistItem, error)
+	GetItem(userID, productID uuid.UUID, variantID *uuid.UUID) (*models.WishlistItem, error)
+	GetByUserID(userID uuid.UUID) ([]models.WishlistItem, error)
+	CountByProducts(productIDs []uuid.UUID) (map[uuid.UUID]int, error)
+	GetAllWithProducts() ([]models.WishlistItem, error)
+	UpdateKnownPrice(id uuid.UUID, price float64) error
+}
+
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) WishlistRepository {
+	return &wishlistRepository{db}
+}
+
+func (r *wishlistRepository) Create(item *models.WishlistItem) error {
+	return r.db.Create(item).Error
+}
+
+func (r *wishlistRepository) Delete(userID, id uuid.UUID) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.WishlistItem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *wishlistRepository) GetByID(userID, id uuid.UUID) (*models.WishlistItem, error) {
+	var item models.WishlistItem
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *wishlistRepository) GetItem(userID, productID uuid.UUID, variantID *uuid.UUID) (*models.WishlistItem, error) {
+	var item models.WishlistItem
+	query := r.db.Where("user_id = ? AND product_id = ?", userID, productID)
+	if variantID == nil {
+		query = query.Where("variant_id IS NULL")
+	} else {
+		query = query.Where("variant_id = ?", *variantID)
+	}
+	if err := query.First(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *wishlistRepository) GetByUserID(userID uuid.UUID) ([]models.WishlistItem, error) {
+	var items []models.WishlistItem
+	err := r.db.Preload("Product.ProductGallery").
+		Preload("Variant.Values.OptionValue.Option").
+		Preload("Variant.Images").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&items).Error
+	return items, err
+}
+
+// CountByProducts returns how many users wishlisted each product
+func (r *wishlistRepository) CountByProducts(productIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int)
+	if len(productIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ProductID uuid.UUID
+		Total     int
+	}
+	err := r.db.Model(&models.WishlistItem{}).
+		Select("product_id, COUNT(DISTINCT user_id) AS total").
+		Where("product_id IN ?", productIDs).
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ProductID] = row.Total
+	}
+	return counts, nil
+}
+
+// GetAllWithProducts returns wishlist items of products that are still on sale
+func (r *wishlistRepository) GetAllWithProducts() ([]models.WishlistItem, error) {
+	var items []models.WishlistItem
+	err := r.db.Preload("Product").
+		Preload("Variant.Values.OptionValue.Option").
+		Joins("JOIN products p ON p.id = wishlist_items.product_id AND p.deleted_at IS NULL AND p.is_active = ?", true).
+		Find(&items).Error
+	return items, err
+}
+
+func (r *wishlistRepository) UpdateKnownPrice(id uuid.UUID, price float64) error {
+	return r.db.Model(&models.WishlistItem{}).
+		Where("id = ?", id).
+		Update("known_price", price).Error
+}
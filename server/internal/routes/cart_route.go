@@ -3,6 +3,7 @@ package routes
 import (
 	"server/internal/handlers"
 	"server/internal/middleware"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,10 +12,27 @@ func CartRoutes(r *gin.Engine, h *handlers.CartHandler) {
 	cart := r.Group("/api/cart", middleware.AuthRequired(), middleware.RoleOnly("customer"))
 
 	cart.GET("", h.GetCart)
-	cart.POST("", h.AddToCart)
+	cart.POST("", middleware.Idempotency(24*time.Hour), h.AddToCart)
 	cart.DELETE("", h.ClearCart)
 	cart.DELETE("/:productId", h.RemoveItem)
 	cart.PUT("/:productId", h.UpdateQuantity)
 	cart.PATCH("/:productId/checked", h.ToggleChecked)
+	cart.POST("/acknowledge-prices", h.AcknowledgePrices)
+
+	cart.GET("/saved", h.GetSavedItems)
+	cart.PATCH("/:productId/save-for-later", h.SaveForLater)
+	cart.PATCH("/:productId/move-to-cart", h.MoveToCart)
+}
 
+// GuestCartRoutes lets visitors build a cart before signing in, it is merged into their cart on login
+func GuestCartRoutes(r *gin.Engine, h *handlers.CartHandler) {
+	cart := r.Group("/api/guest-cart", middleware.GuestCart())
+
+	cart.GET("", h.GetCart)
+	cart.POST("", h.AddToCart)
+	cart.DELETE("", h.ClearCart)
+	cart.DELETE("/:productId", h.RemoveItem)
+	cart.PUT("/:productId", h.UpdateQuantity)
+	cart.PATCH("/:productId/checked", h.ToggleChecked)
+	cart.POST("/acknowledge-prices", h.AcknowledgePrices)
 }
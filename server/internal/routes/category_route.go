@@ -10,6 +10,8 @@ import (
 func CategoryRoutes(r *gin.Engine, h *handlers.CategoryHandler) {
 	category := r.Group("/api/categories")
 	category.GET("", h.GetAllCategories)
+	category.GET("/tree", h.GetCategoryTree)
+	category.GET("/:slug", h.GetCategoryBySlug)
 
 	admin := category.Use(middleware.AuthRequired(), middleware.RoleOnly("admin"))
 	admin.POST("", h.CreateCategory)
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"server/internal/handlers"
+	"server/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InventoryRoutes(r *gin.Engine, h *handlers.InventoryHandler) {
+	inventory := r.Group("/api/admin/inventory")
+	inventory.Use(middleware.AuthRequired(), middleware.RoleOnly("admin"))
+
+	inventory.GET("/movements", h.GetMovements)
+	inventory.GET("/consistency", h.CheckConsistency)
+	inventory.POST("/adjustments", h.AdjustStock)
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"server/internal/handlers"
 	"server/internal/middleware"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,10 +12,14 @@ func OrderRoutes(r *gin.Engine, h *handlers.OrderHandler) {
 
 	order := r.Group("/api/orders", middleware.AuthRequired())
 	order.POST("/check-shipping", h.CheckShippingCost)
+	order.POST("/quote", middleware.RoleOnly("customer"), h.Quote)
 
-	order.POST("", middleware.RoleOnly("customer"), h.Checkout)
+	order.POST("", middleware.RoleOnly("customer"), middleware.Idempotency(24*time.Hour), h.Checkout)
 	order.GET("", middleware.RoleOnly("admin", "customer"), h.GetAllUserOrders)
 	order.GET("/:orderID", middleware.RoleOnly("admin", "customer"), h.GetOrderDetail)
+	order.POST("/:orderID/cancel", middleware.RoleOnly("customer"), h.CancelOrder)
+	order.PATCH("/:orderID/status", middleware.RoleOnly("admin"), h.UpdateOrderStatus)
+	order.GET("/:orderID/history", middleware.RoleOnly("admin", "customer"), h.GetOrderHistory)
 
 	order.POST("/:orderID/shipment", middleware.RoleOnly("admin"), h.CreateShipment)
 	order.PUT("/:orderID/shipment", middleware.RoleOnly("admin"), h.UpdateShipmentStatus)
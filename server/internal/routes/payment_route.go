@@ -7,9 +7,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func PaymentRoutes(r *gin.Engine, h *handlers.PaymentHandler) {
+// fakeGateway registers the local pay page, it only exists while the fake gateway is active
+func PaymentRoutes(r *gin.Engine, h *handlers.PaymentHandler, fakeGateway bool) {
 	order := r.Group("/api/payments")
 	order.POST("/notifications", h.HandlePaymentNotification)
+	if fakeGateway {
+		order.GET("/fake/:orderID", h.FakePayPage)
+		order.POST("/fake/:orderID", h.SimulateFakePayment)
+	}
 	order.GET("", middleware.AuthRequired(), middleware.RoleOnly("customer", "admin"), h.GetAllUserPayments)
+	order.POST("/:orderID/receipt", middleware.AuthRequired(), middleware.RoleOnly("customer"), h.UploadTransferReceipt)
+	order.PATCH("/:orderID/verify", middleware.AuthRequired(), middleware.RoleOnly("admin"), h.VerifyTransfer)
 
 }
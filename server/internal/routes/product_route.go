@@ -10,7 +10,7 @@ import (
 func ProductRoutes(r *gin.Engine, h *handlers.ProductHandler) {
 	product := r.Group("/api/product")
 	product.GET("", h.SearchProducts)
-	product.GET("/:slug", h.GetProductBySlug)
+	product.GET("/:slug", middleware.OptionalAuth(), h.GetProductBySlug)
 
 	admin := product.Use(middleware.AuthRequired(), middleware.RoleOnly("admin"))
 	admin.POST("", h.CreateProduct)
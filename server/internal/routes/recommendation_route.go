@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"server/internal/handlers"
+	"server/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RecommendationRoutes(r *gin.Engine, h *handlers.RecommendationHandler) {
+	product := r.Group("/api/product")
+	product.GET("/recommended", middleware.AuthRequired(), middleware.RoleOnly("customer"), h.GetRecommendedForYou)
+	product.GET("/:slug/related", h.GetRelated)
+	product.GET("/:slug/bought-together", h.GetBoughtTogether)
+}
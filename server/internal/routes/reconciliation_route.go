@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"server/internal/handlers"
+	"server/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReconciliationRoutes(r *gin.Engine, h *handlers.ReconciliationHandler) {
+	recon := r.Group("/api/admin/reconciliations")
+	recon.Use(middleware.AuthRequired(), middleware.RoleOnly("admin"))
+
+	recon.GET("", h.GetReports)
+	recon.GET("/export", h.ExportReports)
+	recon.POST("/run", h.RunReconciliation)
+}
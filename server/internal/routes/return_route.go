@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"server/internal/handlers"
+	"server/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReturnRoutes(r *gin.Engine, h *handlers.ReturnHandler) {
+	ret := r.Group("/api/returns", middleware.AuthRequired())
+
+	ret.POST("", middleware.RoleOnly("customer"), h.CreateReturn)
+	ret.GET("", middleware.RoleOnly("admin", "customer"), h.GetReturns)
+	ret.GET("/:returnID", middleware.RoleOnly("admin", "customer"), h.GetReturnDetail)
+
+	ret.PATCH("/:returnID/approve", middleware.RoleOnly("admin"), h.ApproveReturn)
+	ret.PATCH("/:returnID/reject", middleware.RoleOnly("admin"), h.RejectReturn)
+	ret.PATCH("/:returnID/receive", middleware.RoleOnly("admin"), h.ReceiveReturn)
+	ret.POST("/:returnID/refund", middleware.RoleOnly("admin"), h.RefundReturn)
+}
@@ -13,4 +13,15 @@ func ReviewRoutes(r *gin.Engine, h *handlers.ReviewHandler) {
 
 	auth := review.Use(middleware.AuthRequired())
 	auth.POST("/order/:itemID", h.CreateReview)
+	auth.PUT("/:reviewID", h.UpdateReview)
+	auth.DELETE("/:reviewID", h.DeleteReview)
+	auth.POST("/:reviewID/helpful", h.VoteHelpful)
+	auth.DELETE("/:reviewID/helpful", h.UnvoteHelpful)
+
+	admin := r.Group("/api/admin/reviews")
+	admin.Use(middleware.AuthRequired(), middleware.RoleOnly("admin"))
+	admin.GET("", h.GetModerationQueue)
+	admin.PATCH("/:reviewID/moderate", h.ModerateReview)
+	admin.PUT("/:reviewID/reply", h.ReplyToReview)
+	admin.DELETE("/:reviewID/reply", h.DeleteReply)
 }
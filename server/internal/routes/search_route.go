@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"server/internal/handlers"
+	"server/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SearchRoutes(r *gin.Engine, h *handlers.SearchHandler) {
+	r.GET("/api/product/suggest", h.Suggest)
+
+	admin := r.Group("/api/admin/search")
+	admin.Use(middleware.AuthRequired(), middleware.RoleOnly("admin"))
+	admin.GET("/zero-results", h.GetZeroResultQueries)
+}
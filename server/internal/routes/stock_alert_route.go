@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"server/internal/handlers"
+	"server/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func StockAlertRoutes(r *gin.Engine, h *handlers.StockAlertHandler) {
+	restock := r.Group("/api/restock-subscriptions", middleware.AuthRequired(), middleware.RoleOnly("customer"))
+	restock.GET("", h.GetSubscriptions)
+	restock.POST("", h.Subscribe)
+	restock.DELETE("/:id", h.Unsubscribe)
+
+	admin := r.Group("/api/admin/inventory", middleware.AuthRequired(), middleware.RoleOnly("admin"))
+	admin.POST("/alerts/run", h.CheckAlerts)
+}
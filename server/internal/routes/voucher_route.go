@@ -3,6 +3,7 @@ package routes
 import (
 	"server/internal/handlers"
 	"server/internal/middleware"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,7 +12,7 @@ func VoucherRoutes(r *gin.Engine, h *handlers.VoucherHandler) {
 	v := r.Group("/api/vouchers")
 	v.Use(middleware.AuthRequired())
 	{
-		v.POST("/apply", middleware.RoleOnly("customer"), h.ApplyVoucher)
+		v.POST("/apply", middleware.RoleOnly("customer"), middleware.Idempotency(24*time.Hour), h.ApplyVoucher)
 		v.GET("", h.GetAllVouchers)
 		v.POST("", middleware.RoleOnly("admin"), h.CreateVoucher)
 		v.PUT(":id", h.UpdateVoucher)
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"server/internal/handlers"
+	"server/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WishlistRoutes(r *gin.Engine, h *handlers.WishlistHandler) {
+	wishlist := r.Group("/api/wishlist", middleware.AuthRequired(), middleware.RoleOnly("customer"))
+
+	wishlist.GET("", h.GetWishlist)
+	wishlist.POST("", h.AddItem)
+	wishlist.DELETE("/:id", h.RemoveItem)
+	wishlist.POST("/:id/cart", h.AddToCart)
+}
@@ -0,0 +1,312 @@
+package search
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ** kata di nama produk lebih menentukan relevansi daripada kata di deskripsi
+const (
+	nameFieldWeight        = 3.0
+	descriptionFieldWeight = 1.0
+)
+
+type memoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[uuid.UUID]Document
+	postings map[string]map[uuid.UUID]float64 // term -> document -> weighted term frequency
+	docFreq  map[string]int
+}
+
+// NewMemoryIndex keeps an inverted index of the documents in the process
+func NewMemoryIndex() SearchIndex {
+	idx := &memoryIndex{}
+	idx.reset()
+	return idx
+}
+
+func (m *memoryIndex) reset() {
+	m.docs = make(map[uuid.UUID]Document)
+	m.postings = make(map[string]map[uuid.UUID]float64)
+	m.docFreq = make(map[string]int)
+}
+
+func (m *memoryIndex) Index(doc Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(doc.ID)
+	m.add(doc)
+	return nil
+}
+
+func (m *memoryIndex) Remove(id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(id)
+	return nil
+}
+
+func (m *memoryIndex) Rebuild(docs []Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reset()
+	for _, doc := range docs {
+		m.add(doc)
+	}
+	return nil
+}
+
+func (m *memoryIndex) add(doc Document) {
+	m.docs[doc.ID] = doc
+
+	frequencies := make(map[string]float64)
+	for _, term := range tokenize(doc.Name) {
+		frequencies[term] += nameFieldWeight
+	}
+	for _, term := range tokenize(doc.Description) {
+		frequencies[term] += descriptionFieldWeight
+	}
+
+	for term, tf := range frequencies {
+		if m.postings[term] == nil {
+			m.postings[term] = make(map[uuid.UUID]float64)
+		}
+		m.postings[term][doc.ID] = tf
+		m.docFreq[term]++
+	}
+}
+
+func (m *memoryIndex) remove(id uuid.UUID) {
+	if _, ok := m.docs[id]; !ok {
+		return
+	}
+	delete(m.docs, id)
+
+	for term, docs := range m.postings {
+		if _, ok := docs[id]; !ok {
+			continue
+		}
+		delete(docs, id)
+		if m.docFreq[term]--; m.docFreq[term] == 0 {
+			delete(m.postings, term)
+			delete(m.docFreq, term)
+		}
+	}
+}
+
+func (m *memoryIndex) Search(q Query) (*Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	scores := m.score(q.Text)
+	var matched []Document
+	for id, doc := range m.docs {
+		if scores != nil {
+			if _, ok := scores[id]; !ok {
+				continue
+			}
+		}
+		if matchesStatus(doc, q.Status) {
+			matched = append(matched, doc)
+		}
+	}
+
+	result := &Result{Facets: memoryFacets(matched, q)}
+
+	var hits []Document
+	for _, doc := range matched {
+		if matchesFilters(doc, q, facetNone) {
+			hits = append(hits, doc)
+		}
+	}
+	sortDocuments(hits, scores, q.Sort)
+
+	result.Total = int64(len(hits))
+	start := min((q.Page-1)*q.Limit, len(hits))
+	end := min(start+q.Limit, len(hits))
+	for _, doc := range hits[start:end] {
+		result.IDs = append(result.IDs, doc.ID)
+	}
+	return result, nil
+}
+
+// score ranks the documents containing every query term with tf-idf, nil means no text was given
+func (m *memoryIndex) score(text string) map[uuid.UUID]float64 {
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var scores map[uuid.UUID]float64
+	total := float64(len(m.docs))
+	for _, term := range terms {
+		termScores := make(map[uuid.UUID]float64)
+		for _, mt := range expand(term, m.docFreq) {
+			idf := math.Log(1 + total/float64(m.docFreq[mt.term]))
+			for id, tf := range m.postings[mt.term] {
+				// ? satu kata query bisa cocok dengan beberapa kata, ambil yang terbaik
+				termScores[id] = max(termScores[id], mt.weight*tf*idf)
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if s, ok := termScores[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	return scores
+}
+
+func matchesStatus(doc Document, status string) bool {
+	switch status {
+	case "active":
+		return doc.IsActive
+	case "inactive":
+		return !doc.IsActive
+	case "featured":
+		return doc.IsFeatured
+	case "unfeatured":
+		return !doc.IsFeatured
+	}
+	return true
+}
+
+// matchesFilters applies the facet filters of q, except the one of the facet being counted
+func matchesFilters(doc Document, q Query, skip facet) bool {
+	if skip != facetCategory && len(q.Categories) > 0 && !slices.Contains(q.Categories, doc.CategorySlug) {
+		return false
+	}
+	if skip != facetPrice {
+		if q.MinPrice > 0 && doc.Price < q.MinPrice {
+			return false
+		}
+		if q.MaxPrice > 0 && doc.Price > q.MaxPrice {
+			return false
+		}
+	}
+	if skip != facetRating && q.Rating > 0 && doc.Rating < q.Rating {
+		return false
+	}
+	if skip != facetStock && q.InStock != nil && (doc.Stock > 0) != *q.InStock {
+		return false
+	}
+	return true
+}
+
+func memoryFacets(docs []Document, q Query) Facets {
+	facets := Facets{
+		Prices:  emptyPriceFacets(),
+		Ratings: make([]RatingFacet, len(RatingThresholds)),
+	}
+	for i, threshold := range RatingThresholds {
+		facets.Ratings[i].MinRating = threshold
+	}
+
+	categories := make(map[string]*CategoryFacet)
+	for _, doc := range docs {
+		if matchesFilters(doc, q, facetCategory) && doc.CategorySlug != "" {
+			if categories[doc.CategorySlug] == nil {
+				categories[doc.CategorySlug] = &CategoryFacet{Slug: doc.CategorySlug, Name: doc.CategoryName}
+			}
+			categories[doc.CategorySlug].Count++
+		}
+		if matchesFilters(doc, q, facetPrice) {
+			facets.Prices[priceBucket(doc.Price)].Count++
+		}
+		if matchesFilters(doc, q, facetRating) {
+			for i, threshold := range RatingThresholds {
+				if doc.Rating >= float64(threshold) {
+					facets.Ratings[i].Count++
+				}
+			}
+		}
+		if matchesFilters(doc, q, facetStock) {
+			if doc.Stock > 0 {
+				facets.InStock++
+			} else {
+				facets.OutOfStock++
+			}
+		}
+	}
+
+	for _, c := range categories {
+		facets.Categories = append(facets.Categories, *c)
+	}
+	sortCategoryFacets(facets.Categories)
+	return facets
+}
+
+// sortCategoryFacets puts the biggest categories first, same as the MySQL facet query
+func sortCategoryFacets(categories []CategoryFacet) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Count != categories[j].Count {
+			return categories[i].Count > categories[j].Count
+		}
+		return categories[i].Name < categories[j].Name
+	})
+}
+
+func sortDocuments(docs []Document, scores map[uuid.UUID]float64, sortBy string) {
+	if sortBy == "" && scores != nil {
+		sortBy = SortRelevance
+	}
+
+	less := func(a, b Document) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	switch sortBy {
+	case SortRelevance:
+		less = func(a, b Document) bool {
+			if scores[a.ID] != scores[b.ID] {
+				return scores[a.ID] > scores[b.ID]
+			}
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+	case "price_asc":
+		less = func(a, b Document) bool { return a.Price < b.Price }
+	case "price_desc":
+		less = func(a, b Document) bool { return a.Price > b.Price }
+	case "stock_asc":
+		less = func(a, b Document) bool { return a.Stock < b.Stock }
+	case "stock_desc":
+		less = func(a, b Document) bool { return a.Stock > b.Stock }
+	case "created_at_desc":
+		less = func(a, b Document) bool { return a.CreatedAt.After(b.CreatedAt) }
+	case "rating_asc":
+		less = func(a, b Document) bool { return a.Rating < b.Rating }
+	case "rating_desc":
+		less = func(a, b Document) bool { return a.Rating > b.Rating }
+	case "name_desc":
+		less = func(a, b Document) bool { return strings.ToLower(a.Name) > strings.ToLower(b.Name) }
+	case "best_selling":
+		less = func(a, b Document) bool { return a.Sold > b.Sold }
+	case "most_viewed":
+		less = func(a, b Document) bool { return a.Views > b.Views }
+	case "trending":
+		less = func(a, b Document) bool { return a.Trending > b.Trending }
+	}
+
+	// ID sebagai penentu terakhir supaya urutan halaman stabil
+	sort.SliceStable(docs, func(i, j int) bool {
+		if less(docs[i], docs[j]) {
+			return true
+		}
+		if less(docs[j], docs[i]) {
+			return false
+		}
+		return docs[i].ID.String() < docs[j].ID.String()
+	})
+}
@@ -0,0 +1,209 @@
+package search
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testDocuments are two shirts, a shirt on sale, a bag and a hat over three categories
+func testDocuments() []Document {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := func(n byte, name, category string, price, rating float64, stock int) Document {
+		return Document{
+			ID:           uuid.UUID{n},
+			Name:         name,
+			CategorySlug: category,
+			CategoryName: category,
+			Price:        price,
+			Rating:       rating,
+			Stock:        stock,
+			IsActive:     true,
+			CreatedAt:    created.Add(time.Duration(n) * time.Hour),
+		}
+	}
+	return []Document{
+		doc(1, "Kemeja Flanel", "kemeja", 150000, 4.5, 10),
+		doc(2, "Kemeja Batik Tulis", "kemeja", 450000, 3.5, 0),
+		doc(3, "Kemejaku Murah", "promo", 90000, 2, 5),
+		doc(4, "Tas Kulit", "tas", 750000, 4.8, 3),
+		doc(5, "Topi Rajut", "aksesoris", 60000, 0, 0),
+	}
+}
+
+func newTestMemoryIndex(t *testing.T) SearchIndex {
+	t.Helper()
+	idx := NewMemoryIndex()
+	if err := idx.Rebuild(testDocuments()); err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+func TestMemoryIndexSearchText(t *testing.T) {
+	idx := newTestMemoryIndex(t)
+
+	tests := []struct {
+		name string
+		text string
+		want []uuid.UUID
+	}{
+		{"exact term ranks above typo", "kemejaku", []uuid.UUID{{3}, {2}, {1}}},
+		{"prefix", "kem", []uuid.UUID{{3}, {2}, {1}}},
+		{"typo", "kemja flanel", []uuid.UUID{{1}}},
+		{"every term is required", "kemeja kulit", nil},
+		{"short term must match exactly", "taz", nil},
+		{"single characters are ignored", "tas a", []uuid.UUID{{4}}},
+		{"no text lists everything newest first", "", []uuid.UUID{{1}, {2}, {3}, {4}, {5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := idx.Search(Query{Text: tt.text, Page: 1, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.IDs, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.text, result.IDs, tt.want)
+			}
+			if result.Total != int64(len(tt.want)) {
+				t.Errorf("Search(%q) total = %d, want %d", tt.text, result.Total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemoryIndexIndexAndRemove(t *testing.T) {
+	idx := newTestMemoryIndex(t)
+
+	renamed := testDocuments()[3]
+	renamed.Name = "Ransel Kulit"
+	if err := idx.Index(renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Remove(uuid.UUID{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text string
+		want []uuid.UUID
+	}{
+		{"tas", nil},
+		{"ransel", []uuid.UUID{{4}}},
+		{"flanel", nil},
+		{"kemeja", []uuid.UUID{{2}, {3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result, err := idx.Search(Query{Text: tt.text, Page: 1, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.IDs, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.text, result.IDs, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexFacets(t *testing.T) {
+	idx := newTestMemoryIndex(t)
+	inStock := true
+
+	tests := []struct {
+		name       string
+		query      Query
+		total      int64
+		categories []CategoryFacet
+		prices     []int
+		ratings    []int
+		stock      [2]int
+	}{
+		{
+			name:  "no filters",
+			query: Query{},
+			total: 5,
+			categories: []CategoryFacet{
+				{"kemeja", "kemeja", 2}, {"aksesoris", "aksesoris", 1}, {"promo", "promo", 1}, {"tas", "tas", 1},
+			},
+			prices:  []int{2, 1, 1, 1, 0, 0},
+			ratings: []int{2, 3, 4, 4},
+			stock:   [2]int{3, 2},
+		},
+		{
+			// ? filter kategori tidak mengurangi hitungan kategori lain
+			name:  "category filter keeps the other categories",
+			query: Query{Categories: []string{"kemeja"}},
+			total: 2,
+			categories: []CategoryFacet{
+				{"kemeja", "kemeja", 2}, {"aksesoris", "aksesoris", 1}, {"promo", "promo", 1}, {"tas", "tas", 1},
+			},
+			prices:  []int{0, 1, 1, 0, 0, 0},
+			ratings: []int{1, 2, 2, 2},
+			stock:   [2]int{1, 1},
+		},
+		{
+			name:  "stock and price filters",
+			query: Query{InStock: &inStock, MinPrice: 100000},
+			total: 2,
+			categories: []CategoryFacet{
+				{"kemeja", "kemeja", 1}, {"tas", "tas", 1},
+			},
+			prices:  []int{1, 1, 0, 1, 0, 0},
+			ratings: []int{2, 2, 2, 2},
+			stock:   [2]int{2, 1},
+		},
+		{
+			name:  "text and rating filter",
+			query: Query{Text: "kemeja", Rating: 3},
+			total: 2,
+			categories: []CategoryFacet{
+				{"kemeja", "kemeja", 2},
+			},
+			prices:  []int{0, 1, 1, 0, 0, 0},
+			ratings: []int{1, 2, 3, 3},
+			stock:   [2]int{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.query
+			q.Page, q.Limit = 1, 10
+			result, err := idx.Search(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := result.Facets
+
+			if result.Total != tt.total {
+				t.Errorf("total = %d, want %d", result.Total, tt.total)
+			}
+			if !reflect.DeepEqual(f.Categories, tt.categories) {
+				t.Errorf("categories = %v, want %v", f.Categories, tt.categories)
+			}
+			var prices []int
+			for _, p := range f.Prices {
+				prices = append(prices, p.Count)
+			}
+			if !slices.Equal(prices, tt.prices) {
+				t.Errorf("price counts = %v, want %v", prices, tt.prices)
+			}
+			var ratings []int
+			for _, r := range f.Ratings {
+				ratings = append(ratings, r.Count)
+			}
+			if !slices.Equal(ratings, tt.ratings) {
+				t.Errorf("rating counts = %v, want %v", ratings, tt.ratings)
+			}
+			if stock := [2]int{f.InStock, f.OutOfStock}; stock != tt.stock {
+				t.Errorf("in and out of stock = %v, want %v", stock, tt.stock)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package search
+
+import (
+	"server/internal/models"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mysqlMinTokenLen is innodb_ft_min_token_size, shorter terms are not in the FULLTEXT index
+const mysqlMinTokenLen = 3
+
+const matchExpr = "MATCH (products.name, products.description) AGAINST (? IN BOOLEAN MODE)"
+
+type mysqlIndex struct {
+	db *gorm.DB
+
+	// kosakata produk untuk koreksi typo, FULLTEXT MySQL sendiri tidak toleran typo
+	mu         sync.RWMutex
+	vocabulary map[string]int
+}
+
+// NewMySQLIndex searches the products table through its FULLTEXT index, the documents
+// given to Index and Rebuild only feed the vocabulary used for typo correction
+func NewMySQLIndex(db *gorm.DB) SearchIndex {
+	return &mysqlIndex{db: db, vocabulary: make(map[string]int)}
+}
+
+func (m *mysqlIndex) Index(doc Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.addTerms(doc)
+	return nil
+}
+
+// Remove keeps the document terms, a stale term only adds an alternative that matches nothing
+func (m *mysqlIndex) Remove(id uuid.UUID) error {
+	return nil
+}
+
+func (m *mysqlIndex) Rebuild(docs []Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.vocabulary = make(map[string]int)
+	for _, doc := range docs {
+		m.addTerms(doc)
+	}
+	return nil
+}
+
+func (m *mysqlIndex) addTerms(doc Document) {
+	for _, term := range tokenize(doc.Name + " " + doc.Description) {
+		m.vocabulary[term]++
+	}
+}
+
+func (m *mysqlIndex) Search(q Query) (*Result, error) {
+	against, shortTerms := m.booleanQuery(q.Text)
+
+	filtered := func(skip facet) *gorm.DB {
+		db := m.db.Model(&models.Product{})
+		if against != "" {
+			db = db.Where(matchExpr, against)
+		}
+		for _, term := range shortTerms {
+			db = db.Where("products.name LIKE ?", "%"+term+"%")
+		}
+		return mysqlFilters(db, q, skip)
+	}
+
+	result := &Result{}
+	if err := filtered(facetNone).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	db := filtered(facetNone)
+	sortBy := q.Sort
+	if sortBy == "" && against != "" {
+		sortBy = SortRelevance
+	}
+	if sortBy == SortRelevance && against != "" {
+		// ? Order() tidak menerima ekspresi dengan argumen, MATCH ditulis lewat clause
+		db = db.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                matchExpr + " DESC, products.created_at DESC, products.id",
+			Vars:               []interface{}{against},
+			WithoutParentheses: true,
+		}})
+	} else {
+		// ID sebagai penentu terakhir supaya urutan halaman stabil
+		db = db.Order(mysqlSortColumn(sortBy)).Order("products.id")
+	}
+
+	if err := db.
+		Offset((q.Page-1)*q.Limit).Limit(q.Limit).
+		Pluck("products.id", &result.IDs).Error; err != nil {
+		return nil, err
+	}
+
+	facets, err := mysqlFacets(filtered)
+	if err != nil {
+		return nil, err
+	}
+	result.Facets = *facets
+	return result, nil
+}
+
+// booleanQuery turns the text into a boolean mode query where every term is required.
+// Tiap kata dicari sebagai prefix, ditambah kata di kosakata yang hanya beda typo dengan bobot lebih rendah.
+// Terms shorter than the FULLTEXT token size are returned separately for a LIKE match.
+func (m *mysqlIndex) booleanQuery(text string) (string, []string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var parts, shortTerms []string
+	for _, term := range tokenize(text) {
+		if len([]rune(term)) < mysqlMinTokenLen {
+			shortTerms = append(shortTerms, term)
+			continue
+		}
+
+		var typos []string
+		for _, mt := range expand(term, m.vocabulary) {
+			if mt.weight == weightTypo {
+				typos = append(typos, "<"+mt.term)
+			}
+		}
+		if len(typos) == 0 {
+			parts = append(parts, "+"+term+"*")
+			continue
+		}
+		sort.Strings(typos)
+		parts = append(parts, "+(>"+term+"* "+strings.Join(typos, " ")+")")
+	}
+	return strings.Join(parts, " "), shortTerms
+}
+
+func mysqlFilters(db *gorm.DB, q Query, skip facet) *gorm.DB {
+	switch q.Status {
+	case "active":
+		db = db.Where("products.is_active = ?", true)
+	case "inactive":
+		db = db.Where("products.is_active = ?", false)
+	case "featured":
+		db = db.Where("products.is_featured = ?", true)
+	case "unfeatured":
+		db = db.Where("products.is_featured = ?", false)
+	}
+
+	if skip != facetCategory && len(q.Categories) > 0 {
+		db = db.Where("products.category_id IN (SELECT id FROM categories WHERE slug IN ? AND deleted_at IS NULL)", q.Categories)
+	}
+	if skip != facetPrice {
+		if q.MinPrice > 0 {
+			db = db.Where("products.price >= ?", q.MinPrice)
+		}
+		if q.MaxPrice > 0 {
+			db = db.Where("products.price <= ?", q.MaxPrice)
+		}
+	}
+	if skip != facetRating && q.Rating > 0 {
+		db = db.Where("products.average_rating >= ?", q.Rating)
+	}
+	if skip != facetStock && q.InStock != nil {
+		if *q.InStock {
+			db = db.Where("products.stock > 0")
+		} else {
+			db = db.Where("products.stock <= 0")
+		}
+	}
+	return db
+}
+
+func mysqlSortColumn(sortBy string) string {
+	switch sortBy {
+	case "price_asc":
+		return "products.price asc"
+	case "price_desc":
+		return "products.price desc"
+	case "stock_asc":
+		return "products.stock asc"
+	case "stock_desc":
+		return "products.stock desc"
+	case "created_at_desc":
+		return "products.created_at desc"
+	case "rating_asc":
+		return "products.average_rating asc"
+	case "rating_desc":
+		return "products.average_rating desc"
+	case "name_desc":
+		return "products.name desc"
+	case "best_selling":
+		return "products.sold desc"
+	case "most_viewed":
+		return "products.view_count desc"
+	case "trending":
+		return "products.trending_score desc"
+	}
+	return "products.created_at asc"
+}
+
+func mysqlFacets(filtered func(skip facet) *gorm.DB) (*Facets, error) {
+	facets := &Facets{Prices: emptyPriceFacets()}
+
+	if err := filtered(facetCategory).
+		Joins("JOIN categories ON categories.id = products.category_id AND categories.deleted_at IS NULL").
+		Select("categories.slug AS slug, categories.name AS name, COUNT(*) AS count").
+		Group("categories.slug, categories.name").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+	sortCategoryFacets(facets.Categories)
+
+	bucketSQL := "CASE"
+	var bucketArgs []interface{}
+	for i, upper := range PriceBuckets {
+		bucketSQL += " WHEN products.price < ? THEN ?"
+		bucketArgs = append(bucketArgs, upper, i)
+	}
+	bucketSQL += " ELSE ? END AS bucket, COUNT(*) AS count"
+	bucketArgs = append(bucketArgs, len(PriceBuckets))
+
+	var buckets []struct {
+		Bucket int
+		Count  int
+	}
+	if err := filtered(facetPrice).
+		Select(bucketSQL, bucketArgs...).
+		Group("bucket").
+		Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range buckets {
+		if b.Bucket >= 0 && b.Bucket < len(facets.Prices) {
+			facets.Prices[b.Bucket].Count = b.Count
+		}
+	}
+
+	ratingColumns := make([]string, len(RatingThresholds))
+	ratingArgs := make([]interface{}, len(RatingThresholds))
+	ratingCounts := make([]interface{}, len(RatingThresholds))
+	facets.Ratings = make([]RatingFacet, len(RatingThresholds))
+	for i, threshold := range RatingThresholds {
+		ratingColumns[i] = "COALESCE(SUM(products.average_rating >= ?), 0)"
+		ratingArgs[i] = threshold
+		facets.Ratings[i].MinRating = threshold
+		ratingCounts[i] = &facets.Ratings[i].Count
+	}
+	if err := filtered(facetRating).
+		Select(strings.Join(ratingColumns, ", "), ratingArgs...).
+		Row().Scan(ratingCounts...); err != nil {
+		return nil, err
+	}
+
+	if err := filtered(facetStock).
+		Select("COALESCE(SUM(products.stock > 0), 0), COALESCE(SUM(products.stock <= 0), 0)").
+		Row().Scan(&facets.InStock, &facets.OutOfStock); err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
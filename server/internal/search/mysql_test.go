@@ -0,0 +1,82 @@
+package search
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/logger"
+)
+
+func TestMySQLBooleanQuery(t *testing.T) {
+	idx := NewMySQLIndex(nil)
+	if err := idx.Rebuild(testDocuments()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		text       string
+		against    string
+		shortTerms []string
+	}{
+		{"empty", "", "", nil},
+		{"every term is a required prefix", "Kemeja Flanel", "+kemeja* +flanel*", nil},
+		{"typos are lower ranked alternatives", "kemja", "+(>kemja* <kemeja)", nil},
+		{"several typos sorted", "kemejaa", "+(>kemejaa* <kemeja <kemejaku)", nil},
+		{"prefix matches are left to the wildcard", "kem", "+kem*", nil},
+		{"short terms go to LIKE", "tas xl", "+tas*", []string{"xl"}},
+		{"operators in the text are dropped", `+"topi" -rajut*`, "+topi* +rajut*", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			against, shortTerms := idx.(*mysqlIndex).booleanQuery(tt.text)
+			if against != tt.against {
+				t.Errorf("booleanQuery(%q) = %q, want %q", tt.text, against, tt.against)
+			}
+			if !slices.Equal(shortTerms, tt.shortTerms) {
+				t.Errorf("booleanQuery(%q) short terms = %v, want %v", tt.text, shortTerms, tt.shortTerms)
+			}
+		})
+	}
+}
+
+func TestMySQLIgnoresDeletedCategories(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	errStop := errors.New("stop")
+	capture := func(tx *gorm.DB) {
+		callbacks.BuildQuerySQL(tx)
+		queries = append(queries, tx.Statement.SQL.String())
+		tx.AddError(errStop)
+	}
+	db.Callback().Query().Replace("gorm:query", capture)
+	db.Callback().Row().Replace("gorm:row", capture)
+
+	q := Query{Categories: []string{"kemeja"}}
+	if err := mysqlFilters(db.Table("products"), q, facetPrice).Find(&[]map[string]interface{}{}).Error; !errors.Is(err, errStop) {
+		t.Fatal(err)
+	}
+	if _, err := mysqlFacets(func(skip facet) *gorm.DB {
+		return mysqlFilters(db.Table("products"), q, skip)
+	}); !errors.Is(err, errStop) {
+		t.Fatal(err)
+	}
+
+	if len(queries) != 2 {
+		t.Fatalf("captured %d queries, want 2", len(queries))
+	}
+	for i, name := range []string{"category filter", "category facet"} {
+		if !strings.Contains(queries[i], "deleted_at IS NULL") {
+			t.Errorf("%s does not skip deleted categories: %s", name, queries[i])
+		}
+	}
+}
@@ -0,0 +1,252 @@
+// Package search finds catalog products by text and counts the facets of the result.
+//
+// Two SearchIndex implementations exist: the MySQL one ranks with the FULLTEXT index
+// on products and is what the app runs on, the in-memory inverted index keeps every
+// document in the process and is meant for tests and local development.
+//
+// Facets are disjunctive: every facet is counted with all filters applied except its
+// own, so picking a category still shows how many results the other categories have.
+package search
+
+import (
+	"server/internal/models"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BackendMySQL  = "mysql"
+	BackendMemory = "memory"
+)
+
+// SortRelevance is the default order when the query has text
+const SortRelevance = "relevance"
+
+// PriceBuckets are the upper bounds of the price facet ranges, the last range has no upper bound
+var PriceBuckets = []float64{100000, 250000, 500000, 1000000, 5000000}
+
+// RatingThresholds are the "n stars & up" rating facets
+var RatingThresholds = []int{4, 3, 2, 1}
+
+type SearchIndex interface {
+	Search(q Query) (*Result, error)
+	// Index adds the document or replaces the indexed copy of it
+	Index(doc Document) error
+	Remove(id uuid.UUID) error
+	// Rebuild replaces the whole index with docs
+	Rebuild(docs []Document) error
+}
+
+// Document is the searchable copy of a product
+type Document struct {
+	ID           uuid.UUID
+	Name         string
+	Slug         string
+	Description  string
+	CategorySlug string
+	CategoryName string
+	Price        float64
+	Rating       float64
+	Stock        int
+	Sold         int
+	Views        int64
+	Trending     float64
+	IsActive     bool
+	IsFeatured   bool
+	CreatedAt    time.Time
+}
+
+// NewDocument expects the product with its category loaded
+func NewDocument(p *models.Product) Document {
+	return Document{
+		ID:           p.ID,
+		Name:         p.Name,
+		Slug:         p.Slug,
+		Description:  p.Description,
+		CategorySlug: p.Category.Slug,
+		CategoryName: p.Category.Name,
+		Price:        p.Price,
+		Rating:       p.AverageRating,
+		Stock:        p.Stock,
+		Sold:         p.Sold,
+		Views:        p.ViewCount,
+		Trending:     p.TrendingScore,
+		IsActive:     p.IsActive,
+		IsFeatured:   p.IsFeatured,
+		CreatedAt:    p.CreatedAt,
+	}
+}
+
+type Query struct {
+	Text       string
+	Categories []string // category slugs, a product in any of them matches
+	Status     string   // all, active, inactive, featured or unfeatured
+	MinPrice   float64
+	MaxPrice   float64
+	Rating     float64
+	InStock    *bool
+	Sort       string
+	Page       int
+	Limit      int
+}
+
+// Result holds one page of product IDs in result order and the facets of the whole match
+type Result struct {
+	IDs    []uuid.UUID
+	Total  int64
+	Facets Facets
+}
+
+type Facets struct {
+	Categories []CategoryFacet
+	Prices     []PriceFacet
+	Ratings    []RatingFacet
+	InStock    int
+	OutOfStock int
+}
+
+type CategoryFacet struct {
+	Slug  string
+	Name  string
+	Count int
+}
+
+// PriceFacet covers Min <= price < Max, Max is 0 for the open-ended last range
+type PriceFacet struct {
+	Min   float64
+	Max   float64
+	Count int
+}
+
+type RatingFacet struct {
+	MinRating int
+	Count     int
+}
+
+// facet names a filter that is left out when its own facet is counted
+type facet int
+
+const (
+	facetNone facet = iota
+	facetCategory
+	facetPrice
+	facetRating
+	facetStock
+)
+
+// priceBucket returns the index of the PriceBuckets range price falls in
+func priceBucket(price float64) int {
+	for i, upper := range PriceBuckets {
+		if price < upper {
+			return i
+		}
+	}
+	return len(PriceBuckets)
+}
+
+func emptyPriceFacets() []PriceFacet {
+	facets := make([]PriceFacet, len(PriceBuckets)+1)
+	lower := 0.0
+	for i, upper := range PriceBuckets {
+		facets[i] = PriceFacet{Min: lower, Max: upper}
+		lower = upper
+	}
+	facets[len(PriceBuckets)] = PriceFacet{Min: lower}
+	return facets
+}
+
+// Normalize lowercases text and joins its letter and digit runs with single spaces
+func Normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// tokenize lowercases text and splits it into letter and digit runs, single characters are dropped
+func tokenize(text string) []string {
+	fields := strings.Fields(Normalize(text))
+	tokens := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) > 1 {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+// maxTypos is how many edits a query term may be away from an indexed term,
+// short terms must match exactly because one edit already changes their meaning
+func maxTypos(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the Levenshtein distance of a and b, it stops counting past limit
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// match is an indexed term a query term stands for, weighted by how close it is
+type match struct {
+	term   string
+	weight float64
+}
+
+const (
+	weightExact  = 1.0
+	weightPrefix = 0.7
+	weightTypo   = 0.4
+)
+
+// expand finds the indexed terms a query term matches: itself, terms it is a prefix of
+// and terms within maxTypos edits
+func expand(term string, vocabulary map[string]int) []match {
+	var matches []match
+	typos := maxTypos(term)
+	for indexed := range vocabulary {
+		switch {
+		case indexed == term:
+			matches = append(matches, match{indexed, weightExact})
+		case strings.HasPrefix(indexed, term):
+			matches = append(matches, match{indexed, weightPrefix})
+		case typos > 0 && editDistance(term, indexed, typos) <= typos:
+			matches = append(matches, match{indexed, weightTypo})
+		}
+	}
+	return matches
+}
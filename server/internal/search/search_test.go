@@ -0,0 +1,84 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMaxTypos(t *testing.T) {
+	tests := []struct {
+		term string
+		want int
+	}{
+		{"", 0},
+		{"tas", 0},
+		{"topi", 1},
+		{"celana", 1},
+		{"kemejaa", 2},
+		{"sepatulari", 2},
+		{"sépa", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			if got := maxTypos(tt.term); got != tt.want {
+				t.Errorf("maxTypos(%q) = %d, want %d", tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"equal", "kemeja", "kemeja", 2, 0},
+		{"substitution", "kemeja", "kemaja", 2, 1},
+		{"insertion", "kemeja", "kemejaa", 2, 1},
+		{"deletion", "kemeja", "kmeja", 2, 1},
+		{"transposition counts twice", "kemeja", "kmeeja", 2, 2},
+		{"empty", "", "tas", 3, 3},
+		{"runes not bytes", "sépatu", "sepatu", 1, 1},
+		{"length difference past limit", "tas", "tasbelanja", 2, 3},
+		{"stops past limit", "celana", "jaket", 1, 2},
+		{"zero limit", "topi", "tops", 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := editDistance(tt.a, tt.b, tt.limit); got != tt.want {
+				t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	vocabulary := map[string]int{"kemeja": 3, "kemejaku": 1, "kemaja": 1, "tas": 2, "tas2": 1, "topi": 1}
+
+	tests := []struct {
+		term string
+		want []match
+	}{
+		{"kemeja", []match{{"kemaja", weightTypo}, {"kemeja", weightExact}, {"kemejaku", weightPrefix}}},
+		{"kem", []match{{"kemaja", weightPrefix}, {"kemeja", weightPrefix}, {"kemejaku", weightPrefix}}},
+		{"tas", []match{{"tas", weightExact}, {"tas2", weightPrefix}}},
+		{"tos", nil},
+		{"topu", []match{{"topi", weightTypo}}},
+		{"sandal", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			got := expand(tt.term, vocabulary)
+			slices.SortFunc(got, func(a, b match) int { return strings.Compare(a.term, b.term) })
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expand(%q) = %v, want %v", tt.term, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// MaxSuggestions is how many suggestions of each kind a trie node keeps
+const MaxSuggestions = 10
+
+type ProductSuggestion struct {
+	ID   uuid.UUID
+	Name string
+	Slug string
+}
+
+type CategorySuggestion struct {
+	Slug string
+	Name string
+}
+
+type PopularQuery struct {
+	Query string
+	Count int
+}
+
+type Suggestions struct {
+	Products   []ProductSuggestion
+	Categories []CategorySuggestion
+	Queries    []string
+}
+
+// Suggester answers search box autocomplete from in-memory tries. Every trie node keeps its
+// best entries already ranked, so a lookup only walks the characters of the prefix.
+// Trie produk dibangun ulang di luar lock setiap ada perubahan produk lalu ditukar,
+// jadi autocomplete tidak ikut menunggu selama katalog dibangun.
+type Suggester struct {
+	// ? writeMu mengantrekan perubahan produk, mu hanya dipegang sebentar saat menukar trie
+	writeMu  sync.Mutex
+	products map[uuid.UUID]Document
+
+	mu           sync.RWMutex
+	catalog      *productCatalog
+	queryTrie    *trie
+	queryEntries []string
+}
+
+// productCatalog is one build of the product and category tries, it is never changed once built
+type productCatalog struct {
+	productTrie     *trie
+	productEntries  []ProductSuggestion
+	categoryTrie    *trie
+	categoryEntries []CategorySuggestion
+}
+
+func NewSuggester() *Suggester {
+	return &Suggester{
+		products:  make(map[uuid.UUID]Document),
+		catalog:   &productCatalog{productTrie: newTrie(), categoryTrie: newTrie()},
+		queryTrie: newTrie(),
+	}
+}
+
+// SetProducts replaces the suggested products and categories with docs
+func (s *Suggester) SetProducts(docs []Document) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.products = make(map[uuid.UUID]Document, len(docs))
+	for _, doc := range docs {
+		s.products[doc.ID] = doc
+	}
+	s.rebuildProducts()
+}
+
+func (s *Suggester) IndexProduct(doc Document) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.products[doc.ID] = doc
+	s.rebuildProducts()
+}
+
+func (s *Suggester) RemoveProduct(id uuid.UUID) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if _, ok := s.products[id]; !ok {
+		return
+	}
+	delete(s.products, id)
+	s.rebuildProducts()
+}
+
+// SetPopularQueries replaces the suggested past queries, weighted by how often they were searched
+func (s *Suggester) SetPopularQueries(queries []PopularQuery) {
+	t := newTrie()
+	entries := make([]string, 0, len(queries))
+	for _, q := range queries {
+		query := Normalize(q.Query)
+		if query == "" {
+			continue
+		}
+		entries = append(entries, query)
+		t.insert(query, len(entries)-1, float64(q.Count))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queryTrie = t
+	s.queryEntries = entries
+}
+
+// rebuildProducts indexes active products under every word of their name, so
+// "head" finds "Wireless Headphones", and categories under their name. writeMu must be held.
+func (s *Suggester) rebuildProducts() {
+	productTrie, categoryTrie := newTrie(), newTrie()
+	var productEntries []ProductSuggestion
+	var categoryEntries []CategorySuggestion
+
+	// urut nama supaya produk berbobot sama selalu muncul dengan urutan yang sama
+	docs := make([]Document, 0, len(s.products))
+	for _, doc := range s.products {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Name < docs[j].Name })
+
+	categoryIndex := make(map[string]int)
+	categoryWeights := make(map[string]float64)
+	for _, doc := range docs {
+		if !doc.IsActive {
+			continue
+		}
+
+		productEntries = append(productEntries, ProductSuggestion{ID: doc.ID, Name: doc.Name, Slug: doc.Slug})
+		words := strings.Fields(Normalize(doc.Name))
+		for i := range words {
+			productTrie.insert(strings.Join(words[i:], " "), len(productEntries)-1, doc.Rating)
+		}
+
+		if doc.CategorySlug == "" {
+			continue
+		}
+		if _, ok := categoryIndex[doc.CategorySlug]; !ok {
+			categoryEntries = append(categoryEntries, CategorySuggestion{Slug: doc.CategorySlug, Name: doc.CategoryName})
+			categoryIndex[doc.CategorySlug] = len(categoryEntries) - 1
+		}
+		categoryWeights[doc.CategorySlug]++
+	}
+
+	// ? bobot kategori adalah jumlah produk aktifnya, baru diketahui setelah semua produk dihitung
+	for i, category := range categoryEntries {
+		words := strings.Fields(Normalize(category.Name))
+		for j := range words {
+			categoryTrie.insert(strings.Join(words[j:], " "), i, categoryWeights[category.Slug])
+		}
+	}
+
+	catalog := &productCatalog{
+		productTrie:     productTrie,
+		productEntries:  productEntries,
+		categoryTrie:    categoryTrie,
+		categoryEntries: categoryEntries,
+	}
+
+	s.mu.Lock()
+	s.catalog = catalog
+	s.mu.Unlock()
+}
+
+// Suggest returns up to limit products, categories and past queries starting with prefix
+func (s *Suggester) Suggest(prefix string, limit int) Suggestions {
+	prefix = Normalize(prefix)
+	limit = min(limit, MaxSuggestions)
+
+	s.mu.RLock()
+	catalog, queryTrie, queryEntries := s.catalog, s.queryTrie, s.queryEntries
+	s.mu.RUnlock()
+
+	suggestions := Suggestions{
+		Products:   []ProductSuggestion{},
+		Categories: []CategorySuggestion{},
+		Queries:    []string{},
+	}
+	if prefix == "" || limit <= 0 {
+		return suggestions
+	}
+
+	for _, i := range catalog.productTrie.lookup(prefix, limit) {
+		suggestions.Products = append(suggestions.Products, catalog.productEntries[i])
+	}
+	for _, i := range catalog.categoryTrie.lookup(prefix, limit) {
+		suggestions.Categories = append(suggestions.Categories, catalog.categoryEntries[i])
+	}
+	for _, i := range queryTrie.lookup(prefix, limit) {
+		suggestions.Queries = append(suggestions.Queries, queryEntries[i])
+	}
+	return suggestions
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	top      []rankedEntry // entri terbaik di bawah node ini, terurut dari bobot tertinggi
+}
+
+type rankedEntry struct {
+	id     int
+	weight float64
+}
+
+type trie struct {
+	root *trieNode
+}
+
+func newTrie() *trie {
+	return &trie{root: &trieNode{}}
+}
+
+// insert adds entry id under key, every node on the path keeps it if it ranks in its top MaxSuggestions
+func (t *trie) insert(key string, id int, weight float64) {
+	node := t.root
+	for _, r := range key {
+		if node.children == nil {
+			node.children = make(map[rune]*trieNode)
+		}
+		child, ok := node.children[r]
+		if !ok {
+			child = &trieNode{}
+			node.children[r] = child
+		}
+		node = child
+		node.top = rankEntry(node.top, rankedEntry{id, weight})
+	}
+}
+
+func (t *trie) lookup(prefix string, limit int) []int {
+	node := t.root
+	for _, r := range prefix {
+		if node = node.children[r]; node == nil {
+			return nil
+		}
+	}
+
+	ids := make([]int, 0, min(limit, len(node.top)))
+	for _, e := range node.top[:min(limit, len(node.top))] {
+		ids = append(ids, e.id)
+	}
+	return ids
+}
+
+func rankEntry(top []rankedEntry, entry rankedEntry) []rankedEntry {
+	for _, e := range top {
+		// ? entri yang sama bisa lewat node ini dari dua kata berbeda
+		if e.id == entry.id {
+			return top
+		}
+	}
+
+	top = append(top, entry)
+	sort.SliceStable(top, func(i, j int) bool { return top[i].weight > top[j].weight })
+	if len(top) > MaxSuggestions {
+		top = top[:MaxSuggestions]
+	}
+	return top
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func productNames(s Suggestions) []string {
+	names := []string{}
+	for _, p := range s.Products {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestSuggesterSuggest(t *testing.T) {
+	s := NewSuggester()
+	s.SetProducts(testDocuments())
+	s.SetPopularQueries([]PopularQuery{{"kemeja flanel", 12}, {"kemeja batik", 30}, {"  ", 5}})
+
+	tests := []struct {
+		prefix     string
+		limit      int
+		products   []string
+		categories []CategorySuggestion
+		queries    []string
+	}{
+		{"kem", 10, []string{"Kemeja Flanel", "Kemeja Batik Tulis", "Kemejaku Murah"}, []CategorySuggestion{{"kemeja", "kemeja"}}, []string{"kemeja batik", "kemeja flanel"}},
+		{"kem", 1, []string{"Kemeja Flanel"}, []CategorySuggestion{{"kemeja", "kemeja"}}, []string{"kemeja batik"}},
+		{"KULIT", 10, []string{"Tas Kulit"}, []CategorySuggestion{}, []string{}},
+		{"batik tu", 10, []string{"Kemeja Batik Tulis"}, []CategorySuggestion{}, []string{}},
+		{"sepatu", 10, []string{}, []CategorySuggestion{}, []string{}},
+		{"", 10, []string{}, []CategorySuggestion{}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got := s.Suggest(tt.prefix, tt.limit)
+			if names := productNames(got); !reflect.DeepEqual(names, tt.products) {
+				t.Errorf("products = %v, want %v", names, tt.products)
+			}
+			if !reflect.DeepEqual(got.Categories, tt.categories) {
+				t.Errorf("categories = %v, want %v", got.Categories, tt.categories)
+			}
+			if !reflect.DeepEqual(got.Queries, tt.queries) {
+				t.Errorf("queries = %v, want %v", got.Queries, tt.queries)
+			}
+		})
+	}
+}
+
+func TestSuggesterIndexAndRemoveProduct(t *testing.T) {
+	s := NewSuggester()
+	s.SetProducts(testDocuments())
+
+	sandal := Document{ID: uuid.New(), Name: "Sandal Gunung", CategorySlug: "sandal", CategoryName: "Sandal", IsActive: true}
+	s.IndexProduct(sandal)
+	s.RemoveProduct(uuid.UUID{4})
+	s.RemoveProduct(uuid.New())
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"sandal", []string{"Sandal Gunung"}},
+		{"gun", []string{"Sandal Gunung"}},
+		{"tas", []string{}},
+		{"topi", []string{"Topi Rajut"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := productNames(s.Suggest(tt.prefix, 10)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suggest(%q) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+// ? perubahan produk yang bersamaan tidak boleh saling menimpa
+func TestSuggesterConcurrentUpdates(t *testing.T) {
+	s := NewSuggester()
+	s.SetProducts(testDocuments())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.IndexProduct(Document{ID: uuid.New(), Name: "Sandal Gunung", IsActive: true})
+		}()
+		go func() {
+			defer wg.Done()
+			s.Suggest("sandal", MaxSuggestions)
+		}()
+	}
+	wg.Wait()
+
+	if got := len(s.Suggest("sandal", MaxSuggestions).Products); got != MaxSuggestions {
+		t.Errorf("Suggest() returned %d products, want %d", got, MaxSuggestions)
+	}
+	if got := len(s.products); got != len(testDocuments())+20 {
+		t.Errorf("suggester holds %d products, want %d", got, len(testDocuments())+20)
+	}
+}
@@ -20,6 +20,11 @@ func ResetDatabase(db *gorm.DB) {
 		&models.Banner{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderStatusHistory{},
+		&models.Reconciliation{},
+		&models.ReturnRequest{},
+		&models.ReturnItem{},
+		&models.ReturnImage{},
 		&models.Shipment{},
 		&models.Address{},
 		&models.Province{},
@@ -28,6 +33,17 @@ func ResetDatabase(db *gorm.DB) {
 		&models.Subdistrict{},
 		&models.PostalCode{},
 		&models.ProductGallery{},
+		&models.ProductOption{},
+		&models.ProductOptionValue{},
+		&models.ProductVariant{},
+		&models.ProductVariantValue{},
+		&models.ProductVariantImage{},
+		&models.StockMovement{},
+		&models.RestockSubscription{},
+		&models.StockReservation{},
+		&models.WishlistItem{},
+		&models.SearchQuery{},
+		&models.ProductAffinity{},
 		&models.Payment{},
 		&models.Notification{},
 		&models.NotificationType{},
@@ -35,6 +51,9 @@ func ResetDatabase(db *gorm.DB) {
 		&models.Voucher{},
 		&models.UsedVoucher{},
 		&models.Review{},
+		&models.ReviewImage{},
+		&models.ReviewVote{},
+		&models.ReviewReply{},
 	)
 	if err != nil {
 		log.Fatalf("❌ Failed to drop tables: %v", err)
@@ -54,6 +73,11 @@ func ResetDatabase(db *gorm.DB) {
 		&models.Cart{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderStatusHistory{},
+		&models.Reconciliation{},
+		&models.ReturnRequest{},
+		&models.ReturnItem{},
+		&models.ReturnImage{},
 		&models.Shipment{},
 		&models.Address{},
 		&models.Province{},
@@ -62,6 +86,17 @@ func ResetDatabase(db *gorm.DB) {
 		&models.Subdistrict{},
 		&models.PostalCode{},
 		&models.ProductGallery{},
+		&models.ProductOption{},
+		&models.ProductOptionValue{},
+		&models.ProductVariant{},
+		&models.ProductVariantValue{},
+		&models.ProductVariantImage{},
+		&models.StockMovement{},
+		&models.RestockSubscription{},
+		&models.StockReservation{},
+		&models.WishlistItem{},
+		&models.SearchQuery{},
+		&models.ProductAffinity{},
 		&models.Payment{},
 		&models.Notification{},
 		&models.NotificationType{},
@@ -69,6 +104,9 @@ func ResetDatabase(db *gorm.DB) {
 		&models.Voucher{},
 		&models.UsedVoucher{},
 		&models.Review{},
+		&models.ReviewImage{},
+		&models.ReviewVote{},
+		&models.ReviewReply{},
 	)
 	if err != nil {
 		log.Fatalf("❌ Failed to migrate tables: %v", err)
@@ -92,8 +130,10 @@ func ResetDatabase(db *gorm.DB) {
 	SeedFoodSecond(db)
 	SeedWatchesFirst(db)
 	SeedGadgetElectronic(db)
+	SeedOpeningStock(db)
 	SeedVouchers(db)
 	SeedReviews(db)
+	SeedProductRatings(db)
 	SeedCustomerTransactions(db)
 	SeedCustomerNotifications(db)
 	log.Println("✅ Seeding completed successfully.")
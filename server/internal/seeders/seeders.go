@@ -15,6 +15,7 @@ import (
 	"gorm.io/gorm"
 
 	"server/internal/models"
+	"server/internal/repositories"
 	"server/internal/utils"
 )
 
@@ -374,6 +375,8 @@ func SeedFashionFirst(db *gorm.DB) {
 		IsFeatured    bool
 		Discount      float64
 		Images        []string
+		Sizes         []string
+		Colors        []string
 	}{
 		{
 			Category:      "Fashion and Apparel",
@@ -390,6 +393,7 @@ func SeedFashionFirst(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745429275/erem_shirt_02_dusksh.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745429265/erem_shirt_03_ykizqa.webp",
 			},
+			Sizes: []string{"S", "M", "L", "XL"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -407,6 +411,8 @@ func SeedFashionFirst(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745509053/cloth_mens_03_nwcb4c.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745509050/cloth_mens_04_xttcat.webp",
 			},
+			Sizes:  []string{"S", "M", "L", "XL"},
+			Colors: []string{"White", "Black", "Navy"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -423,6 +429,7 @@ func SeedFashionFirst(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745509457/jaket02_ru71to.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745509458/jaket03_ygtnw2.webp",
 			},
+			Sizes: []string{"M", "L", "XL"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -438,6 +445,7 @@ func SeedFashionFirst(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745509054/jaket_mens_02_tyjlul.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745509053/jaket_mens_01_a21ye5.webp",
 			},
+			Sizes: []string{"M", "L", "XL"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -454,6 +462,7 @@ func SeedFashionFirst(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745510301/dress02_xnlphu.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745510304/dress03_d3y08s.webp",
 			},
+			Sizes: []string{"S", "M", "L"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -470,6 +479,8 @@ func SeedFashionFirst(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745510299/wom_dress02_susije.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745510299/wom_dress01_zgzscq.webp",
 			},
+			Sizes:  []string{"S", "M", "L"},
+			Colors: []string{"Black", "Maroon"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -486,6 +497,7 @@ func SeedFashionFirst(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745510925/pants02_cloota.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745510925/pants03_rx1ixk.webp",
 			},
+			Sizes: []string{"28", "30", "32", "34"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -501,6 +513,8 @@ func SeedFashionFirst(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745510916/men_pants02_yjdzug.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745510904/men_pants01_tgqmbn.webp",
 			},
+			Sizes:  []string{"28", "30", "32", "34"},
+			Colors: []string{"Khaki", "Black", "Olive"},
 		},
 	}
 
@@ -536,6 +550,97 @@ func SeedFashionFirst(db *gorm.DB) {
 			})
 		}
 
+		seedVariants(db, product, p.Sizes, p.Colors)
+
+	}
+}
+
+// seedVariants creates Size and Color options with one variant per combination,
+// the product stock is spread over the variants.
+func seedVariants(db *gorm.DB, product models.Product, sizes, colors []string) {
+	var options []models.ProductOption
+	for i, opt := range []struct {
+		Name   string
+		Values []string
+	}{{"Size", sizes}, {"Color", colors}} {
+		if len(opt.Values) == 0 {
+			continue
+		}
+		option := models.ProductOption{ID: uuid.New(), ProductID: product.ID, Name: opt.Name, Position: i}
+		for j, v := range opt.Values {
+			option.Values = append(option.Values, models.ProductOptionValue{ID: uuid.New(), OptionID: option.ID, Value: v, Position: j})
+		}
+		db.Create(&option)
+		options = append(options, option)
+	}
+	if len(options) == 0 {
+		return
+	}
+
+	combinations := [][]models.ProductOptionValue{{}}
+	for _, option := range options {
+		var next [][]models.ProductOptionValue
+		for _, combo := range combinations {
+			for _, v := range option.Values {
+				next = append(next, append(append([]models.ProductOptionValue{}, combo...), v))
+			}
+		}
+		combinations = next
+	}
+
+	for i, combo := range combinations {
+		stock := product.Stock / len(combinations)
+		if i < product.Stock%len(combinations) {
+			stock++
+		}
+
+		sku := product.Slug
+		variant := models.ProductVariant{ID: uuid.New(), ProductID: product.ID, Stock: stock, IsActive: true}
+		for _, v := range combo {
+			sku += "-" + strings.ToLower(v.Value)
+			variant.Values = append(variant.Values, models.ProductVariantValue{ID: uuid.New(), OptionValueID: v.ID})
+		}
+		variant.SKU = sku
+		db.Create(&variant)
+	}
+}
+
+// SeedOpeningStock writes the opening ledger entry for every seeded product or variant
+func SeedOpeningStock(db *gorm.DB) {
+	var products []models.Product
+	if err := db.Preload("Variants").Find(&products).Error; err != nil {
+		log.Printf("❌ Failed to load products for opening stock: %v", err)
+		return
+	}
+
+	var movements []models.StockMovement
+	for _, p := range products {
+		opening := models.StockMovement{
+			ProductID: p.ID,
+			Type:      "initial",
+			Quantity:  p.Stock,
+			ActorType: "system",
+			Reason:    "opening stock",
+		}
+		if len(p.Variants) == 0 {
+			opening.StockAfter = p.Stock
+			movements = append(movements, opening)
+			continue
+		}
+		for _, v := range p.Variants {
+			movement := opening
+			movement.VariantID = &v.ID
+			movement.Quantity = v.Stock
+			movement.StockAfter = v.Stock
+			movements = append(movements, movement)
+		}
+	}
+
+	if len(movements) == 0 {
+		return
+	}
+	if err := db.Create(&movements).Error; err != nil {
+		log.Printf("❌ Failed to seed opening stock: %v", err)
 	}
 }
 
@@ -851,6 +956,8 @@ func SeedFashionSecond(db *gorm.DB) {
 		IsFeatured    bool
 		Discount      float64
 		Images        []string
+		Sizes         []string
+		Colors        []string
 	}{
 		{
 			Category:      "Fashion and Apparel",
@@ -866,6 +973,7 @@ func SeedFashionSecond(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536263/3sneaker_shoes_01_t4lbd5.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536264/3sneaker_shoes_02_atfnsn.webp",
 			},
+			Sizes: []string{"39", "40", "41", "42", "43"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -882,6 +990,8 @@ func SeedFashionSecond(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536262/sneaker_shoes_02_mctuky.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536262/sneaker_shoes_03_aiuieg.webp",
 			},
+			Sizes:  []string{"39", "40", "41", "42", "43"},
+			Colors: []string{"Black", "Checkerboard"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -898,6 +1008,8 @@ func SeedFashionSecond(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536263/sneaker2_shoes_02_iluvmx.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536263/sneaker3_shoes_02_viyrm9.webp",
 			},
+			Sizes:  []string{"39", "40", "41", "42", "43"},
+			Colors: []string{"Black", "White"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -913,6 +1025,7 @@ func SeedFashionSecond(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536494/03sandals01_ogodhf.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536496/03sandals02_uqknkc.webp",
 			},
+			Sizes: []string{"39", "40", "41", "42", "43"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -929,6 +1042,7 @@ func SeedFashionSecond(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536494/02sandals02_xuz1zl.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536498/02sandals03_f4bphf.webp",
 			},
+			Sizes: []string{"39", "40", "41", "42", "43"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -945,6 +1059,7 @@ func SeedFashionSecond(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536494/01sandals02_euuo47.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536495/01sandals03_dxzjww.webp",
 			},
+			Sizes: []string{"39", "40", "41", "42", "43"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -960,6 +1075,7 @@ func SeedFashionSecond(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536998/02formal01_nojgda.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536998/02formal02_ihkwdw.webp",
 			},
+			Sizes: []string{"39", "40", "41", "42", "43"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -976,6 +1092,7 @@ func SeedFashionSecond(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536998/01formal02_wqdqvd.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536998/01formal01_sxsc4y.webp",
 			},
+			Sizes: []string{"39", "40", "41", "42", "43"},
 		},
 		{
 			Category:      "Fashion and Apparel",
@@ -991,6 +1108,7 @@ func SeedFashionSecond(db *gorm.DB) {
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536999/03formal02_ysq0pe.webp",
 				"https://res.cloudinary.com/dp1xbgxdn/image/upload/v1745536999/03formal03_kgeocu.webp",
 			},
+			Sizes: []string{"39", "40", "41", "42", "43"},
 		},
 	}
 
@@ -1025,6 +1143,8 @@ func SeedFashionSecond(db *gorm.DB) {
 				Image:     img,
 			})
 		}
+
+		seedVariants(db, product, p.Sizes, p.Colors)
 	}
 }
 
@@ -1428,6 +1548,24 @@ func SeedReviews(db *gorm.DB) {
 	log.Println("✅ Review seeding from 5 customers completed")
 }
 
+// SeedProductRatings replaces the hardcoded ratings with the summary of the seeded reviews
+func SeedProductRatings(db *gorm.DB) {
+	var productIDs []uuid.UUID
+	if err := db.Model(&models.Product{}).Pluck("id", &productIDs).Error; err != nil {
+		log.Println("❌ Failed to fetch products for ratings:", err)
+		return
+	}
+
+	reviewRepo := repositories.NewReviewRepository(db)
+	for _, id := range productIDs {
+		if err := reviewRepo.RecalculateProductRating(id); err != nil {
+			log.Printf("❌ Failed to recalculate rating for product %s: %v", id, err)
+		}
+	}
+
+	log.Println("✅ Product ratings recalculated")
+}
+
 func SeedNotificationTypes(db *gorm.DB) {
 	types := []models.NotificationType{
 		// Transaksi Pembelian
@@ -1435,7 +1573,13 @@ func SeedNotificationTypes(db *gorm.DB) {
 		{ID: uuid.New(), Code: "order_processed", Title: "Order is Being Processed", Category: "transaction", DefaultEnabled: true},
 		{ID: uuid.New(), Code: "order_shipped", Title: "Order Shipped", Category: "transaction", DefaultEnabled: true},
 		{ID: uuid.New(), Code: "order_completed", Title: "Order Completed", Category: "transaction", DefaultEnabled: true},
+		{ID: uuid.New(), Code: "order_canceled", Title: "Order Canceled", Category: "transaction", DefaultEnabled: true},
+		{ID: uuid.New(), Code: "return_update", Title: "Return & Refund Update", Category: "transaction", DefaultEnabled: true},
 		{ID: uuid.New(), Code: "promo_offer", Title: "Promo & Discount", Category: "promotion", DefaultEnabled: true},
+		{ID: uuid.New(), Code: "restock_alert", Title: "Back in Stock", Category: "product", DefaultEnabled: true},
+		{ID: uuid.New(), Code: "payment_alert", Title: "Payment Needs Attention", Category: "transaction", DefaultEnabled: true},
+		{ID: uuid.New(), Code: "low_stock", Title: "Low Stock Alert", Category: "inventory", DefaultEnabled: true},
+		{ID: uuid.New(), Code: "price_drop", Title: "Wishlist Price Drop", Category: "product", DefaultEnabled: true},
 		{ID: uuid.New(), Code: "system_message", Title: "System Announcement", Category: "announcement", DefaultEnabled: false},
 	}
 
@@ -1560,10 +1704,10 @@ func SeedCustomerTransactions(db *gorm.DB) {
 			voucher := 10000.0
 			amount := total + shipping + tax - voucher
 
-			statuses := []string{"waiting_payment", "pending", "success", "process"}
+			statuses := []string{"waiting_payment", "pending", "process", "shipped", "delivered"}
 			status := statuses[rand.Intn(len(statuses))]
 			paymentStatus := "pending"
-			if status == "success" || status == "process" {
+			if status != "waiting_payment" {
 				paymentStatus = "success"
 			}
 
@@ -1599,6 +1743,14 @@ func SeedCustomerTransactions(db *gorm.DB) {
 				},
 			}
 			db.Create(&order)
+			db.Create(&models.OrderStatusHistory{
+				ID:        uuid.New(),
+				OrderID:   orderID,
+				ToStatus:  status,
+				ActorType: "system",
+				Reason:    "seeded order",
+				CreatedAt: order.CreatedAt,
+			})
 
 			payment := models.Payment{
 				ID:       uuid.New(),
@@ -1613,9 +1765,9 @@ func SeedCustomerTransactions(db *gorm.DB) {
 			}
 			db.Create(&payment)
 
-			if status == "process" || status == "success" {
+			if status == "shipped" || status == "delivered" {
 				shipmentStatus := "shipped"
-				if status == "success" {
+				if status == "delivered" {
 					shipmentStatus = "delivered"
 				}
 				shipment := models.Shipment{
@@ -97,7 +97,7 @@ func (s *adminService) GetDashboardStats(gender string) (*dto.DashboardStatsResp
 		TotalCustomers: totalCustomer,
 		TotalProducts:  totalProduct,
 		TotalOrders:    totalOrder,
-		TotalRevenue:   totalRevenue,
+		TotalRevenue:   totalRevenue.Float(),
 	}, nil
 }
 
@@ -109,7 +109,7 @@ func (s *adminService) GetRevenueStats(rangeType string) (*dto.RevenueStatsRespo
 
 	return &dto.RevenueStatsResponse{
 		Range:         rangeType,
-		TotalRevenue:  total,
+		TotalRevenue:  total.Float(),
 		RevenueSeries: stats,
 	}, nil
 }
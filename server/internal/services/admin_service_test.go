@@ -0,0 +1,66 @@
+package services
+
+import (
+	"server/internal/repositories"
+	"testing"
+	"time"
+)
+
+func TestRevenueRoundsEachPaymentLikeTheCharge(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("QUOTE_SECRET", "admin-test-secret")
+
+	svc := newCheckoutServices(db, NewFakeGateway("http://localhost:5002", "fake-secret"))
+	admin := NewAdminService(repositories.NewAdminRepository(db))
+	paidAt := time.Date(2001, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	revenue := func(t *testing.T) (float64, float64) {
+		t.Helper()
+		dashboard, err := admin.GetDashboardStats("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats, err := admin.GetRevenueStats("yearly")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var year float64
+		for _, stat := range stats.RevenueSeries {
+			if stat.Date == "2001" {
+				year = stat.Total
+			}
+		}
+		return dashboard.TotalRevenue, year
+	}
+	beforeTotal, beforeYear := revenue(t)
+
+	// ? 100.50 + 200.50 - 0.50 = 300.50 kalau kolomnya langsung dijumlahkan,
+	// ? gateway menagih 101 + 201 dan mengembalikan 1
+	payments := []struct {
+		total, refunded float64
+		status          string
+	}{
+		{100.50, 0, "success"},
+		{200.50, 0.50, "refunded"},
+	}
+	for _, p := range payments {
+		user, _ := seedCustomer(t, db, 5, 1)
+		_, payment := svc.checkout(t, db, user.ID.String())
+		if err := db.Model(payment).UpdateColumns(map[string]interface{}{
+			"total":           p.total,
+			"refunded_amount": p.refunded,
+			"status":          p.status,
+			"paid_at":         paidAt,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	afterTotal, afterYear := revenue(t)
+	if got := afterTotal - beforeTotal; got != 301 {
+		t.Errorf("dashboard revenue grew by %v, want 301", got)
+	}
+	if got := afterYear - beforeYear; got != 301 {
+		t.Errorf("2001 revenue grew by %v, want 301", got)
+	}
+}
@@ -118,6 +118,7 @@ func (s *authService) Login(req *dto.LoginRequest) (*dto.AuthResponse, error) {
 	}
 
 	return &dto.AuthResponse{
+		UserID:       user.ID.String(),
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 	}, nil
@@ -169,6 +170,7 @@ func (s *authService) Register(req *dto.RegisterRequest) (*dto.AuthResponse, err
 	s.generateDefaultSettingsForUser(user.ID)
 
 	return &dto.AuthResponse{
+		UserID:       userID,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 	}, nil
@@ -304,6 +306,7 @@ func (s *authService) GoogleSignIn(idToken string) (*dto.AuthResponse, error) {
 	}
 
 	return &dto.AuthResponse{
+		UserID:       user.ID.String(),
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 	}, nil
@@ -2,31 +2,61 @@ package services
 
 import (
 	"errors"
+	"server/internal/config"
 	"server/internal/dto"
 	"server/internal/models"
+	"server/internal/pricing"
 	"server/internal/repositories"
+	"server/internal/utils"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type CartService interface {
 	ClearCart(userID string) error
-	RemoveItem(userID, productID string) error
+	RemoveItem(userID, productID, variantID string) error
 	AddToCart(userID string, req dto.CartItemRequest) error
-	UpdateQuantity(userID, productID string, quantity int) error
+	UpdateQuantity(userID, productID, variantID string, quantity int) error
 	GetCart(userID string) ([]dto.CartItemResponse, float64, error)
-	ToggleItemChecked(userID, productID string) error
+	GetSavedItems(userID string) ([]dto.CartItemResponse, error)
+	SaveForLater(userID, productID, variantID string) error
+	MoveToCart(userID, productID, variantID string) error
+	ToggleItemChecked(userID, productID, variantID string) error
+	ReleaseExpiredReservations() (int64, error)
+	AcknowledgePriceChanges(userID string) error
+	MergeGuestCart(guestID, userID string) error
+	PurgeGuestCarts() (int64, error)
 }
 
 type cartService struct {
-	cartRepo    repositories.CartRepository
-	productRepo repositories.ProductRepository
+	cartRepo        repositories.CartRepository
+	guestCartRepo   repositories.CartRepository
+	productRepo     repositories.ProductRepository
+	reservationRepo repositories.ReservationRepository
 }
 
-func NewCartService(cartRepo repositories.CartRepository, productRepo repositories.ProductRepository) CartService {
-	return &cartService{cartRepo, productRepo}
+func NewCartService(cartRepo, guestCartRepo repositories.CartRepository, productRepo repositories.ProductRepository, reservationRepo repositories.ReservationRepository) CartService {
+	return &cartService{cartRepo, guestCartRepo, productRepo, reservationRepo}
 }
 
+// NewGuestCartService serves the same cart operations to visitors, userID arguments are guest IDs
+func NewGuestCartService(guestCartRepo repositories.CartRepository, productRepo repositories.ProductRepository, reservationRepo repositories.ReservationRepository) CartService {
+	return &cartService{guestCartRepo, guestCartRepo, productRepo, reservationRepo}
+}
+
+// ** status tiap baris keranjang yang perlu diperhatikan customer
+const (
+	CartWarningPriceChanged    = "price_changed"
+	CartWarningOutOfStock      = "out_of_stock"
+	CartWarningQuantityClamped = "quantity_clamped"
+	CartWarningUnavailable     = "unavailable"
+)
+
+// GetCart revalidates every line against the current catalog without changing the cart. Lines above
+// the stock left are shown and counted clamped, only lines that can still be bought count towards
+// the total. A price change keeps being reported until the customer acknowledges it.
 func (s *cartService) GetCart(userID string) ([]dto.CartItemResponse, float64, error) {
 	uid, _ := uuid.Parse(userID)
 	carts, err := s.cartRepo.GetByUserID(uid)
@@ -34,93 +64,449 @@ func (s *cartService) GetCart(userID string) ([]dto.CartItemResponse, float64, e
 		return nil, 0, err
 	}
 
-	var items []dto.CartItemResponse
-	var total float64
+	holds := make(map[string]time.Time)
+	if config.CartReservationEnabled {
+		reservations, err := s.reservationRepo.GetUserReservations(uid)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, r := range reservations {
+			holds[cartLineKey(r.ProductID, r.VariantID)] = r.ExpiresAt
+		}
+	}
 
-	for _, c := range carts {
-		if len(c.Product.ProductGallery) == 0 {
-			continue
+	items := make([]dto.CartItemResponse, 0, len(carts))
+	var total pricing.Money
+
+	for i := range carts {
+		c := &carts[i]
+		warnings := []string{}
+		purchasable := false
+
+		if !cartLineAvailable(c) {
+			warnings = append(warnings, CartWarningUnavailable)
+		} else if stock := variantStock(&c.Product, c.Variant); stock == 0 {
+			warnings = append(warnings, CartWarningOutOfStock)
+		} else {
+			purchasable = true
+			// ? jumlah baru disimpan saat checkout, membaca keranjang tidak mengubah apa pun
+			if c.Quantity > stock {
+				c.Quantity = stock
+				warnings = append(warnings, CartWarningQuantityClamped)
+			}
 		}
 
-		price := c.Product.Price
-		discount := 0.0
-		if c.Product.Discount != nil {
-			discount = *c.Product.Discount
+		item, subtotal := toCartItemResponse(c)
+		if c.PriceAtAdd > 0 && pricing.FromFloat(c.PriceAtAdd) != pricing.FromFloat(item.DiscountedPrice) {
+			warnings = append(warnings, CartWarningPriceChanged)
+		}
+		item.Warnings = warnings
+		if until, ok := holds[cartLineKey(c.ProductID, c.VariantID)]; ok {
+			item.ReservedUntil = &until
 		}
 
-		discountedPrice := price * (1 - discount/100)
-		originalSubtotal := price * float64(c.Quantity)
-		discountedSubtotal := discountedPrice * float64(c.Quantity)
+		if purchasable {
+			total += subtotal
+		}
+		items = append(items, item)
+	}
 
-		total += discountedSubtotal
+	return items, total.Float(), nil
+}
 
-		items = append(items, dto.CartItemResponse{
-			ProductID:        c.ProductID.String(),
-			Name:             c.Product.Name,
-			Price:            price,
-			Discount:         discount,
-			DiscountedPrice:  discountedPrice,
-			Image:            c.Product.ProductGallery[0].Image,
-			IsChecked:        c.IsChecked,
-			Weight:           c.Product.Weight,
-			Quantity:         c.Quantity,
-			OriginalSubtotal: originalSubtotal,
-			Subtotal:         discountedSubtotal,
-		})
+func (s *cartService) GetSavedItems(userID string) ([]dto.CartItemResponse, error) {
+	uid, _ := uuid.Parse(userID)
+	saved, err := s.cartRepo.GetSavedByUserID(uid)
+	if err != nil {
+		return nil, err
 	}
 
-	return items, total, nil
+	items := make([]dto.CartItemResponse, 0, len(saved))
+	for i := range saved {
+		item, _ := toCartItemResponse(&saved[i])
+		if !cartLineAvailable(&saved[i]) {
+			item.Warnings = []string{CartWarningUnavailable}
+		} else if variantStock(&saved[i].Product, saved[i].Variant) == 0 {
+			item.Warnings = []string{CartWarningOutOfStock}
+		}
+		items = append(items, item)
+	}
+	return items, nil
 }
 
 func (s *cartService) AddToCart(userID string, req dto.CartItemRequest) error {
 	uid, _ := uuid.Parse(userID)
 	pid, _ := uuid.Parse(req.ProductID)
 
+	var variantID *uuid.UUID
+	if req.VariantID != nil {
+		id, err := parseVariantID(*req.VariantID)
+		if err != nil {
+			return err
+		}
+		variantID = id
+	}
+
 	product, err := s.productRepo.GetProductByID(pid)
+	if err != nil || !product.IsActive {
+		return ErrProductUnavailable
+	}
+	variant, err := findVariant(product, variantID)
 	if err != nil {
 		return err
 	}
-	if req.Quantity > product.Stock {
+
+	// ** jumlah yang dicek termasuk yang sudah ada di keranjang
+	quantity := req.Quantity
+	if existing, err := s.cartRepo.GetItem(uid, pid, variantID); err == nil {
+		quantity += existing.Quantity
+	}
+	if stock := variantStock(product, variant); quantity > stock {
+		// ** frontend menawarkan langganan notifikasi restock
+		if stock == 0 {
+			return ErrOutOfStock
+		}
 		return errors.New("stock not available")
 	}
 
 	cart := &models.Cart{
-		UserID:    uid,
-		ProductID: pid,
-		Quantity:  req.Quantity,
+		ProductID:  pid,
+		VariantID:  variantID,
+		Quantity:   req.Quantity,
+		PriceAtAdd: pricing.PriceUnit(variantPrice(product, variant), product.Discount).Net.Float(),
 	}
 
-	return s.cartRepo.AddOrUpdate(cart)
+	if !config.CartReservationEnabled {
+		return s.cartRepo.AddOrUpdate(uid, cart)
+	}
+
+	// ** reservasi mencakup jumlah yang sudah ada di keranjang
+	return s.productRepo.WithTx(func(tx *gorm.DB) error {
+		if err := reserveStock(repositories.NewProductRepository(tx), repositories.NewReservationRepository(tx), uid, product, variant, quantity); err != nil {
+			return err
+		}
+		return s.txCartRepo(tx).AddOrUpdate(uid, cart)
+	})
 }
 
-func (s *cartService) UpdateQuantity(userID, productID string, quantity int) error {
+func (s *cartService) UpdateQuantity(userID, productID, variantID string, quantity int) error {
 	uid, _ := uuid.Parse(userID)
 	pid, _ := uuid.Parse(productID)
+	vid, err := parseVariantID(variantID)
+	if err != nil {
+		return err
+	}
 
 	product, err := s.productRepo.GetProductByID(pid)
 	if err != nil {
 		return err
 	}
-	if quantity > product.Stock {
+	variant, err := findVariant(product, vid)
+	if err != nil {
+		return err
+	}
+	if quantity > variantStock(product, variant) {
 		return errors.New("stock not available")
 	}
 
-	return s.cartRepo.UpdateQuantity(uid, pid, quantity)
+	// ** customer mengubah baris ini dengan harga yang sedang tampil, jadi harganya ikut diperbarui
+	price := pricing.PriceUnit(variantPrice(product, variant), product.Discount).Net.Float()
+	return s.productRepo.WithTx(func(tx *gorm.DB) error {
+		if config.CartReservationEnabled {
+			if err := reserveStock(repositories.NewProductRepository(tx), repositories.NewReservationRepository(tx), uid, product, variant, quantity); err != nil {
+				return err
+			}
+		}
+		cartRepo := s.txCartRepo(tx)
+		if err := cartRepo.UpdateQuantity(uid, pid, vid, quantity); err != nil {
+			return err
+		}
+		return cartRepo.UpdatePriceAtAdd(uid, pid, vid, price)
+	})
 }
 
-func (s *cartService) RemoveItem(userID, productID string) error {
+func (s *cartService) RemoveItem(userID, productID, variantID string) error {
 	uid, _ := uuid.Parse(userID)
 	pid, _ := uuid.Parse(productID)
-	return s.cartRepo.RemoveItem(uid, pid)
+	vid, err := parseVariantID(variantID)
+	if err != nil {
+		return err
+	}
+	if err := s.cartRepo.RemoveItem(uid, pid, vid); err != nil {
+		return err
+	}
+	return s.reservationRepo.Release(uid, pid, vid)
 }
 
 func (s *cartService) ClearCart(userID string) error {
 	uid, _ := uuid.Parse(userID)
-	return s.cartRepo.Clear(uid)
+	if err := s.cartRepo.Clear(uid); err != nil {
+		return err
+	}
+	return s.reservationRepo.ReleaseAll(uid)
+}
+
+func (s *cartService) ToggleItemChecked(userID, productID, variantID string) error {
+	uid, _ := uuid.Parse(userID)
+	pid, _ := uuid.Parse(productID)
+	vid, err := parseVariantID(variantID)
+	if err != nil {
+		return err
+	}
+	return s.cartRepo.ToggleIsChecked(uid, pid, vid)
+}
+
+// SaveForLater parks the line outside the cart, it no longer counts for checkout or holds stock
+func (s *cartService) SaveForLater(userID, productID, variantID string) error {
+	uid, _ := uuid.Parse(userID)
+	pid, _ := uuid.Parse(productID)
+	vid, err := parseVariantID(variantID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.cartRepo.GetItem(uid, pid, vid); err != nil {
+		return errors.New("cart item not found")
+	}
+
+	if err := s.cartRepo.SetSavedForLater(uid, pid, vid, true); err != nil {
+		return err
+	}
+	return s.reservationRepo.Release(uid, pid, vid)
 }
 
-func (s *cartService) ToggleItemChecked(userID, productID string) error {
+func (s *cartService) MoveToCart(userID, productID, variantID string) error {
 	uid, _ := uuid.Parse(userID)
 	pid, _ := uuid.Parse(productID)
-	return s.cartRepo.ToggleIsChecked(uid, pid)
+	vid, err := parseVariantID(variantID)
+	if err != nil {
+		return err
+	}
+	saved, err := s.cartRepo.GetItem(uid, pid, vid)
+	if err != nil || !saved.SavedForLater {
+		return errors.New("saved item not found")
+	}
+
+	product, err := s.productRepo.GetProductByID(pid)
+	if err != nil || !product.IsActive {
+		return ErrProductUnavailable
+	}
+	variant, err := findVariant(product, vid)
+	if err != nil {
+		return err
+	}
+	stock := variantStock(product, variant)
+	if stock == 0 {
+		return ErrOutOfStock
+	}
+	// ? jumlah disesuaikan dengan stok yang tersisa saat dipindahkan
+	quantity := min(saved.Quantity, stock)
+
+	return s.productRepo.WithTx(func(tx *gorm.DB) error {
+		cartRepo := s.txCartRepo(tx)
+		if config.CartReservationEnabled {
+			if err := reserveStock(repositories.NewProductRepository(tx), repositories.NewReservationRepository(tx), uid, product, variant, quantity); err != nil {
+				return err
+			}
+		}
+		if err := cartRepo.UpdateQuantity(uid, pid, vid, quantity); err != nil {
+			return err
+		}
+		return cartRepo.SetSavedForLater(uid, pid, vid, false)
+	})
+}
+
+// AcknowledgePriceChanges is the customer confirming the current prices, the price_changed
+// warnings disappear because every line remembers the price it now shows
+func (s *cartService) AcknowledgePriceChanges(userID string) error {
+	uid, _ := uuid.Parse(userID)
+	carts, err := s.cartRepo.GetByUserID(uid)
+	if err != nil {
+		return err
+	}
+
+	return s.productRepo.WithTx(func(tx *gorm.DB) error {
+		cartRepo := s.txCartRepo(tx)
+		for i := range carts {
+			c := &carts[i]
+			if !cartLineAvailable(c) {
+				continue
+			}
+			price := pricing.PriceUnit(variantPrice(&c.Product, c.Variant), c.Product.Discount).Net
+			if pricing.FromFloat(c.PriceAtAdd) == price {
+				continue
+			}
+			if err := cartRepo.UpdatePriceAtAdd(uid, c.ProductID, c.VariantID, price.Float()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// clampCartLines lowers lines above the stock left to what can still be bought and saves them,
+// lines that are sold out are left for the stock check to reject
+func clampCartLines(cartRepo repositories.CartRepository, userID uuid.UUID, carts []models.Cart) error {
+	for i := range carts {
+		c := &carts[i]
+		stock := variantStock(&c.Product, c.Variant)
+		if stock == 0 || c.Quantity <= stock {
+			continue
+		}
+		if err := cartRepo.UpdateQuantity(userID, c.ProductID, c.VariantID, stock); err != nil {
+			return err
+		}
+		c.Quantity = stock
+	}
+	return nil
+}
+
+// ReleaseExpiredReservations sweeps holds whose TTL ran out, expired rows are already ignored by reads
+func (s *cartService) ReleaseExpiredReservations() (int64, error) {
+	return s.reservationRepo.DeleteExpired()
+}
+
+// MergeGuestCart moves the guest cart into the user's cart after sign in. A line already in the
+// user's cart takes the sum of both quantities, and every merged line is clamped to the stock left.
+func (s *cartService) MergeGuestCart(guestID, userID string) error {
+	gid, err := uuid.Parse(guestID)
+	if err != nil {
+		return nil
+	}
+	uid, _ := uuid.Parse(userID)
+
+	guestLines, err := s.guestCartRepo.GetByUserID(gid)
+	if err != nil || len(guestLines) == 0 {
+		return err
+	}
+
+	return s.productRepo.WithTx(func(tx *gorm.DB) error {
+		productRepo := repositories.NewProductRepository(tx)
+		cartRepo := repositories.NewCartRepository(tx)
+		guestCartRepo := repositories.NewGuestCartRepository(tx)
+		reservationRepo := repositories.NewReservationRepository(tx)
+
+		if err := reservationRepo.ReleaseAll(gid); err != nil {
+			return err
+		}
+
+		for _, g := range guestLines {
+			// ? produk atau varian sudah tidak dijual, baris tamu dibuang
+			if !g.Product.IsActive || (g.VariantID != nil && (g.Variant == nil || !g.Variant.IsActive)) {
+				continue
+			}
+
+			quantity := g.Quantity
+			existing, err := cartRepo.GetItem(uid, g.ProductID, g.VariantID)
+			if err == nil {
+				quantity += existing.Quantity
+			}
+
+			stock := variantStock(&g.Product, g.Variant)
+			if config.CartReservationEnabled {
+				reserved, err := reservationRepo.GetReservedByOthers(uid, g.ProductID, g.VariantID)
+				if err != nil {
+					return err
+				}
+				stock = availableStock(stock, reserved)
+			}
+			if quantity > stock {
+				quantity = stock
+			}
+			if quantity <= 0 {
+				continue
+			}
+
+			if existing != nil {
+				if err := cartRepo.UpdateQuantity(uid, g.ProductID, g.VariantID, quantity); err != nil {
+					return err
+				}
+				if err := cartRepo.SetSavedForLater(uid, g.ProductID, g.VariantID, false); err != nil {
+					return err
+				}
+			} else {
+				// ? harga saat tamu memasukkan barang tetap dipakai untuk peringatan perubahan harga
+				priceAtAdd := g.PriceAtAdd
+				if priceAtAdd == 0 {
+					priceAtAdd = pricing.PriceUnit(variantPrice(&g.Product, g.Variant), g.Product.Discount).Net.Float()
+				}
+				line := &models.Cart{
+					ProductID:  g.ProductID,
+					VariantID:  g.VariantID,
+					Quantity:   quantity,
+					PriceAtAdd: priceAtAdd,
+				}
+				if err := cartRepo.AddOrUpdate(uid, line); err != nil {
+					return err
+				}
+			}
+
+			if config.CartReservationEnabled {
+				if err := reserveStock(productRepo, reservationRepo, uid, &g.Product, g.Variant, quantity); err != nil {
+					return err
+				}
+			}
+		}
+
+		return guestCartRepo.Clear(gid)
+	})
+}
+
+// PurgeGuestCarts drops guest carts whose cookie has expired
+func (s *cartService) PurgeGuestCarts() (int64, error) {
+	return s.guestCartRepo.DeleteStale(time.Now().Add(-utils.GuestCartTTL))
+}
+
+// txCartRepo binds the service's cart repository, user or guest, to the transaction
+func (s *cartService) txCartRepo(tx *gorm.DB) repositories.CartRepository {
+	if s.cartRepo == s.guestCartRepo {
+		return repositories.NewGuestCartRepository(tx)
+	}
+	return repositories.NewCartRepository(tx)
+}
+
+func cartLineKey(productID uuid.UUID, variantID *uuid.UUID) string {
+	if variantID == nil {
+		return productID.String()
+	}
+	return productID.String() + ":" + variantID.String()
+}
+
+func toCartItemResponse(c *models.Cart) (dto.CartItemResponse, pricing.Money) {
+	unit := pricing.PriceUnit(variantPrice(&c.Product, c.Variant), c.Product.Discount)
+	line := pricing.NewLine(c.ID.String(), unit, c.Quantity)
+
+	item := dto.CartItemResponse{
+		ProductID:        c.ProductID.String(),
+		Name:             c.Product.Name,
+		Price:            unit.List.Float(),
+		Discount:         unit.DiscountPercent,
+		DiscountedPrice:  unit.Net.Float(),
+		Image:            variantImage(&c.Product, c.Variant),
+		IsChecked:        c.IsChecked,
+		Weight:           variantWeight(&c.Product, c.Variant),
+		Quantity:         c.Quantity,
+		OriginalSubtotal: line.Gross.Float(),
+		Subtotal:         line.Subtotal.Float(),
+
+		PriceAtAdd: c.PriceAtAdd,
+	}
+	if c.VariantID != nil {
+		item.VariantID = c.VariantID.String()
+	}
+	if c.Variant != nil {
+		item.Variant = variantLabel(c.Variant)
+		item.SKU = c.Variant.SKU
+	}
+	return item, line.Subtotal
+}
+
+// cartLineAvailable is false once the product or the chosen variant was deleted or deactivated
+func cartLineAvailable(c *models.Cart) bool {
+	if c.Product.ID == uuid.Nil || !c.Product.IsActive {
+		return false
+	}
+	if c.VariantID != nil {
+		return c.Variant != nil && c.Variant.IsActive
+	}
+	return true
 }
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/repositories"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func newTestCartService(db *gorm.DB) CartService {
+	return NewCartService(repositories.NewCartRepository(db), repositories.NewGuestCartRepository(db), repositories.NewProductRepository(db), repositories.NewReservationRepository(db))
+}
+
+func TestMergeGuestCartKeepsPriceAtAdd(t *testing.T) {
+	db := testDB(t)
+	carts := newTestCartService(db)
+
+	tests := []struct {
+		name       string
+		priceAtAdd float64
+		want       float64
+	}{
+		{"guest price is kept", 120000, 120000},
+		{"missing guest price takes the current price", 0, 100000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, product := seedCustomer(t, db, 5, 1)
+			if err := db.Where("user_id = ?", user.ID).Delete(&models.Cart{}).Error; err != nil {
+				t.Fatal(err)
+			}
+			guestID := uuid.New()
+			guest := &models.Cart{ID: uuid.New(), GuestID: &guestID, ProductID: product.ID, Quantity: 2, IsChecked: true, PriceAtAdd: tt.priceAtAdd}
+			if err := db.Create(guest).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			if err := carts.MergeGuestCart(guestID.String(), user.ID.String()); err != nil {
+				t.Fatalf("MergeGuestCart() error = %v", err)
+			}
+
+			var line models.Cart
+			if err := db.First(&line, "user_id = ? AND product_id = ?", user.ID, product.ID).Error; err != nil {
+				t.Fatalf("guest line not merged: %v", err)
+			}
+			if line.Quantity != 2 || line.PriceAtAdd != tt.want {
+				t.Errorf("merged line quantity %d price at add %.0f, want 2 and %.0f", line.Quantity, line.PriceAtAdd, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCartDoesNotChangeTheCart(t *testing.T) {
+	db := testDB(t)
+	carts := newTestCartService(db)
+
+	user, product := seedCustomer(t, db, 5, 4)
+	if err := db.Model(&models.Product{}).Where("id = ?", product.ID).Updates(map[string]any{"price": 90000, "stock": 3}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	stored := func() models.Cart {
+		t.Helper()
+		var line models.Cart
+		if err := db.First(&line, "user_id = ? AND product_id = ?", user.ID, product.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return line
+	}
+
+	tests := []struct {
+		name         string
+		before       func() error
+		wantWarnings []string
+		wantPriceAdd float64
+	}{
+		{"first read", nil, []string{CartWarningQuantityClamped, CartWarningPriceChanged}, 100000},
+		{"second read still warns", nil, []string{CartWarningQuantityClamped, CartWarningPriceChanged}, 100000},
+		{"after acknowledging the prices", func() error { return carts.AcknowledgePriceChanges(user.ID.String()) }, []string{CartWarningQuantityClamped}, 90000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != nil {
+				if err := tt.before(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			items, _, err := carts.GetCart(user.ID.String())
+			if err != nil {
+				t.Fatalf("GetCart() error = %v", err)
+			}
+			if len(items) != 1 {
+				t.Fatalf("GetCart() returned %d lines, want 1", len(items))
+			}
+			if !slices.Equal(items[0].Warnings, tt.wantWarnings) || items[0].Quantity != 3 {
+				t.Errorf("warnings %v quantity %d, want %v and 3", items[0].Warnings, items[0].Quantity, tt.wantWarnings)
+			}
+			if line := stored(); line.Quantity != 4 || line.PriceAtAdd != tt.wantPriceAdd {
+				t.Errorf("stored line quantity %d price at add %.0f, want 4 and %.0f", line.Quantity, line.PriceAtAdd, tt.wantPriceAdd)
+			}
+		})
+	}
+}
+
+func TestQuoteClampsCartToStock(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("QUOTE_SECRET", "checkout-test-secret")
+	svc := newCheckoutServices(db, NewFakeGateway("http://localhost:5002", "fake-secret"))
+
+	user, product := seedCustomer(t, db, 5, 4)
+	if err := db.Model(&models.Product{}).Where("id = ?", product.ID).Update("stock", 3).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	quote, err := svc.order.Quote(user.ID.String(), dto.CheckoutQuoteRequest{Courier: "jne", Service: "REG"})
+	if err != nil {
+		t.Fatalf("Quote() error = %v", err)
+	}
+	if len(quote.Items) != 1 || quote.Items[0].Quantity != 3 {
+		t.Errorf("quote items = %+v, want one line of 3", quote.Items)
+	}
+
+	var line models.Cart
+	if err := db.First(&line, "user_id = ? AND product_id = ?", user.ID, product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if line.Quantity != 3 {
+		t.Errorf("cart quantity after quote = %d, want 3", line.Quantity)
+	}
+}
+
+func TestAddToCartChecksProductAndCartQuantity(t *testing.T) {
+	db := testDB(t)
+	carts := newTestCartService(db)
+
+	user, product := seedCustomer(t, db, 5, 2)
+	_, inactive := seedCustomer(t, db, 5, 1)
+	if err := db.Model(&models.Product{}).Where("id = ?", inactive.ID).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		productID string
+		quantity  int
+		wantErr   bool
+		wantCart  int
+	}{
+		{"inactive product", inactive.ID.String(), 1, true, 2},
+		{"up to the stock with what is in the cart", product.ID.String(), 3, false, 5},
+		{"more than the stock with what is in the cart", product.ID.String(), 1, true, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := carts.AddToCart(user.ID.String(), dto.CartItemRequest{ProductID: tt.productID, Quantity: tt.quantity})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddToCart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.productID == inactive.ID.String() && !errors.Is(err, ErrProductUnavailable) {
+				t.Errorf("AddToCart() error = %v, want %v", err, ErrProductUnavailable)
+			}
+
+			var line models.Cart
+			if err := db.First(&line, "user_id = ? AND product_id = ?", user.ID, product.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if line.Quantity != tt.wantCart {
+				t.Errorf("cart quantity = %d, want %d", line.Quantity, tt.wantCart)
+			}
+		})
+	}
+}
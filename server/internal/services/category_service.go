@@ -1,17 +1,31 @@
 package services
 
 import (
+	"errors"
 	"server/internal/dto"
 	"server/internal/models"
 	"server/internal/repositories"
 	"server/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrParentNotFound      = errors.New("parent category not found")
+	ErrCategoryCycle       = errors.New("a category cannot be moved under itself or its subcategories")
+	ErrCategoryInUse       = errors.New("category still has products or subcategories, choose a category to move them to")
+	ErrInvalidCategoryMove = errors.New("products cannot be moved to the deleted category or its subcategories")
 )
 
 type CategoryService interface {
 	GetAllCategories(param dto.CategoryQueryParam) ([]dto.CategoryListResponse, *dto.PaginationResponse, error)
-	DeleteCategory(categoryID string) error
+	GetCategoryTree() ([]dto.CategoryTreeResponse, error)
+	DeleteCategory(categoryID string, param dto.DeleteCategoryParam) error
 	CreateCategory(req dto.CreateCategoryRequest) error
 	GetCategoryByID(categoryID string) (*dto.CategoryResponse, error)
+	GetCategoryBySlug(slug string) (*dto.CategoryResponse, error)
 	UpdateCategory(categoryID string, req dto.UpdateCategoryRequest) error
 }
 
@@ -30,12 +44,7 @@ func (s *categoryService) GetAllCategories(param dto.CategoryQueryParam) ([]dto.
 
 	var result []dto.CategoryListResponse
 	for _, c := range categories {
-		result = append(result, dto.CategoryListResponse{
-			ID:    c.ID.String(),
-			Name:  c.Name,
-			Slug:  c.Slug,
-			Image: c.Image,
-		})
+		result = append(result, toCategoryListResponse(&c))
 	}
 
 	totalPages := int((total + int64(param.Limit) - 1) / int64(param.Limit))
@@ -49,11 +58,43 @@ func (s *categoryService) GetAllCategories(param dto.CategoryQueryParam) ([]dto.
 	return result, pagination, nil
 }
 
+func (s *categoryService) GetCategoryTree() ([]dto.CategoryTreeResponse, error) {
+	tree, err := s.loadTree()
+	if err != nil {
+		return nil, err
+	}
+	productCounts, err := s.repo.CountProductsByCategory()
+	if err != nil {
+		return nil, err
+	}
+	return tree.response(uuid.Nil, productCounts), nil
+}
+
 func (s *categoryService) CreateCategory(req dto.CreateCategoryRequest) error {
+	var parentID *uuid.UUID
+	if req.ParentID != "" {
+		tree, err := s.loadTree()
+		if err != nil {
+			return err
+		}
+		id, _ := uuid.Parse(req.ParentID)
+		if tree.byID[id] == nil {
+			return ErrParentNotFound
+		}
+		parentID = &id
+	}
+
+	position, err := s.position(parentID, req.Position)
+	if err != nil {
+		return err
+	}
+
 	category := models.Category{
-		Name:  req.Name,
-		Slug:  utils.GenerateSlug(req.Name),
-		Image: req.ImageURL,
+		Name:     req.Name,
+		Slug:     utils.GenerateSlug(req.Name),
+		Image:    req.ImageURL,
+		ParentID: parentID,
+		Position: position,
 	}
 	return s.repo.CreateCategory(&category)
 }
@@ -61,7 +102,7 @@ func (s *categoryService) CreateCategory(req dto.CreateCategoryRequest) error {
 func (s *categoryService) UpdateCategory(categoryID string, req dto.UpdateCategoryRequest) error {
 	category, err := s.repo.GetCategoryByID(categoryID)
 	if err != nil {
-		return err
+		return ErrCategoryNotFound
 	}
 
 	if req.Name != "" {
@@ -73,30 +114,208 @@ func (s *categoryService) UpdateCategory(categoryID string, req dto.UpdateCatego
 		category.Image = req.ImageURL
 	}
 
+	moved := false
+	if req.ParentID != nil {
+		parentID, err := s.validParent(category.ID, *req.ParentID)
+		if err != nil {
+			return err
+		}
+		moved = !sameParent(category.ParentID, parentID)
+		category.ParentID = parentID
+	}
+
+	// ? pindah parent tanpa posisi berarti ditaruh paling akhir di parent barunya
+	if req.Position != nil || moved {
+		position, err := s.position(category.ParentID, req.Position)
+		if err != nil {
+			return err
+		}
+		category.Position = position
+	}
+
 	return s.repo.UpdateCategory(category)
 }
 
-func (s *categoryService) DeleteCategory(categoryID string) error {
+// DeleteCategory only deletes a category that nothing references anymore. Products and
+// subcategories still in it are moved to param.MoveTo first, without it the delete is refused.
+func (s *categoryService) DeleteCategory(categoryID string, param dto.DeleteCategoryParam) error {
 	category, err := s.repo.GetCategoryByID(categoryID)
+	if err != nil {
+		return ErrCategoryNotFound
+	}
+
+	tree, err := s.loadTree()
 	if err != nil {
 		return err
 	}
+	products, err := s.repo.CountProducts(category.ID)
+	if err != nil {
+		return err
+	}
+	inUse := products > 0 || len(tree.children[category.ID]) > 0
 
-	utils.CleanupImageOnError(category.Image)
+	var moveTo uuid.UUID
+	if inUse {
+		if param.MoveTo == "" {
+			return ErrCategoryInUse
+		}
+		moveTo, _ = uuid.Parse(param.MoveTo)
+		if tree.byID[moveTo] == nil {
+			return ErrCategoryNotFound
+		}
+		if tree.isWithin(moveTo, category.ID) {
+			return ErrInvalidCategoryMove
+		}
+	}
 
-	return s.repo.DeleteCategory(categoryID)
+	err = s.repo.WithTx(func(tx *gorm.DB) error {
+		txRepo := repositories.NewCategoryRepository(tx)
+		if inUse {
+			if err := txRepo.MoveProducts(category.ID, moveTo); err != nil {
+				return err
+			}
+			if err := txRepo.MoveChildren(category.ID, &moveTo); err != nil {
+				return err
+			}
+		}
+		return txRepo.DeleteCategory(categoryID)
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.CleanupImageOnError(category.Image)
+	return nil
 }
 
 func (s *categoryService) GetCategoryByID(categoryID string) (*dto.CategoryResponse, error) {
 	category, err := s.repo.GetCategoryByID(categoryID)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+	return s.toCategoryResponse(category)
+}
+
+func (s *categoryService) GetCategoryBySlug(slug string) (*dto.CategoryResponse, error) {
+	category, err := s.repo.GetCategoryBySlug(slug)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+	return s.toCategoryResponse(category)
+}
+
+func (s *categoryService) toCategoryResponse(category *models.Category) (*dto.CategoryResponse, error) {
+	tree, err := s.loadTree()
 	if err != nil {
 		return nil, err
 	}
 
+	children := make([]dto.CategoryListResponse, 0, len(tree.children[category.ID]))
+	for _, child := range tree.children[category.ID] {
+		children = append(children, toCategoryListResponse(child))
+	}
+
+	item := toCategoryListResponse(category)
 	return &dto.CategoryResponse{
-		ID:    categoryID,
-		Name:  category.Name,
-		Slug:  category.Slug,
-		Image: category.Image,
+		ID:          item.ID,
+		Name:        item.Name,
+		Slug:        item.Slug,
+		Image:       item.Image,
+		ParentID:    item.ParentID,
+		Position:    item.Position,
+		Breadcrumbs: tree.breadcrumbs(category.ID),
+		Children:    children,
 	}, nil
 }
+
+func (s *categoryService) loadTree() (*categoryTree, error) {
+	categories, err := s.repo.GetCategoryNodes()
+	if err != nil {
+		return nil, err
+	}
+	return newCategoryTree(categories), nil
+}
+
+// validParent resolves the requested parent of a category, an empty ID means the top level
+func (s *categoryService) validParent(categoryID uuid.UUID, parent string) (*uuid.UUID, error) {
+	if parent == "" {
+		return nil, nil
+	}
+	parentID, err := uuid.Parse(parent)
+	if err != nil {
+		return nil, ErrParentNotFound
+	}
+
+	tree, err := s.loadTree()
+	if err != nil {
+		return nil, err
+	}
+	if tree.byID[parentID] == nil {
+		return nil, ErrParentNotFound
+	}
+	if tree.isWithin(parentID, categoryID) {
+		return nil, ErrCategoryCycle
+	}
+	return &parentID, nil
+}
+
+// position keeps the requested position, or puts the category after its last sibling
+func (s *categoryService) position(parentID *uuid.UUID, requested *int) (int, error) {
+	if requested != nil {
+		return *requested, nil
+	}
+	return s.repo.NextPosition(parentID)
+}
+
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func toCategoryListResponse(c *models.Category) dto.CategoryListResponse {
+	var parentID *string
+	if c.ParentID != nil {
+		parentID = utils.ToPtr(c.ParentID.String())
+	}
+	return dto.CategoryListResponse{
+		ID:       c.ID.String(),
+		Name:     c.Name,
+		Slug:     c.Slug,
+		Image:    c.Image,
+		ParentID: parentID,
+		Position: c.Position,
+	}
+}
+
+// categorySubtreeSlugs returns the slug and the slugs of every category below it,
+// an unknown slug is returned alone so the search simply finds nothing
+func categorySubtreeSlugs(repo repositories.CategoryRepository, slug string) ([]string, error) {
+	categories, err := repo.GetCategoryNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	tree := newCategoryTree(categories)
+	for id, c := range tree.byID {
+		if c.Slug != slug {
+			continue
+		}
+		var slugs []string
+		for _, node := range tree.subtree(id) {
+			slugs = append(slugs, node.Slug)
+		}
+		return slugs, nil
+	}
+	return []string{slug}, nil
+}
+
+// categoryBreadcrumbs is the path from the top level down to the category
+func categoryBreadcrumbs(repo repositories.CategoryRepository, categoryID uuid.UUID) ([]dto.CategoryBreadcrumb, error) {
+	categories, err := repo.GetCategoryNodes()
+	if err != nil {
+		return nil, err
+	}
+	return newCategoryTree(categories).breadcrumbs(categoryID), nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"server/internal/dto"
+	"server/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// categoryTree indexes the loaded categories by ID and by parent.
+// Kategori dengan parent yang sudah dihapus diperlakukan sebagai kategori utama.
+type categoryTree struct {
+	byID     map[uuid.UUID]*models.Category
+	children map[uuid.UUID][]*models.Category // uuid.Nil -> kategori utama
+}
+
+// newCategoryTree expects the categories ordered by position, children keep that order
+func newCategoryTree(categories []models.Category) *categoryTree {
+	t := &categoryTree{
+		byID:     make(map[uuid.UUID]*models.Category, len(categories)),
+		children: make(map[uuid.UUID][]*models.Category),
+	}
+	for i := range categories {
+		t.byID[categories[i].ID] = &categories[i]
+	}
+	for i := range categories {
+		c := &categories[i]
+		t.children[t.parentOf(c)] = append(t.children[t.parentOf(c)], c)
+	}
+	return t
+}
+
+func (t *categoryTree) parentOf(c *models.Category) uuid.UUID {
+	if c.ParentID == nil {
+		return uuid.Nil
+	}
+	if _, ok := t.byID[*c.ParentID]; !ok {
+		return uuid.Nil
+	}
+	return *c.ParentID
+}
+
+// path returns the categories from the top level down to id
+func (t *categoryTree) path(id uuid.UUID) []*models.Category {
+	var path []*models.Category
+	seen := make(map[uuid.UUID]bool)
+	for c := t.byID[id]; c != nil && !seen[c.ID]; c = t.byID[t.parentOf(c)] {
+		seen[c.ID] = true
+		path = append([]*models.Category{c}, path...)
+	}
+	return path
+}
+
+// subtree returns id and every category below it
+func (t *categoryTree) subtree(id uuid.UUID) []*models.Category {
+	root := t.byID[id]
+	if root == nil {
+		return nil
+	}
+
+	nodes := []*models.Category{root}
+	seen := map[uuid.UUID]bool{root.ID: true}
+	for i := 0; i < len(nodes); i++ {
+		for _, child := range t.children[nodes[i].ID] {
+			if !seen[child.ID] {
+				seen[child.ID] = true
+				nodes = append(nodes, child)
+			}
+		}
+	}
+	return nodes
+}
+
+// isWithin reports whether id is ancestor itself or one of its descendants
+func (t *categoryTree) isWithin(id, ancestor uuid.UUID) bool {
+	for _, c := range t.path(id) {
+		if c.ID == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *categoryTree) breadcrumbs(id uuid.UUID) []dto.CategoryBreadcrumb {
+	path := t.path(id)
+	crumbs := make([]dto.CategoryBreadcrumb, 0, len(path))
+	for _, c := range path {
+		crumbs = append(crumbs, dto.CategoryBreadcrumb{ID: c.ID.String(), Name: c.Name, Slug: c.Slug})
+	}
+	return crumbs
+}
+
+// response builds the nested tree under parent, product counts add up the whole subtree
+func (t *categoryTree) response(parent uuid.UUID, productCounts map[uuid.UUID]int) []dto.CategoryTreeResponse {
+	children := t.children[parent]
+	nodes := make([]dto.CategoryTreeResponse, 0, len(children))
+	for _, c := range children {
+		node := dto.CategoryTreeResponse{
+			ID:           c.ID.String(),
+			Name:         c.Name,
+			Slug:         c.Slug,
+			Image:        c.Image,
+			Position:     c.Position,
+			ProductCount: productCounts[c.ID],
+			Children:     t.response(c.ID, productCounts),
+		}
+		for _, child := range node.Children {
+			node.ProductCount += child.ProductCount
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
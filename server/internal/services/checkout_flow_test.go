@@ -0,0 +1,348 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"server/internal/config"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/repositories"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ? test alur lengkap butuh MySQL, jalankan dengan TEST_DB_URL=user:pass@tcp(host:port)/db?parseTime=true
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+
+	// ? transaksi bersarang tidak pakai savepoint supaya juga jalan di server MySQL in-memory
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent), DisableNestedTransaction: true})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	if err := config.Migrate(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+type nopNotificationService struct {
+	NotificationService
+	sent []dto.NotificationEvent
+}
+
+func (s *nopNotificationService) SendToUser(req dto.NotificationEvent) error {
+	s.sent = append(s.sent, req)
+	return nil
+}
+
+func (s *nopNotificationService) SendToAdmins(req dto.NotificationEvent) error {
+	s.sent = append(s.sent, req)
+	return nil
+}
+
+// seedCustomer creates a customer with a main address and one product of stock in the cart
+func seedCustomer(t *testing.T, db *gorm.DB, stock, quantity int) (*models.User, *models.Product) {
+	t.Helper()
+	suffix := uuid.NewString()[:8]
+
+	user := &models.User{ID: uuid.New(), Email: "checkout-" + suffix + "@example.com", Password: "x", Role: "customer"}
+	category := &models.Category{ID: uuid.New(), Name: "Checkout " + suffix, Slug: "checkout-" + suffix}
+	product := &models.Product{
+		ID:         uuid.New(),
+		CategoryID: category.ID,
+		Name:       "Checkout Shirt " + suffix,
+		Slug:       "checkout-shirt-" + suffix,
+		Stock:      stock,
+		Price:      100000,
+		IsActive:   true,
+		Weight:     500,
+	}
+	seed := []any{
+		user,
+		&models.Profile{ID: uuid.New(), UserID: user.ID, Fullname: "Checkout Customer"},
+		&models.Address{
+			ID: uuid.New(), UserID: user.ID, Name: "Home", IsMain: true, Address: "Jl. Test 1",
+			ProvinceID: uint(config.ShopOriginProvinceID), CityID: uint(config.ShopOriginCityID), DistrictID: 1, SubdistrictID: 1, PostalCodeID: 1,
+			Province: "Sumatera Utara", City: "Medan", District: "Medan Kota", Subdistrict: "Kota", PostalCode: "20111", Phone: "08123456789",
+		},
+		category,
+		product,
+		&models.Cart{ID: uuid.New(), UserID: &user.ID, ProductID: product.ID, Quantity: quantity, IsChecked: true, PriceAtAdd: product.Price},
+	}
+	for _, row := range seed {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("failed to seed %T: %v", row, err)
+		}
+	}
+	return user, product
+}
+
+// checkoutServices wires the order and payment services to the test database and a fake gateway
+type checkoutServices struct {
+	repo          *repositories.Repositories
+	notifications *nopNotificationService
+	gateway       PaymentGateway
+	order         OrderService
+	payment       PaymentService
+}
+
+func newCheckoutServices(db *gorm.DB, gateway PaymentGateway) *checkoutServices {
+	repo := repositories.InitRepositories(db)
+	notifications := &nopNotificationService{}
+	return &checkoutServices{
+		repo:          repo,
+		notifications: notifications,
+		gateway:       gateway,
+		order:         NewOrderService(repo.OrderRepository, repo.PaymentRepository, repo.AuthRepository, NewVoucherService(repo.VoucherRepository), notifications, gateway),
+		payment:       NewPaymentService(repo.PaymentRepository, repo.AuthRepository, repo.OrderRepository, notifications, gateway),
+	}
+}
+
+// checkout quotes and places the customer's cart, it returns the payment created for the order
+func (s *checkoutServices) checkout(t *testing.T, db *gorm.DB, userID string) (*dto.CheckoutResponse, *models.Payment) {
+	t.Helper()
+	quote, err := s.order.Quote(userID, dto.CheckoutQuoteRequest{Courier: "jne", Service: "REG"})
+	if err != nil {
+		t.Fatalf("Quote() error = %v", err)
+	}
+	checkout, err := s.order.Checkout(userID, dto.CheckoutRequest{QuoteID: quote.QuoteID})
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+
+	var payment models.Payment
+	if err := db.Preload("Order.Items").First(&payment, "id = ?", checkout.PaymentID).Error; err != nil {
+		t.Fatalf("payment not created: %v", err)
+	}
+	return checkout, &payment
+}
+
+func TestCheckoutFakeSettlementMovesOrderToPending(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("QUOTE_SECRET", "checkout-test-secret")
+
+	gateway := NewFakeGateway("http://localhost:5002", "fake-secret")
+	svc := newCheckoutServices(db, gateway)
+	paymentService := svc.payment
+
+	user, product := seedCustomer(t, db, 5, 2)
+	checkout, payment := svc.checkout(t, db, user.ID.String())
+	if checkout.Gateway != GatewayFake || checkout.SnapURL == "" {
+		t.Fatalf("Checkout() = %+v, want a fake gateway pay link", checkout)
+	}
+
+	orderID := payment.OrderID.String()
+	if payment.Order.Status != OrderWaitingPayment || payment.Status != "pending" {
+		t.Fatalf("after checkout order is %s and payment %s, want %s and pending", payment.Order.Status, payment.Status, OrderWaitingPayment)
+	}
+	if payment.Order.PaymentLink != checkout.SnapURL {
+		t.Errorf("order payment link = %q, want %q", payment.Order.PaymentLink, checkout.SnapURL)
+	}
+
+	if err := paymentService.SimulateFakePayment(orderID, "wrong-token", "settlement"); err == nil {
+		t.Fatal("SimulateFakePayment() accepted a wrong pay token")
+	}
+	if err := paymentService.SimulateFakePayment(orderID, gateway.PayToken(orderID), "settlement"); err != nil {
+		t.Fatalf("SimulateFakePayment() error = %v", err)
+	}
+
+	var order models.Order
+	if err := db.First(&order, "id = ?", orderID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != OrderPending {
+		t.Errorf("order status = %s, want %s", order.Status, OrderPending)
+	}
+	if err := db.First(&payment, "id = ?", checkout.PaymentID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if payment.Status != "success" || payment.Method != "fake" {
+		t.Errorf("payment = %s via %s, want success via fake", payment.Status, payment.Method)
+	}
+
+	var stored models.Product
+	if err := db.First(&stored, "id = ?", product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Stock != 3 || stored.Sold != 2 {
+		t.Errorf("product stock %d sold %d, want 3 and 2", stored.Stock, stored.Sold)
+	}
+
+	var settled int64
+	if err := db.Model(&models.OrderStatusHistory{}).
+		Where("order_id = ? AND from_status = ? AND to_status = ? AND actor_type = ?", orderID, OrderWaitingPayment, OrderPending, ActorGateway).
+		Count(&settled).Error; err != nil {
+		t.Fatal(err)
+	}
+	if settled != 1 {
+		t.Errorf("gateway settlement recorded %d times in the status history, want once", settled)
+	}
+
+	// ? webhook yang dikirim ulang tidak mengubah apa pun
+	if err := paymentService.SimulateFakePayment(orderID, gateway.PayToken(orderID), "settlement"); err != nil {
+		t.Fatalf("repeated SimulateFakePayment() error = %v", err)
+	}
+}
+
+func TestCancelRestoresStockOfDeletedProduct(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("QUOTE_SECRET", "checkout-test-secret")
+
+	svc := newCheckoutServices(db, NewFakeGateway("http://localhost:5002", "fake-secret"))
+	user, product := seedCustomer(t, db, 5, 2)
+	_, payment := svc.checkout(t, db, user.ID.String())
+
+	// ? admin menghapus produk selagi order masih menunggu pembayaran
+	if err := db.Delete(&models.Product{}, "id = ?", product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.order.CancelOrder(user.ID.String(), payment.OrderID.String()); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+
+	var stored models.Product
+	if err := db.Unscoped().First(&stored, "id = ?", product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Stock != 5 {
+		t.Errorf("deleted product stock = %d, want 5", stored.Stock)
+	}
+
+	var movements []models.StockMovement
+	if err := db.Find(&movements, "reference_id = ? AND type = ?", payment.OrderID, StockCancel).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 1 || movements[0].Quantity != 2 || movements[0].StockAfter != 5 {
+		t.Errorf("cancel movements = %+v, want one movement of 2 ending at 5", movements)
+	}
+}
+
+// failingChargeGateway is the fake gateway refusing every new charge
+type failingChargeGateway struct {
+	*FakeGateway
+}
+
+func (g *failingChargeGateway) CreateCharge(req ChargeRequest) (*ChargeResult, error) {
+	return nil, errors.New("gateway unavailable")
+}
+
+func TestCheckoutCancelsOrderWhenChargeFails(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("QUOTE_SECRET", "checkout-test-secret")
+
+	svc := newCheckoutServices(db, &failingChargeGateway{NewFakeGateway("http://localhost:5002", "fake-secret")})
+	user, product := seedCustomer(t, db, 5, 2)
+
+	quote, err := svc.order.Quote(user.ID.String(), dto.CheckoutQuoteRequest{Courier: "jne", Service: "REG"})
+	if err != nil {
+		t.Fatalf("Quote() error = %v", err)
+	}
+	if _, err := svc.order.Checkout(user.ID.String(), dto.CheckoutRequest{QuoteID: quote.QuoteID}); err == nil {
+		t.Fatal("Checkout() succeeded while the gateway refused the charge")
+	}
+
+	var order models.Order
+	if err := db.Preload("Items").First(&order, "user_id = ?", user.ID).Error; err != nil {
+		t.Fatalf("order not created: %v", err)
+	}
+	if order.Status != OrderCanceled || order.PaymentLink != "" {
+		t.Errorf("order status %s with link %q, want %s without a link", order.Status, order.PaymentLink, OrderCanceled)
+	}
+
+	var payment models.Payment
+	if err := db.First(&payment, "order_id = ?", order.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if payment.Status != "failed" {
+		t.Errorf("payment status = %s, want failed", payment.Status)
+	}
+
+	var stored models.Product
+	if err := db.First(&stored, "id = ?", product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Stock != 5 {
+		t.Errorf("product stock = %d, want 5", stored.Stock)
+	}
+}
+
+func TestRefundedPaymentIgnoresReplayedWebhooks(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("QUOTE_SECRET", "checkout-test-secret")
+
+	gateway := NewFakeGateway("http://localhost:5002", "fake-secret")
+	svc := newCheckoutServices(db, gateway)
+	user, _ := seedCustomer(t, db, 5, 2)
+	_, payment := svc.checkout(t, db, user.ID.String())
+	orderID := payment.OrderID.String()
+	amount := int64(payment.Order.AmountToPay)
+
+	if err := svc.payment.SimulateFakePayment(orderID, gateway.PayToken(orderID), "settlement"); err != nil {
+		t.Fatalf("SimulateFakePayment() error = %v", err)
+	}
+	if _, err := svc.order.CancelOrder(user.ID.String(), orderID); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		status string
+	}{
+		{"replayed settlement", "settlement"},
+		{"gateway refund webhook", "refund"},
+		{"gateway partial refund webhook", "partial_refund"},
+		{"late expiry", "expire"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := gateway.Notify(orderID, tt.status, amount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.payment.HandlePaymentNotification(body); err != nil {
+				t.Fatalf("HandlePaymentNotification(%s) error = %v", tt.status, err)
+			}
+
+			var p models.Payment
+			var o models.Order
+			if err := db.First(&p, "order_id = ?", orderID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.First(&o, "id = ?", orderID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if p.Status != "refunded" || int64(p.RefundedAmount) != amount || o.Status != OrderCanceled {
+				t.Errorf("payment %s refunded %.0f order %s, want refunded %d and %s", p.Status, p.RefundedAmount, o.Status, amount, OrderCanceled)
+			}
+			if len(gateway.Refunds) != 1 {
+				t.Errorf("gateway received %d refunds, want 1", len(gateway.Refunds))
+			}
+		})
+	}
+}
+
+func TestPaymentNotificationForUnknownOrder(t *testing.T) {
+	db := testDB(t)
+
+	gateway := NewFakeGateway("http://localhost:5002", "fake-secret")
+	svc := newCheckoutServices(db, gateway)
+
+	body, err := gateway.Notify(uuid.NewString(), "settlement", 100000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.payment.HandlePaymentNotification(body); !errors.Is(err, ErrPaymentNotFound) {
+		t.Errorf("HandlePaymentNotification() error = %v, want %v", err, ErrPaymentNotFound)
+	}
+}
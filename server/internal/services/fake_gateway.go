@@ -0,0 +1,164 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FakeGateway is an in-memory gateway for local development and tests.
+// Charges are paid through a local pay page or by calling Notify directly,
+// notifications and pay page links are signed with the shared secret.
+type FakeGateway struct {
+	mu      sync.Mutex
+	baseURL string
+	secret  []byte
+	charges map[string]*FakeCharge
+	Refunds []FakeRefund
+}
+
+type FakeCharge struct {
+	OrderID     string
+	Amount      int64
+	Status      string
+	PaymentType string
+}
+
+type FakeRefund struct {
+	OrderID   string
+	RefundKey string
+	Amount    int64
+	Reason    string
+}
+
+type fakeNotification struct {
+	OrderID           string `json:"order_id"`
+	TransactionStatus string `json:"transaction_status"`
+	PaymentType       string `json:"payment_type"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+}
+
+func NewFakeGateway(baseURL, secret string) *FakeGateway {
+	return &FakeGateway{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  []byte(secret),
+		charges: make(map[string]*FakeCharge),
+	}
+}
+
+func (g *FakeGateway) Name() string { return GatewayFake }
+
+func (g *FakeGateway) CreateCharge(req ChargeRequest) (*ChargeResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	orderID := req.Order.ID.String()
+	g.charges[orderID] = &FakeCharge{
+		OrderID: orderID,
+		Amount:  int64(req.Order.AmountToPay),
+		Status:  "pending",
+	}
+
+	return &ChargeResult{
+		Token:       "fake-" + orderID,
+		RedirectURL: fmt.Sprintf("%s/api/payments/fake/%s?token=%s", g.baseURL, orderID, g.PayToken(orderID)),
+	}, nil
+}
+
+// Notify changes the charge status and returns the webhook body the gateway would send.
+// transactionStatus uses the same values as midtrans: settlement, pending, expire, cancel, deny.
+func (g *FakeGateway) Notify(orderID, transactionStatus string, amount int64) ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[orderID]
+	if !ok {
+		// ? charge hilang kalau server restart, buat ulang dari data order
+		charge = &FakeCharge{OrderID: orderID, Amount: amount}
+		g.charges[orderID] = charge
+	}
+	charge.Status = transactionStatus
+	charge.PaymentType = "fake"
+
+	n := fakeNotification{
+		OrderID:           orderID,
+		TransactionStatus: transactionStatus,
+		PaymentType:       charge.PaymentType,
+		GrossAmount:       fmt.Sprintf("%d.00", charge.Amount),
+	}
+	n.SignatureKey = g.sign(n.OrderID, n.TransactionStatus, n.PaymentType, n.GrossAmount)
+	return json.Marshal(n)
+}
+
+func (g *FakeGateway) ParseNotification(body []byte) (*PaymentStatus, error) {
+	var n fakeNotification
+	if err := json.Unmarshal(body, &n); err != nil || n.OrderID == "" || n.SignatureKey == "" {
+		return nil, ErrInvalidNotification
+	}
+	if !g.verify(n.SignatureKey, n.OrderID, n.TransactionStatus, n.PaymentType, n.GrossAmount) {
+		return nil, ErrInvalidSignature
+	}
+
+	return midtransStatus(n.OrderID, n.TransactionStatus, "", n.PaymentType, n.GrossAmount), nil
+}
+
+func (g *FakeGateway) QueryStatus(orderID string) (*PaymentStatus, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[orderID]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+
+	return midtransStatus(orderID, charge.Status, "", charge.PaymentType, fmt.Sprintf("%d.00", charge.Amount)), nil
+}
+
+func (g *FakeGateway) CancelCharge(orderID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if charge, ok := g.charges[orderID]; ok {
+		charge.Status = "cancel"
+	}
+	return nil
+}
+
+func (g *FakeGateway) Refund(orderID, refundKey string, amount int64, reason string) (*RefundResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, r := range g.Refunds {
+		if r.RefundKey == refundKey {
+			return &RefundResult{Reference: "fake-" + refundKey, Amount: r.Amount}, nil
+		}
+	}
+
+	g.Refunds = append(g.Refunds, FakeRefund{orderID, refundKey, amount, reason})
+	return &RefundResult{Reference: "fake-" + refundKey, Amount: amount}, nil
+}
+
+// PayToken authorizes the pay page of one order, only the customer who got the redirect URL knows it
+func (g *FakeGateway) PayToken(orderID string) string {
+	return g.sign("pay", orderID)
+}
+
+func (g *FakeGateway) VerifyPayToken(orderID, token string) bool {
+	return g.verify(token, "pay", orderID)
+}
+
+// ? HMAC-SHA256 dari semua field yang dipisah "|", sama seperti signature_key midtrans tapi pakai secret sendiri
+func (g *FakeGateway) sign(fields ...string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(strings.Join(fields, "|")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (g *FakeGateway) verify(signature string, fields ...string) bool {
+	return hmac.Equal([]byte(g.sign(fields...)), []byte(strings.ToLower(signature)))
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFakeGatewayNotificationSignature(t *testing.T) {
+	gateway := NewFakeGateway("http://localhost:5002", "fake-secret")
+	body, err := gateway.Notify("order-1", "settlement", 150000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tamper := func(field, value string) []byte {
+		var n map[string]string
+		json.Unmarshal(body, &n)
+		n[field] = value
+		b, _ := json.Marshal(n)
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		gateway *FakeGateway
+		body    []byte
+		wantErr error
+	}{
+		{"signed by the gateway", gateway, body, nil},
+		{"signed with another secret", NewFakeGateway("http://localhost:5002", "other-secret"), body, ErrInvalidSignature},
+		{"amount changed", gateway, tamper("gross_amount", "1.00"), ErrInvalidSignature},
+		{"status changed", gateway, tamper("transaction_status", "expire"), ErrInvalidSignature},
+		{"unsigned", gateway, tamper("signature_key", ""), ErrInvalidNotification},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := tt.gateway.ParseNotification(tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseNotification() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (status.Status != "success" || status.GrossAmount != "150000.00") {
+				t.Errorf("ParseNotification() = %+v, want a settled 150000.00", status)
+			}
+		})
+	}
+}
+
+func TestFakeGatewayPayToken(t *testing.T) {
+	gateway := NewFakeGateway("http://localhost:5002", "fake-secret")
+	token := gateway.PayToken("order-1")
+
+	if !gateway.VerifyPayToken("order-1", token) {
+		t.Error("token of the order is rejected")
+	}
+	if gateway.VerifyPayToken("order-2", token) {
+		t.Error("token is accepted for another order")
+	}
+	if gateway.VerifyPayToken("order-1", "") {
+		t.Error("empty token is accepted")
+	}
+}
@@ -1,44 +1,71 @@
 package services
 
 import (
+	"log"
+	"server/internal/config"
 	"server/internal/repositories"
-
 )
 
 type Services struct {
-	AdminService        AdminService
-	AuthService         AuthService
-	ProductService      ProductService
-	VoucherService      VoucherService
-	BannerService       BannerService
-	ProfileService      ProfileService
-	PaymentService      PaymentService
-	CartService         CartService
-	OrderService        OrderService
-	AddressService      AddressService
-	LocationService     LocationService
-	CategoryService     CategoryService
-	NotificationService NotificationService
-	ReviewService       ReviewService
+	AdminService          AdminService
+	AuthService           AuthService
+	ProductService        ProductService
+	VoucherService        VoucherService
+	BannerService         BannerService
+	ProfileService        ProfileService
+	PaymentService        PaymentService
+	CartService           CartService
+	GuestCartService      CartService
+	OrderService          OrderService
+	AddressService        AddressService
+	LocationService       LocationService
+	CategoryService       CategoryService
+	NotificationService   NotificationService
+	ReviewService         ReviewService
+	ReturnService         ReturnService
+	ReconciliationService ReconciliationService
+	InventoryService      InventoryService
+	StockAlertService     StockAlertService
+	WishlistService       WishlistService
+	SearchService         SearchService
+	RecommendationService RecommendationService
 }
 
 func InitServices(r *repositories.Repositories) *Services {
 	voucherSvc := NewVoucherService(r.VoucherRepository)
 	notificationSvc := NewNotificationService(r.NotificationRepository)
+	gateway := newPaymentGateway(config.PaymentGatewayName)
+	cartSvc := NewCartService(r.CartRepository, r.GuestCartRepository, r.ProductRepository, r.ReservationRepository)
 	return &Services{
-		VoucherService:      voucherSvc,
-		AdminService:        NewAdminService(r.AdminRepository),
-		BannerService:       NewBannerService(r.BannerRepository),
-		ProductService:      NewProductService(r.ProductRepository),
-		ProfileService:      NewProfileService(r.ProfileRepository),
-		LocationService:     NewLocationService(r.LocationRepository),
-		CategoryService:     NewCategoryService(r.CategoryRepository),
-		NotificationService: NewNotificationService(r.NotificationRepository),
-		CartService:         NewCartService(r.CartRepository, r.ProductRepository),
-		AuthService:         NewAuthService(r.AuthRepository, r.NotificationRepository),
-		AddressService:      NewAddressService(r.AddressRepository, r.LocationRepository),
-		PaymentService:      NewPaymentService(r.PaymentRepository, r.AuthRepository, r.ProductRepository, voucherSvc, r.OrderRepository, notificationSvc),
-		OrderService:        NewOrderService(r.OrderRepository, r.PaymentRepository, r.AuthRepository, r.ProductRepository, voucherSvc, notificationSvc),
-		ReviewService:       NewReviewService(r.ReviewRepository, r.OrderRepository),
+		VoucherService:        voucherSvc,
+		AdminService:          NewAdminService(r.AdminRepository),
+		BannerService:         NewBannerService(r.BannerRepository),
+		ProductService:        NewProductService(r.ProductRepository, r.ReservationRepository, r.WishlistRepository, r.CategoryRepository, r.SearchIndex, r.Suggester, r.SearchQueryRepository),
+		ProfileService:        NewProfileService(r.ProfileRepository),
+		LocationService:       NewLocationService(r.LocationRepository),
+		CategoryService:       NewCategoryService(r.CategoryRepository),
+		NotificationService:   NewNotificationService(r.NotificationRepository),
+		CartService:           cartSvc,
+		GuestCartService:      NewGuestCartService(r.GuestCartRepository, r.ProductRepository, r.ReservationRepository),
+		AuthService:           NewAuthService(r.AuthRepository, r.NotificationRepository),
+		AddressService:        NewAddressService(r.AddressRepository, r.LocationRepository),
+		PaymentService:        NewPaymentService(r.PaymentRepository, r.AuthRepository, r.OrderRepository, notificationSvc, gateway),
+		OrderService:          NewOrderService(r.OrderRepository, r.PaymentRepository, r.AuthRepository, voucherSvc, notificationSvc, gateway),
+		ReviewService:         NewReviewService(r.ReviewRepository, r.OrderRepository),
+		ReconciliationService: NewReconciliationService(r.ReconciliationRepository, r.PaymentRepository, r.OrderRepository, notificationSvc, gateway),
+		ReturnService:         NewReturnService(r.ReturnRepository, r.OrderRepository, r.PaymentRepository, gateway, notificationSvc),
+		InventoryService:      NewInventoryService(r.InventoryRepository, r.ProductRepository),
+		StockAlertService:     NewStockAlertService(r.StockAlertRepository, r.ProductRepository, notificationSvc),
+		WishlistService:       NewWishlistService(r.WishlistRepository, r.ProductRepository, cartSvc, notificationSvc),
+		SearchService:         NewSearchService(r.SearchQueryRepository, r.Suggester),
+		RecommendationService: NewRecommendationService(r.RecommendationRepository, r.ProductRepository),
+	}
+}
+
+func newPaymentGateway(name string) PaymentGateway {
+	if name == GatewayFake {
+		log.Println("⚠️  Using fake payment gateway, no real money will be charged")
+		return NewFakeGateway(config.AppURL, config.FakeGatewaySecret)
 	}
+	return NewMidtransGateway(config.MidtransServerKey, config.SnapClient, config.CoreClient, config.MidtransConfirmStatus)
 }
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	StockInitial    = "initial"
+	StockSale       = "sale"
+	StockCancel     = "cancel"
+	StockReturn     = "return"
+	StockAdjustment = "adjustment"
+	StockTake       = "stock_take"
+)
+
+type InventoryService interface {
+	AdjustStock(adminID string, req dto.StockAdjustmentRequest) (*dto.StockMovementResponse, error)
+	GetMovements(param dto.StockMovementQueryParam) ([]dto.StockMovementResponse, *dto.PaginationResponse, error)
+	CheckConsistency() (*dto.StockConsistencyResponse, error)
+}
+
+type inventoryService struct {
+	inventoryRepo repositories.InventoryRepository
+	productRepo   repositories.ProductRepository
+}
+
+func NewInventoryService(inventoryRepo repositories.InventoryRepository, productRepo repositories.ProductRepository) InventoryService {
+	return &inventoryService{inventoryRepo, productRepo}
+}
+
+// AdjustStock posts a manual correction. A stock take records the difference
+// between the counted quantity and the current stock.
+func (s *inventoryService) AdjustStock(adminID string, req dto.StockAdjustmentRequest) (*dto.StockMovementResponse, error) {
+	aid, _ := uuid.Parse(adminID)
+
+	productID, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		return nil, errors.New("invalid product ID")
+	}
+	var variantID *uuid.UUID
+	if req.VariantID != nil && *req.VariantID != "" {
+		id, err := uuid.Parse(*req.VariantID)
+		if err != nil {
+			return nil, errors.New("invalid variant ID")
+		}
+		variantID = &id
+	}
+
+	if req.Type == StockAdjustment && req.Quantity == 0 {
+		return nil, errors.New("adjustment quantity cannot be zero")
+	}
+	if req.Type == StockTake && req.Quantity < 0 {
+		return nil, errors.New("counted stock cannot be negative")
+	}
+
+	product, err := s.productRepo.GetProductByID(productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	if len(product.Variants) > 0 && variantID == nil {
+		return nil, ErrVariantRequired
+	}
+
+	movement := &models.StockMovement{
+		ProductID: productID,
+		VariantID: variantID,
+		Type:      req.Type,
+		Quantity:  req.Quantity,
+		ActorType: ActorAdmin,
+		ActorID:   &aid,
+		Reason:    req.Reason,
+	}
+
+	err = s.productRepo.WithTx(func(tx *gorm.DB) error {
+		productRepo := repositories.NewProductRepository(tx)
+
+		// ** kunci baris supaya hitungan stock take tidak balapan dengan checkout
+		products, err := productRepo.LockProductsForUpdate([]uuid.UUID{productID})
+		if err != nil || len(products) == 0 {
+			return errors.New("product not found")
+		}
+		current := products[0].Stock
+
+		if variantID != nil {
+			variants, err := productRepo.LockVariantsForUpdate([]uuid.UUID{*variantID})
+			if err != nil || len(variants) == 0 || variants[0].ProductID != productID {
+				return ErrVariantUnavailable
+			}
+			current = variants[0].Stock
+		}
+
+		if req.Type == StockTake {
+			movement.Quantity = req.Quantity - current
+			if movement.Quantity == 0 {
+				return nil
+			}
+		}
+		if current+movement.Quantity < 0 {
+			return fmt.Errorf("adjustment would make stock negative, current stock is %d", current)
+		}
+
+		return productRepo.MoveStock(movement)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// stock take tanpa selisih tidak dicatat
+	if movement.ID == uuid.Nil {
+		return nil, nil
+	}
+
+	movement.Product = *product
+	if variantID != nil {
+		for i := range product.Variants {
+			if product.Variants[i].ID == *variantID {
+				movement.Variant = &product.Variants[i]
+			}
+		}
+	}
+	response := toStockMovementResponse(movement)
+	return &response, nil
+}
+
+func (s *inventoryService) GetMovements(param dto.StockMovementQueryParam) ([]dto.StockMovementResponse, *dto.PaginationResponse, error) {
+	if param.Page <= 0 {
+		param.Page = 1
+	}
+	if param.Limit <= 0 {
+		param.Limit = 10
+	}
+
+	movements, total, err := s.inventoryRepo.GetMovements(param)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]dto.StockMovementResponse, 0, len(movements))
+	for i := range movements {
+		result = append(result, toStockMovementResponse(&movements[i]))
+	}
+
+	totalPages := int((total + int64(param.Limit) - 1) / int64(param.Limit))
+	pagination := &dto.PaginationResponse{
+		Page:       param.Page,
+		Limit:      param.Limit,
+		TotalRows:  int(total),
+		TotalPages: totalPages,
+	}
+
+	return result, pagination, nil
+}
+
+// CheckConsistency lists every product and variant whose stock no longer matches its ledger
+func (s *inventoryService) CheckConsistency() (*dto.StockConsistencyResponse, error) {
+	products, err := s.inventoryRepo.GetProductMismatches()
+	if err != nil {
+		return nil, err
+	}
+	variants, err := s.inventoryRepo.GetVariantMismatches()
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := append(products, variants...)
+	if mismatches == nil {
+		mismatches = []dto.StockMismatch{}
+	}
+
+	return &dto.StockConsistencyResponse{
+		Consistent: len(mismatches) == 0,
+		Mismatches: mismatches,
+	}, nil
+}
+
+// moveOrderStock writes one movement per order item, sale takes stock out and
+// cancel puts it back. Pass a tx-scoped repository to make it part of the order change.
+func moveOrderStock(productRepo repositories.ProductRepository, order *models.Order, items []models.OrderItem, movementType string, actor OrderActor, reason string) error {
+	for _, item := range items {
+		qty := item.Quantity
+		if movementType == StockSale {
+			qty = -qty
+		}
+
+		if err := productRepo.MoveStock(&models.StockMovement{
+			ProductID:     item.ProductID,
+			VariantID:     item.VariantID,
+			Type:          movementType,
+			Quantity:      qty,
+			ReferenceType: "order",
+			ReferenceID:   &order.ID,
+			ActorType:     actor.Type,
+			ActorID:       actor.ID,
+			Reason:        reason,
+		}); err != nil {
+			return fmt.Errorf("failed to move stock for %s: %w", item.ProductName, err)
+		}
+	}
+	return nil
+}
+
+func toStockMovementResponse(m *models.StockMovement) dto.StockMovementResponse {
+	response := dto.StockMovementResponse{
+		ID:            m.ID.String(),
+		ProductID:     m.ProductID.String(),
+		ProductName:   m.Product.Name,
+		Type:          m.Type,
+		Quantity:      m.Quantity,
+		StockAfter:    m.StockAfter,
+		ReferenceType: m.ReferenceType,
+		ActorType:     m.ActorType,
+		Reason:        m.Reason,
+		CreatedAt:     m.CreatedAt,
+	}
+	if m.VariantID != nil {
+		response.VariantID = m.VariantID.String()
+	}
+	if m.Variant != nil {
+		response.SKU = m.Variant.SKU
+	}
+	if m.ReferenceID != nil {
+		response.ReferenceID = m.ReferenceID.String()
+	}
+	if m.ActorID != nil {
+		response.ActorID = m.ActorID.String()
+	}
+	return response
+}
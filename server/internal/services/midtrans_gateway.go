@@ -0,0 +1,217 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"server/internal/dto"
+	"server/internal/pricing"
+	"server/internal/utils"
+
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
+	"github.com/midtrans/midtrans-go/snap"
+)
+
+// MidtransSnapClient is the subset of snap.Client used to create a charge
+type MidtransSnapClient interface {
+	CreateTransaction(req *snap.Request) (*snap.Response, *midtrans.Error)
+}
+
+// MidtransCoreClient is the subset of coreapi.Client used after a charge is created.
+// config.CoreClient satisfies it, tests can pass a fake.
+type MidtransCoreClient interface {
+	CheckTransaction(param string) (*coreapi.TransactionStatusResponse, *midtrans.Error)
+	CancelTransaction(param string) (*coreapi.CancelResponse, *midtrans.Error)
+	RefundTransaction(param string, req *coreapi.RefundReq) (*coreapi.RefundResponse, *midtrans.Error)
+}
+
+type MidtransGateway struct {
+	serverKey     string
+	snap          MidtransSnapClient
+	core          MidtransCoreClient
+	confirmStatus bool
+}
+
+// confirmStatus re-queries CoreAPI before trusting a webhook payload
+func NewMidtransGateway(serverKey string, snapClient MidtransSnapClient, coreClient MidtransCoreClient, confirmStatus bool) *MidtransGateway {
+	return &MidtransGateway{serverKey, snapClient, coreClient, confirmStatus}
+}
+
+func (g *MidtransGateway) Name() string { return GatewayMidtrans }
+
+func (g *MidtransGateway) CreateCharge(req ChargeRequest) (*ChargeResult, error) {
+	resp, mErr := g.snap.CreateTransaction(buildSnapRequest(req))
+	if mErr != nil {
+		return nil, fmt.Errorf("failed to create payment transaction: %s", mErr.GetMessage())
+	}
+	return &ChargeResult{Token: resp.Token, RedirectURL: resp.RedirectURL}, nil
+}
+
+func (g *MidtransGateway) ParseNotification(body []byte) (*PaymentStatus, error) {
+	var req dto.MidtransNotificationRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, ErrInvalidNotification
+	}
+	if req.OrderID == "" || req.StatusCode == "" || req.GrossAmount == "" || req.SignatureKey == "" {
+		return nil, ErrInvalidNotification
+	}
+
+	if !utils.VerifyMidtransSignature(req.OrderID, req.StatusCode, req.GrossAmount, g.serverKey, req.SignatureKey) {
+		return nil, ErrInvalidSignature
+	}
+
+	if !g.confirmStatus {
+		return midtransStatus(req.OrderID, req.TransactionStatus, req.FraudStatus, req.PaymentType, req.GrossAmount), nil
+	}
+
+	status, err := g.QueryStatus(req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if status.GrossAmount != req.GrossAmount {
+		return nil, fmt.Errorf("transaction status mismatch for order %s", req.OrderID)
+	}
+	if status.PaymentType == "" {
+		status.PaymentType = req.PaymentType
+	}
+
+	return status, nil
+}
+
+func (g *MidtransGateway) QueryStatus(orderID string) (*PaymentStatus, error) {
+	status, mErr := g.core.CheckTransaction(orderID)
+	if mErr != nil && mErr.GetStatusCode() == 404 {
+		return nil, ErrTransactionNotFound
+	}
+	if mErr != nil {
+		return nil, fmt.Errorf("failed to confirm transaction status: %s", mErr.GetMessage())
+	}
+
+	if status.OrderID != orderID {
+		return nil, fmt.Errorf("transaction status mismatch for order %s", orderID)
+	}
+
+	return midtransStatus(status.OrderID, status.TransactionStatus, status.FraudStatus, status.PaymentType, status.GrossAmount), nil
+}
+
+func (g *MidtransGateway) CancelCharge(orderID string) error {
+	if _, mErr := g.core.CancelTransaction(orderID); mErr != nil {
+		return errors.New(mErr.GetMessage())
+	}
+	return nil
+}
+
+func (g *MidtransGateway) Refund(orderID, refundKey string, amount int64, reason string) (*RefundResult, error) {
+	resp, mErr := g.core.RefundTransaction(orderID, &coreapi.RefundReq{
+		RefundKey: refundKey,
+		Amount:    amount,
+		Reason:    reason,
+	})
+	if mErr != nil {
+		return nil, fmt.Errorf("midtrans refund failed: %s", mErr.GetMessage())
+	}
+
+	// ** midtrans balas 200 walau refund ditolak, cek status_code dari body
+	if resp.StatusCode != "200" && resp.StatusCode != "201" {
+		return nil, fmt.Errorf("midtrans refund rejected: %s", resp.StatusMessage)
+	}
+
+	reference := resp.RefundKey
+	if reference == "" {
+		reference = refundKey
+	}
+
+	return &RefundResult{Reference: reference, Amount: amount}, nil
+}
+
+func midtransStatus(orderID, transactionStatus, fraudStatus, paymentType, grossAmount string) *PaymentStatus {
+	status := "failed"
+	switch transactionStatus {
+	case "settlement", "capture":
+		if fraudStatus == "accept" || fraudStatus == "" {
+			status = "success"
+		}
+	case "pending":
+		status = "pending"
+	case "refund", "partial_refund":
+		status = "refunded"
+	}
+
+	return &PaymentStatus{
+		OrderID:     orderID,
+		Status:      status,
+		RawStatus:   transactionStatus,
+		PaymentType: paymentType,
+		GrossAmount: grossAmount,
+	}
+}
+
+func buildSnapRequest(req ChargeRequest) *snap.Request {
+	order, user, address := req.Order, req.Customer, req.Address
+
+	var itemDetails []midtrans.ItemDetails
+	for _, item := range req.Items {
+		id, name := item.ProductID.String(), item.ProductName
+		if item.SKU != "" {
+			id = item.SKU
+			name = fmt.Sprintf("%s (%s)", name, item.VariantName)
+		}
+		if len(name) > 64 {
+			name = name[:64]
+		}
+
+		itemDetails = append(itemDetails, midtrans.ItemDetails{
+			ID:    id,
+			Name:  name,
+			Price: pricing.FromFloat(item.Price).Int64(),
+			Qty:   int32(item.Quantity),
+		})
+	}
+
+	// ** semua nominal sudah dibulatkan oleh pricing, jumlah item sama dengan GrossAmt
+	if order.ShippingCost > 0 {
+		itemDetails = append(itemDetails, midtrans.ItemDetails{
+			ID:    "shipping",
+			Name:  fmt.Sprintf("Shipping via %s", order.Courier),
+			Price: pricing.FromFloat(order.ShippingCost).Int64(),
+			Qty:   1,
+		})
+	}
+	if discount := pricing.FromFloat(order.VoucherDiscount); discount > 0 {
+		itemDetails = append(itemDetails, midtrans.ItemDetails{
+			ID:    "discount",
+			Name:  "Voucher Discount",
+			Price: -discount.Int64(), // harus negatif!
+			Qty:   1,
+		})
+	}
+
+	if order.Tax > 0 {
+		itemDetails = append(itemDetails, midtrans.ItemDetails{
+			ID:    "tax",
+			Name:  "Tax (PPN)",
+			Price: pricing.FromFloat(order.Tax).Int64(),
+			Qty:   1,
+		})
+	}
+
+	return &snap.Request{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  order.ID.String(),
+			GrossAmt: int64(order.AmountToPay),
+		},
+		CustomerDetail: &midtrans.CustomerDetails{
+			FName: user.Profile.Fullname,
+			Email: user.Email,
+			Phone: address.Phone,
+		},
+		Items:        &itemDetails,
+		CustomField1: fmt.Sprintf("%s, %s, %s", address.Address, address.City, address.PostalCode),
+		EnabledPayments: []snap.SnapPaymentType{
+			snap.PaymentTypeGopay,
+			snap.PaymentTypeBankTransfer,
+			snap.PaymentTypeCreditCard,
+		},
+	}
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
+)
+
+const testServerKey = "SB-Mid-server-test"
+
+// fakeCoreClient answers CoreAPI calls from a fixed transaction status
+type fakeCoreClient struct {
+	status  *coreapi.TransactionStatusResponse
+	err     *midtrans.Error
+	refund  *coreapi.RefundResponse
+	checked []string
+}
+
+func (c *fakeCoreClient) CheckTransaction(param string) (*coreapi.TransactionStatusResponse, *midtrans.Error) {
+	c.checked = append(c.checked, param)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.status, nil
+}
+
+func (c *fakeCoreClient) CancelTransaction(param string) (*coreapi.CancelResponse, *midtrans.Error) {
+	return &coreapi.CancelResponse{}, c.err
+}
+
+func (c *fakeCoreClient) RefundTransaction(param string, req *coreapi.RefundReq) (*coreapi.RefundResponse, *midtrans.Error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.refund, nil
+}
+
+func signedNotification(t *testing.T, serverKey string, fields map[string]string) []byte {
+	t.Helper()
+	if _, ok := fields["signature_key"]; !ok {
+		sum := sha512.Sum512([]byte(fields["order_id"] + fields["status_code"] + fields["gross_amount"] + serverKey))
+		fields["signature_key"] = hex.EncodeToString(sum[:])
+	}
+	body, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func settlementFields() map[string]string {
+	return map[string]string{
+		"order_id":           "order-1",
+		"status_code":        "200",
+		"gross_amount":       "150000.00",
+		"transaction_status": "settlement",
+		"payment_type":       "gopay",
+	}
+}
+
+func TestMidtransParseNotificationSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    func(t *testing.T) []byte
+		want    string
+		wantErr error
+	}{
+		{
+			name: "valid signature",
+			body: func(t *testing.T) []byte { return signedNotification(t, testServerKey, settlementFields()) },
+			want: "success",
+		},
+		{
+			name: "signature is case insensitive",
+			body: func(t *testing.T) []byte {
+				f := settlementFields()
+				sum := sha512.Sum512([]byte(f["order_id"] + f["status_code"] + f["gross_amount"] + testServerKey))
+				f["signature_key"] = strings.ToUpper(hex.EncodeToString(sum[:]))
+				return signedNotification(t, testServerKey, f)
+			},
+			want: "success",
+		},
+		{
+			name:    "signed with another server key",
+			body:    func(t *testing.T) []byte { return signedNotification(t, "another-key", settlementFields()) },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "amount changed after signing",
+			body: func(t *testing.T) []byte {
+				var f map[string]string
+				json.Unmarshal(signedNotification(t, testServerKey, settlementFields()), &f)
+				f["gross_amount"] = "1.00"
+				return signedNotification(t, testServerKey, f)
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "missing signature",
+			body: func(t *testing.T) []byte {
+				f := settlementFields()
+				f["signature_key"] = ""
+				return signedNotification(t, testServerKey, f)
+			},
+			wantErr: ErrInvalidNotification,
+		},
+		{
+			name:    "not json",
+			body:    func(t *testing.T) []byte { return []byte("order_id=order-1") },
+			wantErr: ErrInvalidNotification,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &fakeCoreClient{}
+			gateway := NewMidtransGateway(testServerKey, nil, core, false)
+
+			status, err := gateway.ParseNotification(tt.body(t))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseNotification() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseNotification() error = %v", err)
+			}
+			if status.Status != tt.want || status.OrderID != "order-1" || status.PaymentType != "gopay" {
+				t.Errorf("ParseNotification() = %+v, want status %s", status, tt.want)
+			}
+			if len(core.checked) != 0 {
+				t.Errorf("CoreAPI queried %v without confirmStatus", core.checked)
+			}
+		})
+	}
+}
+
+func TestMidtransQueryStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  *coreapi.TransactionStatusResponse
+		err     *midtrans.Error
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "settlement",
+			status: &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "settlement", GrossAmount: "150000.00"},
+			want:   "success",
+		},
+		{
+			name:   "accepted capture",
+			status: &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "capture", FraudStatus: "accept"},
+			want:   "success",
+		},
+		{
+			name:   "capture challenged by fraud detection",
+			status: &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "capture", FraudStatus: "challenge"},
+			want:   "failed",
+		},
+		{
+			name:   "pending",
+			status: &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "pending"},
+			want:   "pending",
+		},
+		{
+			name:   "expired",
+			status: &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "expire"},
+			want:   "failed",
+		},
+		{
+			name:   "refund",
+			status: &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "refund"},
+			want:   "refunded",
+		},
+		{
+			name:   "partial refund",
+			status: &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "partial_refund"},
+			want:   "refunded",
+		},
+		{
+			name:    "unknown transaction maps to not found",
+			err:     &midtrans.Error{StatusCode: 404, Message: "Transaction doesn't exist."},
+			wantErr: ErrTransactionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := NewMidtransGateway(testServerKey, nil, &fakeCoreClient{status: tt.status, err: tt.err}, false)
+
+			status, err := gateway.QueryStatus("order-1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("QueryStatus() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("QueryStatus() error = %v", err)
+			}
+			if status.Status != tt.want || status.RawStatus != tt.status.TransactionStatus {
+				t.Errorf("QueryStatus() = %+v, want status %s", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestMidtransQueryStatusErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *coreapi.TransactionStatusResponse
+		err    *midtrans.Error
+	}{
+		{"server error is not not found", nil, &midtrans.Error{StatusCode: 500, Message: "internal error"}},
+		{"status of another order", &coreapi.TransactionStatusResponse{OrderID: "order-2", TransactionStatus: "settlement"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := NewMidtransGateway(testServerKey, nil, &fakeCoreClient{status: tt.status, err: tt.err}, false)
+
+			_, err := gateway.QueryStatus("order-1")
+			if err == nil || errors.Is(err, ErrTransactionNotFound) {
+				t.Fatalf("QueryStatus() error = %v, want a lookup error", err)
+			}
+		})
+	}
+}
+
+func TestMidtransConfirmStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          *coreapi.TransactionStatusResponse
+		err             *midtrans.Error
+		want            string
+		wantPaymentType string
+		wantErr         error
+	}{
+		{
+			name:            "gateway confirms settlement",
+			status:          &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "settlement", GrossAmount: "150000.00", PaymentType: "bank_transfer"},
+			want:            "success",
+			wantPaymentType: "bank_transfer",
+		},
+		{
+			name:            "gateway status wins over the webhook",
+			status:          &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "pending", GrossAmount: "150000.00"},
+			want:            "pending",
+			wantPaymentType: "gopay",
+		},
+		{
+			name:   "gateway amount differs from the webhook",
+			status: &coreapi.TransactionStatusResponse{OrderID: "order-1", TransactionStatus: "settlement", GrossAmount: "100000.00"},
+		},
+		{
+			name:    "transaction unknown to the gateway",
+			err:     &midtrans.Error{StatusCode: 404, Message: "Transaction doesn't exist."},
+			wantErr: ErrTransactionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &fakeCoreClient{status: tt.status, err: tt.err}
+			gateway := NewMidtransGateway(testServerKey, nil, core, true)
+
+			status, err := gateway.ParseNotification(signedNotification(t, testServerKey, settlementFields()))
+			if len(core.checked) != 1 || core.checked[0] != "order-1" {
+				t.Fatalf("CoreAPI queried %v, want order-1 once", core.checked)
+			}
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseNotification() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.want == "":
+				if err == nil {
+					t.Fatalf("ParseNotification() = %+v, want a mismatch error", status)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("ParseNotification() error = %v", err)
+				}
+				if status.Status != tt.want || status.PaymentType != tt.wantPaymentType {
+					t.Errorf("ParseNotification() = %+v, want status %s paid by %s", status, tt.want, tt.wantPaymentType)
+				}
+			}
+		})
+	}
+}
+
+func TestMidtransRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *coreapi.RefundResponse
+		err     *midtrans.Error
+		wantRef string
+	}{
+		{"accepted", &coreapi.RefundResponse{StatusCode: "200", RefundKey: "ref-1"}, nil, "ref-1"},
+		{"accepted without key", &coreapi.RefundResponse{StatusCode: "201"}, nil, "cancel-order-1"},
+		{"rejected in the body", &coreapi.RefundResponse{StatusCode: "412", StatusMessage: "cannot be refunded"}, nil, ""},
+		{"request failed", nil, &midtrans.Error{StatusCode: 500, Message: "internal error"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := NewMidtransGateway(testServerKey, nil, &fakeCoreClient{refund: tt.resp, err: tt.err}, false)
+
+			result, err := gateway.Refund("order-1", "cancel-order-1", 150000, "canceled")
+			if tt.wantRef == "" {
+				if err == nil {
+					t.Fatalf("Refund() = %+v, want an error", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Refund() error = %v", err)
+			}
+			if result.Reference != tt.wantRef || result.Amount != 150000 {
+				t.Errorf("Refund() = %+v, want reference %s", result, tt.wantRef)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"server/internal/dto"
 	"server/internal/models"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type NotificationService interface {
@@ -18,6 +20,7 @@ type NotificationService interface {
 	UpdateSetting(userID string, req dto.UpdateNotificationSettingRequest) error
 	GetSettingsByUser(userID string) ([]dto.NotificationSettingResponse, error)
 	SendToUser(req dto.NotificationEvent) error
+	SendToAdmins(req dto.NotificationEvent) error
 }
 
 type notificationService struct {
@@ -127,8 +130,15 @@ func (s *notificationService) SendToUser(req dto.NotificationEvent) error {
 	}
 
 	for _, channel := range []string{"browser"} {
+		// ? user lama belum punya setting untuk tipe yang ditambahkan belakangan
+		enabled := ntype.DefaultEnabled
 		setting, err := s.repo.FindSetting(uid, ntype.ID, channel)
-		if err != nil || !setting.Enabled {
+		if err == nil {
+			enabled = setting.Enabled
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if !enabled {
 			continue
 		}
 
@@ -136,7 +146,7 @@ func (s *notificationService) SendToUser(req dto.NotificationEvent) error {
 			ID:       uuid.New(),
 			UserID:   uid,
 			TypeCode: req.Type,
-			Title:    setting.NotificationType.Title,
+			Title:    ntype.Title,
 			Message:  req.Message,
 			Channel:  channel,
 		}
@@ -154,3 +164,18 @@ func (s *notificationService) SendToUser(req dto.NotificationEvent) error {
 
 	return nil
 }
+
+func (s *notificationService) SendToAdmins(req dto.NotificationEvent) error {
+	adminIDs, err := s.repo.GetUserIDsByRole("admin")
+	if err != nil {
+		return err
+	}
+
+	for _, id := range adminIDs {
+		req.UserID = id.String()
+		if err := s.SendToUser(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"server/internal/config"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/pricing"
+	"server/internal/repositories"
+	"server/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const quoteTTL = 15 * time.Minute
+
+var (
+	ErrQuoteInvalid  = errors.New("quote is invalid or expired, please request a new quote")
+	ErrQuoteOutdated = errors.New("cart, prices or shipping changed since the quote, please request a new quote")
+)
+
+// checkoutQuote is everything the customer pays for, calculated from database values only
+type checkoutQuote struct {
+	Address         *models.Address
+	Items           []models.OrderItem
+	Courier         string
+	Service         string
+	ETD             string
+	Weight          int
+	Subtotal        pricing.Money
+	VoucherCode     *string
+	VoucherDiscount pricing.Money
+	Total           pricing.Money // subtotal setelah voucher
+	ShippingCost    pricing.Money
+	Tax             pricing.Money
+	AmountToPay     pricing.Money
+	PaymentMethod   string
+}
+
+func (s *orderService) Quote(userID string, req dto.CheckoutQuoteRequest) (*dto.CheckoutQuoteResponse, error) {
+	uid, _ := uuid.Parse(userID)
+
+	method := req.PaymentMethod
+	if method == "" {
+		method = MethodGateway
+	}
+	if err := checkPaymentMethod(method); err != nil {
+		return nil, err
+	}
+
+	var addressID *uuid.UUID
+	if req.AddressID != nil {
+		id, _ := uuid.Parse(*req.AddressID)
+		addressID = &id
+	}
+	address, err := quoteAddress(s.orderRepo, uid, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	carts, err := s.orderRepo.GetUserCart(uid)
+	if err != nil || len(carts) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+	// ** mulai checkout menyesuaikan jumlah dengan stok yang tersisa lalu memperpanjang reservasi stok
+	err = s.orderRepo.WithTx(func(tx *gorm.DB) error {
+		if err := clampCartLines(repositories.NewCartRepository(tx), uid, carts); err != nil {
+			return err
+		}
+		if config.CartReservationEnabled {
+			return reserveCartLines(repositories.NewProductRepository(tx), repositories.NewReservationRepository(tx), uid, carts)
+		}
+		for _, c := range carts {
+			if c.Quantity > variantStock(&c.Product, c.Variant) {
+				return fmt.Errorf("stock not enough for product: %s", c.Product.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := s.calculateQuote(userID, carts, address, req.Courier, req.Service, req.VoucherCode, method)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := utils.GenerateQuoteToken(utils.QuoteClaims{
+		UserID:        userID,
+		AddressID:     address.ID.String(),
+		Courier:       quote.Courier,
+		Service:       quote.Service,
+		VoucherCode:   quote.VoucherCode,
+		PaymentMethod: method,
+		Digest:        quote.digest(),
+	}, quoteTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.CheckoutQuoteItemResponse, 0, len(quote.Items))
+	for _, i := range quote.Items {
+		item := dto.CheckoutQuoteItemResponse{
+			ProductID: i.ProductID.String(),
+			Name:      i.ProductName,
+			Variant:   i.VariantName,
+			Image:     i.Image,
+			Price:     i.Price,
+			Quantity:  i.Quantity,
+			Subtotal:  i.Subtotal,
+		}
+		if i.VariantID != nil {
+			item.VariantID = i.VariantID.String()
+		}
+		items = append(items, item)
+	}
+
+	return &dto.CheckoutQuoteResponse{
+		QuoteID:         token,
+		ExpiresAt:       expiresAt,
+		Items:           items,
+		Subtotal:        quote.Subtotal.Float(),
+		VoucherCode:     quote.VoucherCode,
+		VoucherDiscount: quote.VoucherDiscount.Float(),
+		Courier:         quote.Courier,
+		Service:         quote.Service,
+		ETD:             quote.ETD,
+		Weight:          quote.Weight,
+		ShippingCost:    quote.ShippingCost.Float(),
+		Tax:             quote.Tax.Float(),
+		AmountToPay:     quote.AmountToPay.Float(),
+		PaymentMethod:   method,
+	}, nil
+}
+
+// calculateQuote prices the checked cart lines, voucher, shipping and tax.
+// Checkout calls it again inside the transaction and compares digests.
+func (s *orderService) calculateQuote(userID string, carts []models.Cart, address *models.Address, courier, service string, voucherCode *string, method string) (*checkoutQuote, error) {
+	quote := &checkoutQuote{
+		Address:       address,
+		Courier:       strings.ToLower(courier),
+		Service:       strings.ToUpper(service),
+		PaymentMethod: method,
+	}
+
+	lines := make([]pricing.Line, 0, len(carts))
+	for _, c := range carts {
+		if c.VariantID != nil && c.Variant == nil {
+			return nil, fmt.Errorf("%s: %w", c.Product.Name, ErrVariantUnavailable)
+		}
+		if !cartLineAvailable(&c) {
+			return nil, ErrProductUnavailable
+		}
+
+		unit := pricing.PriceUnit(variantPrice(&c.Product, c.Variant), c.Product.Discount)
+		lines = append(lines, pricing.NewLine(c.ID.String(), unit, c.Quantity))
+		quote.Weight += int(variantWeight(&c.Product, c.Variant)) * c.Quantity
+	}
+
+	var voucher *pricing.Adjustment
+	if voucherCode != nil && *voucherCode != "" {
+		adjustment, err := s.voucherService.GetVoucherAdjustment(userID, *voucherCode)
+		if err != nil {
+			return nil, err
+		}
+		voucher = adjustment
+		quote.VoucherCode = voucherCode
+	}
+
+	rates, err := utils.EstimateShippingRates(
+		config.ShopOriginProvinceID,
+		config.ShopOriginCityID,
+		int(address.ProvinceID),
+		int(address.CityID),
+		quote.Weight,
+		quote.Courier,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var shipping *utils.ShippingOption
+	for i := range rates {
+		if rates[i].Service == quote.Service {
+			shipping = &rates[i]
+			break
+		}
+	}
+	if shipping == nil {
+		return nil, fmt.Errorf("shipping service %s %s is not available", courier, service)
+	}
+	quote.ETD = shipping.ETD
+
+	summary := pricing.Calculate(pricing.Order{
+		Lines:    lines,
+		Voucher:  voucher,
+		Shipping: pricing.FromFloat(shipping.Cost),
+		TaxRate:  utils.GetTaxRate(),
+	})
+
+	for i, c := range carts {
+		line := summary.Lines[i]
+		item := models.OrderItem{
+			ProductID:   c.Product.ID,
+			ProductName: c.Product.Name,
+			ProductSlug: c.Product.Slug,
+			Image:       variantImage(&c.Product, c.Variant),
+			Discount:    utils.ToPtr(line.Unit.Discount.Float()),
+			Price:       line.Unit.Net.Float(),
+			Quantity:    line.Quantity,
+			Subtotal:    line.Subtotal.Float(),
+		}
+		if c.Variant != nil {
+			item.VariantID = &c.Variant.ID
+			item.VariantName = variantLabel(c.Variant)
+			item.SKU = c.Variant.SKU
+		}
+		quote.Items = append(quote.Items, item)
+	}
+
+	quote.Subtotal = summary.Subtotal
+	quote.VoucherDiscount = summary.VoucherDiscount
+	quote.Total = summary.Total
+	quote.ShippingCost = summary.Shipping
+	quote.Tax = summary.Tax
+	quote.AmountToPay = summary.AmountToPay
+
+	return quote, nil
+}
+
+// digest fingerprints every line and amount so a signed quote cannot drift from the order
+func (q *checkoutQuote) digest() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%s|%s|%s|", q.Address.ID, q.Courier, q.Service, q.PaymentMethod)
+	if q.VoucherCode != nil {
+		b.WriteString(*q.VoucherCode)
+	}
+	for _, i := range q.Items {
+		variantID := ""
+		if i.VariantID != nil {
+			variantID = i.VariantID.String()
+		}
+		fmt.Fprintf(&b, "|%s:%s:%.0f:%d", i.ProductID, variantID, i.Price, i.Quantity)
+	}
+	fmt.Fprintf(&b, "|%d|%d|%d|%d|%d", q.Subtotal, q.VoucherDiscount, q.ShippingCost, q.Tax, q.AmountToPay)
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func quoteAddress(orderRepo repositories.OrderRepository, userID uuid.UUID, addressID *uuid.UUID) (*models.Address, error) {
+	if addressID == nil {
+		address, err := orderRepo.GetMainAddress(userID)
+		if err != nil {
+			return nil, errors.New("main address not found")
+		}
+		return address, nil
+	}
+
+	address, err := orderRepo.GetUserAddress(userID, *addressID)
+	if err != nil {
+		return nil, errors.New("address not found")
+	}
+	return address, nil
+}
+
+func checkPaymentMethod(method string) error {
+	if method == MethodManualTransfer && config.ManualTransferAccountNumber == "" {
+		return errors.New("manual bank transfer is not available")
+	}
+	return nil
+}
@@ -9,34 +9,37 @@ import (
 	"server/internal/models"
 	"server/internal/repositories"
 	"server/internal/utils"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/midtrans/midtrans-go"
-	"github.com/midtrans/midtrans-go/snap"
 	"gorm.io/gorm"
 )
 
 type OrderService interface {
 	GetAllOrders(userID string, role string, param dto.OrderQueryParam) ([]dto.OrderListResponse, *dto.PaginationResponse, error)
+	Quote(userID string, req dto.CheckoutQuoteRequest) (*dto.CheckoutQuoteResponse, error)
 	Checkout(userID string, req dto.CheckoutRequest) (*dto.CheckoutResponse, error)
 	GetOrderDetail(orderID string) (*dto.OrderDetailResponse, error)
-	CreateShipment(orderID string, req dto.CreateShipmentRequest) (*dto.ShipmentResponse, error)
+	CreateShipment(adminID, orderID string, req dto.CreateShipmentRequest) (*dto.ShipmentResponse, error)
 	GetShipmentByOrderID(orderID string) (*dto.ShipmentResponse, error)
-	ConfirmOrderDelivered(orderID string) (*dto.ConfirmDeliveryResponse, error)
+	ConfirmOrderDelivered(adminID, orderID string) (*dto.ConfirmDeliveryResponse, error)
+	CancelOrder(userID, orderID string) (*dto.CancelOrderResponse, error)
+	UpdateOrderStatus(adminID, orderID string, req dto.UpdateOrderStatusRequest) error
+	GetOrderHistory(userID, role, orderID string) ([]dto.OrderStatusHistoryResponse, error)
 }
 
 type orderService struct {
 	orderRepo           repositories.OrderRepository
 	paymentRepo         repositories.PaymentRepository
 	authRepo            repositories.AuthRepository
-	productRepo         repositories.ProductRepository
 	voucherService      VoucherService
 	notificationService NotificationService
+	gateway             PaymentGateway
 }
 
-func NewOrderService(orderRepo repositories.OrderRepository, paymentRepo repositories.PaymentRepository, authRepo repositories.AuthRepository, productRepo repositories.ProductRepository, voucherService VoucherService, notificationService NotificationService) OrderService {
-	return &orderService{orderRepo, paymentRepo, authRepo, productRepo, voucherService, notificationService}
+func NewOrderService(orderRepo repositories.OrderRepository, paymentRepo repositories.PaymentRepository, authRepo repositories.AuthRepository, voucherService VoucherService, notificationService NotificationService, gateway PaymentGateway) OrderService {
+	return &orderService{orderRepo, paymentRepo, authRepo, voucherService, notificationService, gateway}
 }
 
 func (s *orderService) Checkout(userID string, req dto.CheckoutRequest) (*dto.CheckoutResponse, error) {
@@ -47,213 +50,262 @@ func (s *orderService) Checkout(userID string, req dto.CheckoutRequest) (*dto.Ch
 		return nil, errors.New("user not found")
 	}
 
-	carts, err := s.orderRepo.GetUserCart(uid)
-	if err != nil || len(carts) == 0 {
-		return nil, errors.New("cart is empty")
+	claims, err := utils.DecodeQuoteToken(req.QuoteID)
+	if err != nil || claims.UserID != userID {
+		return nil, ErrQuoteInvalid
 	}
 
-	address, err := s.orderRepo.GetMainAddress(uid)
+	method := claims.PaymentMethod
+	if err := checkPaymentMethod(method); err != nil {
+		return nil, err
+	}
+
+	addressID, err := uuid.Parse(claims.AddressID)
 	if err != nil {
-		return nil, errors.New("main address not found")
+		return nil, ErrQuoteInvalid
+	}
+	address, err := quoteAddress(s.orderRepo, uid, &addressID)
+	if err != nil {
+		return nil, err
 	}
 
-	var total float64
-	var items []models.OrderItem
-	for _, c := range carts {
-		if c.Quantity > c.Product.Stock {
-			return nil, fmt.Errorf("stock not enough for product: %s", c.Product.Name)
-		}
+	// COD tidak perlu menunggu pembayaran, langsung bisa diproses admin
+	initialStatus := OrderWaitingPayment
+	paymentMethod := method
+	if method == MethodCOD {
+		initialStatus = OrderPending
+	}
+	if method == MethodGateway {
+		paymentMethod = s.gateway.Name()
+	}
+
+	var (
+		order   *models.Order
+		payment *models.Payment
+		charge  = &ChargeResult{}
+	)
 
-		image := ""
-		if len(c.Product.ProductGallery) > 0 {
-			image = c.Product.ProductGallery[0].Image
+	// ** seluruh proses checkout berjalan dalam satu transaksi, gagal di tengah = rollback
+	err = s.orderRepo.WithTx(func(tx *gorm.DB) error {
+		orderRepo := repositories.NewOrderRepository(tx)
+		productRepo := repositories.NewProductRepository(tx)
+		paymentRepo := repositories.NewPaymentRepository(tx)
+		reservationRepo := repositories.NewReservationRepository(tx)
+		voucherService := NewVoucherService(repositories.NewVoucherRepository(tx))
+
+		carts, err := orderRepo.GetUserCart(uid)
+		if err != nil || len(carts) == 0 {
+			return errors.New("cart is empty")
 		}
 
-		price := c.Product.Price
-		if c.Product.Discount != nil && *c.Product.Discount > 0 {
-			price -= *c.Product.Discount
-			if price < 0 {
-				price = 0
+		var productIDs, variantIDs []uuid.UUID
+		for _, c := range carts {
+			productIDs = append(productIDs, c.ProductID)
+			if c.VariantID != nil {
+				variantIDs = append(variantIDs, *c.VariantID)
 			}
 		}
 
-		subtotal := price * float64(c.Quantity)
-		total += subtotal
-
-		items = append(items, models.OrderItem{
-			ProductID:   c.Product.ID,
-			ProductName: c.Product.Name,
-			ProductSlug: c.Product.Slug,
-			Image:       image,
-			Price:       price,
-			Quantity:    c.Quantity,
-			Subtotal:    subtotal,
-		})
-	}
+		reservations, err := reservationRepo.GetUserReservations(uid)
+		if err != nil {
+			return err
+		}
 
-	var voucherDiscount float64
-	if req.VoucherCode != nil {
-		apply, err := s.voucherService.ApplyVoucher(userID, dto.ApplyVoucherRequest{
-			Code:  *req.VoucherCode,
-			Total: total,
-		})
-		if err == nil {
-			total = apply.FinalTotal
-			voucherDiscount = apply.DiscountValue
+		lockedProducts, err := productRepo.LockProductsForUpdate(productIDs)
+		if err != nil {
+			return err
+		}
+		lockedVariants, err := productRepo.LockVariantsForUpdate(variantIDs)
+		if err != nil {
+			return err
 		}
-	}
 
-	taxRate := utils.GetTaxRate()
-	tax := total * taxRate
-	amountToPay := total + req.ShippingCost + tax
+		stockByProduct := make(map[uuid.UUID]int, len(lockedProducts))
+		for _, p := range lockedProducts {
+			stockByProduct[p.ID] = p.Stock
+		}
+		// ** produk bervarian dicek per varian
+		stockByVariant := make(map[uuid.UUID]int, len(lockedVariants))
+		for _, v := range lockedVariants {
+			if v.IsActive {
+				stockByVariant[v.ID] = v.Stock
+			}
+		}
 
-	orderID := uuid.New()
-	invoice := utils.GenerateInvoiceNumber(orderID)
+		// ** stok yang ditahan keranjang customer lain tidak boleh ikut terjual
+		reservedByProduct, reservedByVariant := map[uuid.UUID]int{}, map[uuid.UUID]int{}
+		if config.CartReservationEnabled {
+			reservedByProduct, reservedByVariant, err = reservationRepo.GetReservedQuantities(productIDs)
+			if err != nil {
+				return err
+			}
+			for _, r := range reservations {
+				reservedByProduct[r.ProductID] -= r.Quantity
+				if r.VariantID != nil {
+					reservedByVariant[*r.VariantID] -= r.Quantity
+				}
+			}
+		}
 
-	order := &models.Order{
-		ID:              orderID,
-		InvoiceNumber:   invoice,
-		UserID:          uid,
-		Courier:         req.Courier,
-		RecipientName:   user.Profile.Fullname,
-		ShippingCost:    req.ShippingCost,
-		ShippingAddress: fmt.Sprintf("%s, %s, %s, %s, %s", address.Address, address.Province, address.City, address.District, address.PostalCode),
-		Tax:             tax,
-		Note:            req.Note,
-		Total:           total,
-		AmountToPay:     amountToPay,
-		VoucherCode:     req.VoucherCode,
-		VoucherDiscount: voucherDiscount,
-		Status:          "waiting_payment",
-	}
+		for _, c := range carts {
+			stock, ok := stockByProduct[c.ProductID]
+			reserved := reservedByProduct[c.ProductID]
+			if c.VariantID != nil {
+				stock, ok = stockByVariant[*c.VariantID]
+				reserved = reservedByVariant[*c.VariantID]
+			}
+			if !ok || c.Quantity > availableStock(stock, reserved) {
+				return fmt.Errorf("stock not enough for product: %s", c.Product.Name)
+			}
+		}
 
-	if err := s.orderRepo.CreateOrder(order); err != nil {
-		return nil, err
-	}
+		// ** hitung ulang di dalam transaksi, harus sama persis dengan quote yang ditandatangani
+		quote, err := s.calculateQuote(userID, carts, address, claims.Courier, claims.Service, claims.VoucherCode, method)
+		if err != nil {
+			return err
+		}
+		if quote.digest() != claims.Digest {
+			return ErrQuoteOutdated
+		}
+		items := quote.Items
+
+		orderID := uuid.New()
+		order = &models.Order{
+			ID:              orderID,
+			InvoiceNumber:   utils.GenerateInvoiceNumber(orderID),
+			UserID:          uid,
+			Courier:         fmt.Sprintf("%s %s", strings.ToUpper(quote.Courier), quote.Service),
+			RecipientName:   user.Profile.Fullname,
+			Phone:           address.Phone,
+			ShippingCost:    quote.ShippingCost.Float(),
+			ShippingAddress: fmt.Sprintf("%s, %s, %s, %s, %s", address.Address, address.Province, address.City, address.District, address.PostalCode),
+			Tax:             quote.Tax.Float(),
+			Note:            req.Note,
+			Total:           quote.Total.Float(),
+			AmountToPay:     quote.AmountToPay.Float(),
+			VoucherCode:     quote.VoucherCode,
+			VoucherDiscount: quote.VoucherDiscount.Float(),
+			Status:          initialStatus,
+		}
 
-	for i := range items {
-		items[i].OrderID = order.ID
-	}
-	if err := s.orderRepo.CreateOrderItems(items); err != nil {
-		return nil, err
-	}
+		if err := orderRepo.CreateOrder(order); err != nil {
+			return err
+		}
 
-	for _, c := range carts {
-		if err := s.productRepo.DecreaseProductStock(c.ProductID, c.Quantity); err != nil {
-			return nil, fmt.Errorf("failed to decrease stock for %s", c.Product.Name)
+		if err := RecordInitialStatus(orderRepo, order, CustomerActor(uid)); err != nil {
+			return err
 		}
-	}
 
-	if err := s.orderRepo.ClearUserCart(uid); err != nil {
-		return nil, err
-	}
+		for i := range items {
+			items[i].OrderID = order.ID
+		}
+		if err := orderRepo.CreateOrderItems(items); err != nil {
+			return err
+		}
 
-	paymentID := uuid.New()
-	payment := models.Payment{
-		ID:       paymentID,
-		UserID:   uid,
-		Fullname: user.Profile.Fullname,
-		Email:    user.Email,
-		OrderID:  orderID,
-		Method:   "midtrans",
-		Status:   "pending",
-		PaidAt:   time.Time{},
-		Total:    amountToPay,
-	}
+		// ? stok dipotong saat order dibuat dan dikembalikan jika pembayaran gagal,
+		// jadi reservasi berubah menjadi pengurangan stok nyata di sini
+		if err := moveOrderStock(productRepo, order, items, StockSale, CustomerActor(uid), "checkout"); err != nil {
+			return err
+		}
+		for _, c := range carts {
+			if err := reservationRepo.Release(uid, c.ProductID, c.VariantID); err != nil {
+				return err
+			}
+		}
 
-	if err := s.paymentRepo.CreatePayment(&payment); err != nil {
-		return nil, err
-	}
+		if err := orderRepo.ClearUserCart(uid); err != nil {
+			return err
+		}
 
-	if order.VoucherCode != nil && *order.VoucherCode != "" {
-		if err := s.voucherService.DecreaseQuota(order.UserID, *order.VoucherCode); err != nil {
-			return nil, fmt.Errorf("failed to decrease voucher quota")
+		payment = &models.Payment{
+			ID:       uuid.New(),
+			UserID:   uid,
+			Fullname: user.Profile.Fullname,
+			Email:    user.Email,
+			OrderID:  orderID,
+			Method:   paymentMethod,
+			Status:   "pending",
+			PaidAt:   time.Time{},
+			Total:    quote.AmountToPay.Float(),
 		}
-	}
 
-	var itemDetails []midtrans.ItemDetails
-	for _, item := range items {
-		name := item.ProductName
-		if len(name) > 64 {
-			name = name[:64]
+		if err := paymentRepo.CreatePayment(payment); err != nil {
+			return err
 		}
 
-		itemDetails = append(itemDetails, midtrans.ItemDetails{
-			ID:    item.ProductID.String(),
-			Name:  name,
-			Price: int64(item.Price),
-			Qty:   int32(item.Quantity),
-		})
-	}
+		if quote.VoucherCode != nil && *quote.VoucherCode != "" {
+			if err := voucherService.DecreaseQuota(uid, *quote.VoucherCode); err != nil {
+				return fmt.Errorf("failed to decrease voucher quota: %w", err)
+			}
+		}
 
-	if req.ShippingCost > 0 {
-		itemDetails = append(itemDetails, midtrans.ItemDetails{
-			ID:    "shipping",
-			Name:  fmt.Sprintf("Shipping via %s", order.Courier),
-			Price: int64(req.ShippingCost),
-			Qty:   1,
-		})
-	}
-	if int64(voucherDiscount) > 0 {
-		itemDetails = append(itemDetails, midtrans.ItemDetails{
-			ID:    "discount",
-			Name:  "Voucher Discount",
-			Price: -int64(voucherDiscount), // harus negatif!
-			Qty:   1,
-		})
+		order.Items = items
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if tax > 0 {
-		itemDetails = append(itemDetails, midtrans.ItemDetails{
-			ID:    "tax",
-			Name:  "Tax (PPN)",
-			Price: int64(tax),
-			Qty:   1,
+	// ** charge dibuat setelah commit supaya panggilan ke gateway tidak menahan lock stok,
+	// kalau gagal order langsung dibatalkan dan stok serta voucher dikembalikan
+	if method == MethodGateway {
+		charge, err = s.gateway.CreateCharge(ChargeRequest{
+			Order:    order,
+			Items:    order.Items,
+			Customer: user,
+			Address:  address,
 		})
-	}
+		if err != nil {
+			if cancelErr := failUnpaidOrder(s.orderRepo, order, SystemActor(), "payment charge failed"); cancelErr != nil {
+				log.Printf("Fail to cancel order %s after the charge failed: %v\n", order.ID, cancelErr)
+			}
+			return nil, fmt.Errorf("failed to create payment: %w", err)
+		}
 
-	snapRequest := &snap.Request{
-		TransactionDetails: midtrans.TransactionDetails{
-			OrderID:  payment.OrderID.String(),
-			GrossAmt: int64(amountToPay),
-		},
-		CustomerDetail: &midtrans.CustomerDetails{
-			FName: user.Profile.Fullname,
-			Email: user.Email,
-			Phone: address.Phone,
-		},
-		Items:        &itemDetails,
-		CustomField1: fmt.Sprintf("%s, %s, %s", address.Address, address.City, address.PostalCode),
-		EnabledPayments: []snap.SnapPaymentType{
-			snap.PaymentTypeGopay,
-			snap.PaymentTypeBankTransfer,
-			snap.PaymentTypeCreditCard,
-		},
-	}
-
-	snapResp, err := config.SnapClient.CreateTransaction(snapRequest)
-
-	order.PaymentLink = snapResp.RedirectURL
-	if err := s.orderRepo.UpdateOrder(order); err != nil {
-		return nil, err
+		order.PaymentLink = charge.RedirectURL
+		if err := s.orderRepo.SetPaymentLink(order.ID, order.PaymentLink); err != nil {
+			log.Printf("Fail to save payment link of order %s: %v\n", order.ID, err)
+		}
 	}
 
-	err = s.notificationService.SendToUser(dto.NotificationEvent{
+	notification := dto.NotificationEvent{
 		UserID: user.ID.String(),
 		Type:   "pending_payment",
 		Title:  "Order Created",
 		Message: fmt.Sprintf("Thank you %s, your order with invoice no. %s is created. Please complete your payment.",
-			user.Profile.Fullname, invoice),
-	})
+			user.Profile.Fullname, order.InvoiceNumber),
+	}
+	if method == MethodCOD {
+		notification.Type = "order_processed"
+		notification.Message = fmt.Sprintf("Thank you %s, your order with invoice no. %s is created. Please pay the courier on delivery.",
+			user.Profile.Fullname, order.InvoiceNumber)
+	}
+
+	err = s.notificationService.SendToUser(notification)
 	if err != nil {
 		log.Printf("Fail to send notification to user %s: %v\n", user.ID.String(), err)
 	}
 
-	return &dto.CheckoutResponse{
-		PaymentID: paymentID.String(),
-		SnapToken: snapResp.Token,
-		SnapURL:   snapResp.RedirectURL,
-	}, nil
+	resp := &dto.CheckoutResponse{
+		PaymentID:     payment.ID.String(),
+		SnapToken:     charge.Token,
+		SnapURL:       charge.RedirectURL,
+		PaymentMethod: method,
+	}
+	switch method {
+	case MethodGateway:
+		resp.Gateway = s.gateway.Name()
+	case MethodManualTransfer:
+		resp.BankAccount = &dto.BankAccountResponse{
+			Bank:          config.ManualTransferBank,
+			AccountNumber: config.ManualTransferAccountNumber,
+			AccountName:   config.ManualTransferAccountName,
+			Amount:        order.AmountToPay,
+		}
+	}
+
+	return resp, nil
 }
 
 func (s *orderService) GetAllOrders(userID string, role string, param dto.OrderQueryParam) ([]dto.OrderListResponse, *dto.PaginationResponse, error) {
@@ -283,6 +335,7 @@ func (s *orderService) GetAllOrders(userID string, role string, param dto.OrderQ
 			items = append(items, dto.ItemsResponse{
 				ProductID:   i.ProductID.String(),
 				ProductName: i.ProductName,
+				Variant:     i.VariantName,
 				Image:       i.Image,
 				Quantity:    i.Quantity,
 			})
@@ -319,12 +372,19 @@ func (s *orderService) GetOrderDetail(orderID string) (*dto.OrderDetailResponse,
 
 	var items []dto.ItemsDetailResponse
 	for _, i := range order.Items {
+		var discount float64
+		if i.Discount != nil {
+			discount = *i.Discount
+		}
 		items = append(items, dto.ItemsDetailResponse{
 			ItemID:      i.ID.String(),
 			ProductName: i.ProductName,
 			ProductSlug: i.ProductSlug,
+			Variant:     i.VariantName,
+			SKU:         i.SKU,
 			Image:       i.Image,
 			Price:       i.Price,
+			Discount:    discount,
 			IsReviewed:  i.IsReviewed,
 			Quantity:    i.Quantity,
 			Subtotal:    i.Subtotal,
@@ -348,13 +408,14 @@ func (s *orderService) GetOrderDetail(orderID string) (*dto.OrderDetailResponse,
 		VoucherDiscount: order.VoucherDiscount,
 		Tax:             order.Tax,
 		AmountToPay:     order.AmountToPay,
+		RefundedAmount:  order.RefundedAmount,
 		CreatedAt:       order.CreatedAt,
 		Items:           items,
 	}, nil
 
 }
 
-func (s *orderService) CreateShipment(orderID string, req dto.CreateShipmentRequest) (*dto.ShipmentResponse, error) {
+func (s *orderService) CreateShipment(adminID, orderID string, req dto.CreateShipmentRequest) (*dto.ShipmentResponse, error) {
 	id, err := uuid.Parse(orderID)
 	if err != nil {
 		return nil, errors.New("invalid order ID")
@@ -365,6 +426,12 @@ func (s *orderService) CreateShipment(orderID string, req dto.CreateShipmentRequ
 		return nil, errors.New("order not found")
 	}
 
+	if order.Status != OrderPending && order.Status != OrderProcess {
+		return nil, fmt.Errorf("%w: %s → %s", ErrIllegalTransition, order.Status, OrderShipped)
+	}
+
+	actor := AdminActor(uuid.MustParse(adminID))
+
 	now := time.Now()
 	shipment := &models.Shipment{
 		ID:           uuid.New(),
@@ -376,12 +443,18 @@ func (s *orderService) CreateShipment(orderID string, req dto.CreateShipmentRequ
 	}
 
 	err = s.orderRepo.WithTx(func(tx *gorm.DB) error {
-		if err := tx.Create(shipment).Error; err != nil {
+		orderRepo := repositories.NewOrderRepository(tx)
+		if err := orderRepo.CreateShipment(shipment); err != nil {
 			return err
 		}
-		return tx.Model(&models.Order{}).
-			Where("id = ?", id).
-			Update("status", "success").Error
+
+		// pesanan yang belum diproses otomatis melewati status process
+		if order.Status == OrderPending {
+			if err := TransitionOrder(orderRepo, order, OrderProcess, actor, "shipment created"); err != nil {
+				return err
+			}
+		}
+		return TransitionOrder(orderRepo, order, OrderShipped, actor, "shipment created")
 	})
 	if err != nil {
 		return nil, err
@@ -432,7 +505,7 @@ func (s *orderService) GetShipmentByOrderID(orderID string) (*dto.ShipmentRespon
 	}, nil
 }
 
-func (s *orderService) ConfirmOrderDelivered(orderID string) (*dto.ConfirmDeliveryResponse, error) {
+func (s *orderService) ConfirmOrderDelivered(adminID, orderID string) (*dto.ConfirmDeliveryResponse, error) {
 	id, err := uuid.Parse(orderID)
 	if err != nil {
 		return nil, errors.New("invalid order ID")
@@ -447,7 +520,18 @@ func (s *orderService) ConfirmOrderDelivered(orderID string) (*dto.ConfirmDelive
 		return nil, errors.New("order already marked as delivered")
 	}
 
-	err = s.orderRepo.MarkOrderDelivered(id)
+	err = s.orderRepo.WithTx(func(tx *gorm.DB) error {
+		orderRepo := repositories.NewOrderRepository(tx)
+		if err := orderRepo.MarkOrderDelivered(id); err != nil {
+			return err
+		}
+
+		// ** pembayaran COD diterima kurir saat barang sampai
+		if err := repositories.NewPaymentRepository(tx).MarkCODPaid(id); err != nil {
+			return err
+		}
+		return TransitionOrder(orderRepo, order, OrderDelivered, AdminActor(uuid.MustParse(adminID)), "shipment delivered")
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -472,3 +556,170 @@ func (s *orderService) ConfirmOrderDelivered(orderID string) (*dto.ConfirmDelive
 		Delivered: time.Now(),
 	}, nil
 }
+
+func (s *orderService) CancelOrder(userID, orderID string) (*dto.CancelOrderResponse, error) {
+	order, err := s.orderRepo.GetOrderDetail(orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	if order.UserID.String() != userID {
+		return nil, errors.New("order not found")
+	}
+
+	if order.Status != OrderWaitingPayment && order.Status != OrderPending {
+		return nil, fmt.Errorf("order with status %s cannot be canceled", order.Status)
+	}
+
+	if err := s.cancelOrder(order, CustomerActor(order.UserID), "canceled by customer"); err != nil {
+		return nil, err
+	}
+
+	return &dto.CancelOrderResponse{
+		OrderID: order.ID.String(),
+		Status:  order.Status,
+	}, nil
+}
+
+// cancelOrder transitions the order to canceled and rolls back stock, voucher and payment
+func (s *orderService) cancelOrder(order *models.Order, actor OrderActor, reason string) error {
+	orderID := order.ID.String()
+	payment, err := s.paymentRepo.GetPaymentByOrderID(orderID)
+	if err != nil {
+		return errors.New("payment not found")
+	}
+
+	if !CanTransitionOrder(order.Status, OrderCanceled) {
+		return fmt.Errorf("%w: %s → %s", ErrIllegalTransition, order.Status, OrderCanceled)
+	}
+
+	// ** dana dikembalikan dulu, order baru dibatalkan kalau refund berhasil
+	var refunded float64
+	if payment.Status == "success" {
+		refunded = refundableAmount(order)
+		if refunded > 0 {
+			if _, err := refundPayment(s.gateway, payment, "cancel-"+orderID, int64(refunded), reason); err != nil {
+				return err
+			}
+		}
+	}
+
+	// unpaid orders simply fail, paid orders have been refunded above
+	err = s.orderRepo.WithTx(func(tx *gorm.DB) error {
+		if err := releaseOrder(tx, order, actor, reason); err != nil {
+			return err
+		}
+
+		if payment.Status == "success" {
+			payment.Status = "refunded"
+			payment.RefundedAmount += refunded
+			if err := repositories.NewOrderRepository(tx).AddRefundedAmount(order.ID, refunded); err != nil {
+				return err
+			}
+		} else {
+			payment.Status = "failed"
+		}
+
+		payment.Order = models.Order{}
+		return repositories.NewPaymentRepository(tx).UpdatePayment(payment)
+	})
+	if err != nil {
+		if refunded > 0 {
+			// ! dana sudah dikembalikan tapi order belum batal, refund key yang sama membuat percobaan ulang aman
+			log.Printf("Refund for order %s sent but cancellation failed: %v\n", orderID, err)
+		}
+		return err
+	}
+
+	// ** transaksi gateway yang belum dibayar ditutup setelah commit, gagal di sini tidak membatalkan pembatalan
+	if payment.Status == "failed" && !isManualPayment(payment.Method) {
+		if err := s.gateway.CancelCharge(orderID); err != nil {
+			log.Printf("Failed to cancel %s transaction for order %s: %v\n", s.gateway.Name(), orderID, err)
+		}
+	}
+
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+	payload := dto.NotificationEvent{
+		UserID:  order.UserID.String(),
+		Type:    "order_canceled",
+		Message: fmt.Sprintf("Your order #%s has been canceled.", order.InvoiceNumber),
+	}
+
+	err = s.notificationService.SendToUser(payload)
+	if err != nil {
+		log.Printf("Fail to send notification to user %s: %v\n", payload.UserID, err)
+	}
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+
+	return nil
+}
+
+func (s *orderService) UpdateOrderStatus(adminID, orderID string, req dto.UpdateOrderStatusRequest) error {
+	order, err := s.orderRepo.GetOrderDetail(orderID)
+	if err != nil {
+		return errors.New("order not found")
+	}
+
+	actor := AdminActor(uuid.MustParse(adminID))
+	if req.Status == OrderCanceled {
+		return s.cancelOrder(order, actor, req.Reason)
+	}
+
+	if flow, ok := dedicatedStatusFlows[req.Status]; ok {
+		return fmt.Errorf("order cannot be set to %s directly, %s", req.Status, flow)
+	}
+	if manualTransitions[order.Status] != req.Status {
+		return fmt.Errorf("%w: %s → %s", ErrIllegalTransition, order.Status, req.Status)
+	}
+
+	return TransitionOrder(s.orderRepo, order, req.Status, actor, req.Reason)
+}
+
+// manualTransitions are the only moves without side effects, an admin can make them by just setting the status
+var manualTransitions = map[string]string{
+	OrderPending:   OrderProcess,
+	OrderDelivered: OrderCompleted,
+}
+
+// ** status lain menggerakkan stok, pembayaran atau pengiriman, jadi harus lewat alurnya sendiri
+var dedicatedStatusFlows = map[string]string{
+	OrderPending:   "it follows the payment",
+	OrderShipped:   "create a shipment instead",
+	OrderDelivered: "confirm the delivery instead",
+	OrderRefunded:  "approve and refund a return request instead",
+}
+
+func (s *orderService) GetOrderHistory(userID, role, orderID string) ([]dto.OrderStatusHistoryResponse, error) {
+	order, err := s.orderRepo.GetOrderDetail(orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	if role == "customer" && order.UserID.String() != userID {
+		return nil, errors.New("order not found")
+	}
+
+	histories, err := s.orderRepo.GetStatusHistory(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []dto.OrderStatusHistoryResponse
+	for _, h := range histories {
+		var actorID *string
+		if h.ActorID != nil {
+			actorID = utils.ToPtr(h.ActorID.String())
+		}
+
+		result = append(result, dto.OrderStatusHistoryResponse{
+			FromStatus: h.FromStatus,
+			ToStatus:   h.ToStatus,
+			ActorType:  h.ActorType,
+			ActorID:    actorID,
+			Reason:     h.Reason,
+			CreatedAt:  h.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"server/internal/models"
+	"server/internal/repositories"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	OrderWaitingPayment = "waiting_payment"
+	OrderPending        = "pending"
+	OrderProcess        = "process"
+	OrderShipped        = "shipped"
+	OrderDelivered      = "delivered"
+	OrderCompleted      = "completed"
+	OrderCanceled       = "canceled"
+	OrderRefunded       = "refunded"
+)
+
+const (
+	ActorCustomer = "customer"
+	ActorAdmin    = "admin"
+	ActorSystem   = "system"
+	ActorGateway  = "gateway"
+)
+
+var ErrIllegalTransition = errors.New("illegal order status transition")
+
+// orderTransitions lists every legal next status for a given order status
+var orderTransitions = map[string][]string{
+	OrderWaitingPayment: {OrderPending, OrderCanceled},
+	OrderPending:        {OrderProcess, OrderCanceled, OrderRefunded},
+	OrderProcess:        {OrderShipped, OrderCanceled, OrderRefunded},
+	OrderShipped:        {OrderDelivered},
+	OrderDelivered:      {OrderCompleted, OrderRefunded},
+	OrderCompleted:      {OrderRefunded},
+	OrderCanceled:       {},
+	OrderRefunded:       {},
+}
+
+func CanTransitionOrder(from, to string) bool {
+	next, ok := orderTransitions[from]
+	return ok && slices.Contains(next, to)
+}
+
+type OrderActor struct {
+	Type string
+	ID   *uuid.UUID
+}
+
+func CustomerActor(userID uuid.UUID) OrderActor { return OrderActor{Type: ActorCustomer, ID: &userID} }
+func AdminActor(userID uuid.UUID) OrderActor    { return OrderActor{Type: ActorAdmin, ID: &userID} }
+func SystemActor() OrderActor                   { return OrderActor{Type: ActorSystem} }
+func GatewayActor() OrderActor                  { return OrderActor{Type: ActorGateway} }
+
+// TransitionOrder moves the order to the given status and records it in the status history.
+// Pass a tx-scoped repository to make the transition part of a larger unit of work.
+func TransitionOrder(repo repositories.OrderRepository, order *models.Order, to string, actor OrderActor, reason string) error {
+	from := order.Status
+	if !CanTransitionOrder(from, to) {
+		return fmt.Errorf("%w: %s → %s", ErrIllegalTransition, from, to)
+	}
+
+	err := repo.WithTx(func(tx *gorm.DB) error {
+		txRepo := repositories.NewOrderRepository(tx)
+		if err := txRepo.UpdateOrderStatus(order.ID, from, to); err != nil {
+			return err
+		}
+		if err := txRepo.CreateStatusHistory(&models.OrderStatusHistory{
+			OrderID:    order.ID,
+			FromStatus: from,
+			ToStatus:   to,
+			ActorType:  actor.Type,
+			ActorID:    actor.ID,
+			Reason:     reason,
+		}); err != nil {
+			return err
+		}
+		return syncSoldCount(tx, order.ID, to)
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Status = to
+	return nil
+}
+
+// RecordInitialStatus writes the history entry for a freshly created order
+func RecordInitialStatus(repo repositories.OrderRepository, order *models.Order, actor OrderActor) error {
+	return repo.CreateStatusHistory(&models.OrderStatusHistory{
+		OrderID:   order.ID,
+		ToStatus:  order.Status,
+		ActorType: actor.Type,
+		ActorID:   actor.ID,
+		Reason:    "order created",
+	})
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"server/internal/models"
+	"strconv"
+)
+
+const (
+	GatewayMidtrans = "midtrans"
+	GatewayFake     = "fake"
+)
+
+// payment methods, gateway payments store the gateway payment type instead
+const (
+	MethodGateway        = "gateway"
+	MethodCOD            = "cod"
+	MethodManualTransfer = "manual_transfer"
+)
+
+var (
+	ErrInvalidSignature    = errors.New("invalid notification signature")
+	ErrInvalidNotification = errors.New("invalid notification payload")
+	ErrTransactionNotFound = errors.New("transaction not found at gateway")
+)
+
+type ChargeRequest struct {
+	Order    *models.Order
+	Items    []models.OrderItem
+	Customer *models.User
+	Address  *models.Address
+}
+
+type ChargeResult struct {
+	Token       string
+	RedirectURL string
+}
+
+// PaymentStatus is a gateway transaction mapped to our payment statuses.
+// Status is always one of "success", "pending", "failed" or "refunded", RawStatus keeps the gateway value.
+type PaymentStatus struct {
+	OrderID     string
+	Status      string
+	RawStatus   string
+	PaymentType string
+	GrossAmount string
+}
+
+// PaymentGateway hides the payment provider from checkout, webhook and refund flows
+type PaymentGateway interface {
+	Name() string
+	CreateCharge(req ChargeRequest) (*ChargeResult, error)
+	// ParseNotification verifies a raw webhook body and returns the reported status
+	ParseNotification(body []byte) (*PaymentStatus, error)
+	QueryStatus(orderID string) (*PaymentStatus, error)
+	CancelCharge(orderID string) error
+	RefundProvider
+}
+
+// gross amount dikirim gateway dalam bentuk string, mis. "150000.00"
+func checkGrossAmount(grossAmount string, order *models.Order) error {
+	amount, err := strconv.ParseFloat(grossAmount, 64)
+	if err != nil {
+		return fmt.Errorf("invalid gross amount: %s", grossAmount)
+	}
+
+	// GrossAmt dikirim ke gateway sebagai int64, jadi bandingkan dengan nilai yang sama
+	expected := float64(int64(order.AmountToPay))
+	if math.Abs(amount-expected) > 0.01 {
+		return fmt.Errorf("gross amount %s does not match order amount %.2f", grossAmount, expected)
+	}
+
+	return nil
+}
+
+// isManualPayment reports whether the payment is collected outside the gateway
+func isManualPayment(method string) bool {
+	return method == MethodCOD || method == MethodManualTransfer
+}
+
+// refundPayment refunds through the gateway, COD and manual transfer are refunded by hand
+func refundPayment(gateway PaymentGateway, payment *models.Payment, refundKey string, amount int64, reason string) (*RefundResult, error) {
+	if isManualPayment(payment.Method) {
+		return &RefundResult{Reference: "manual-" + refundKey, Amount: amount}, nil
+	}
+	return gateway.Refund(payment.OrderID.String(), refundKey, amount, reason)
+}
@@ -1,125 +1,332 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"server/internal/dto"
 	"server/internal/models"
 	"server/internal/repositories"
 	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type PaymentService interface {
 	ExpireOldPendingPayments() error
-	HandlePaymentNotification(req dto.MidtransNotificationRequest) error
+	HandlePaymentNotification(body []byte) error
+	GetFakePayment(orderID, token string) (*dto.FakePaymentResponse, error)
+	SimulateFakePayment(orderID, token, transactionStatus string) error
+	UploadTransferReceipt(userID, orderID string, req dto.UploadReceiptRequest) error
+	VerifyTransfer(adminID, orderID string, req dto.VerifyTransferRequest) error
 	GetAllUserPayments(param dto.PaymentQueryParam) ([]dto.PaymentResponse, *dto.PaginationResponse, error)
 }
 
 type paymentService struct {
 	paymentRepo         repositories.PaymentRepository
 	authRepo            repositories.AuthRepository
-	productRepo         repositories.ProductRepository
-	voucherService      VoucherService
 	orderRepo           repositories.OrderRepository
 	notificationService NotificationService
+	gateway             PaymentGateway
 }
 
+var (
+	ErrFakeGatewayDisabled = errors.New("fake payment gateway is not enabled")
+	// ErrPaymentNotFound means no payment exists for the order, a webhook for it will never succeed
+	ErrPaymentNotFound = errors.New("payment not found")
+)
+
 func NewPaymentService(
 	paymentRepo repositories.PaymentRepository,
 	authRepo repositories.AuthRepository,
-	productRepo repositories.ProductRepository,
-	voucherService VoucherService,
 	orderRepo repositories.OrderRepository,
 	notificationService NotificationService,
+	gateway PaymentGateway,
 ) PaymentService {
 	return &paymentService{
 		paymentRepo:         paymentRepo,
 		authRepo:            authRepo,
-		productRepo:         productRepo,
-		voucherService:      voucherService,
 		orderRepo:           orderRepo,
 		notificationService: notificationService,
+		gateway:             gateway,
 	}
 }
-func (s *paymentService) HandlePaymentNotification(req dto.MidtransNotificationRequest) error {
-	payment, err := s.paymentRepo.GetPaymentByOrderID(req.OrderID)
+func (s *paymentService) HandlePaymentNotification(body []byte) error {
+	notif, err := s.gateway.ParseNotification(body)
 	if err != nil {
-		return fmt.Errorf("payment not found for orderID: %s", req.OrderID)
+		return err
 	}
-	if payment.Status == "success" {
+
+	payment, err := s.paymentRepo.GetPaymentByOrderID(notif.OrderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w for orderID: %s", ErrPaymentNotFound, notif.OrderID)
+	}
+	if err != nil {
+		return err
+	}
+	if paymentSettled(payment, notif) {
 		return nil
 	}
+	if isManualPayment(payment.Method) {
+		return fmt.Errorf("payment for order %s is not handled by the gateway", notif.OrderID)
+	}
+
+	if err := checkGrossAmount(notif.GrossAmount, &payment.Order); err != nil {
+		return err
+	}
+
+	if notif.PaymentType != "" {
+		payment.Method = notif.PaymentType
+	}
 
-	payment.Method = req.PaymentType
-
-	switch req.TransactionStatus {
-
-	case "settlement", "capture":
-		if req.FraudStatus == "accept" || req.FraudStatus == "" {
-			payment.Status = "success"
-			payment.PaidAt = time.Now()
-		} else {
-			payment.Status = "failed"
-			err = s.productRepo.RestoreStockOnPaymentFailure(&payment.Order)
-			if err != nil {
-				return fmt.Errorf("failed to restore stock for order %s: %w", payment.OrderID, err)
-			}
-
-			err = s.orderRepo.UpdateOrder(&models.Order{
-				ID:     payment.Order.ID,
-				Status: "canceled",
-			})
-			if err != nil {
-				return fmt.Errorf("failed to update order status: %w", err)
-			}
+	payment.Status = notif.Status
+	if payment.Status == "success" {
+		payment.PaidAt = time.Now()
+	}
+
+	order := payment.Order
+	payment.Order = models.Order{}
+	reason := "payment " + notif.RawStatus
+
+	// ! dana masuk untuk pesanan yang sudah dibatalkan atau kedaluwarsa, stoknya sudah dilepas jadi harus direfund
+	if payment.Status == "success" && order.Status != OrderWaitingPayment {
+		return s.refundLatePayment(payment, &order, reason)
+	}
+
+	err = s.orderRepo.WithTx(func(tx *gorm.DB) error {
+		if err := repositories.NewPaymentRepository(tx).UpdatePayment(payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
 		}
-	case "pending":
-		payment.Status = "pending"
-	default:
-		payment.Status = "failed"
-		if req.FraudStatus == "accept" || req.FraudStatus == "" {
-			payment.Status = "success"
-			payment.PaidAt = time.Now()
-		} else {
-			payment.Status = "failed"
-			err = s.productRepo.RestoreStockOnPaymentFailure(&payment.Order)
-			if err != nil {
-				return fmt.Errorf("failed to restore stock for order %s: %w", payment.OrderID, err)
-			}
-
-			err = s.orderRepo.UpdateOrder(&models.Order{
-				ID:     payment.Order.ID,
-				Status: "canceled",
-			})
-			if err != nil {
-				return fmt.Errorf("failed to update order status: %w", err)
-			}
+
+		switch {
+		case payment.Status == "success":
+			return TransitionOrder(repositories.NewOrderRepository(tx), &order, OrderPending, GatewayActor(), reason)
+		case payment.Status == "failed" && order.Status == OrderWaitingPayment:
+			return releaseOrder(tx, &order, GatewayActor(), reason)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if err := s.paymentRepo.UpdatePayment(payment); err != nil {
-		return fmt.Errorf("failed to update payment: %w", err)
+	if payment.Status == "success" {
+		s.notifyPayment(payment.UserID.String(), "order_processed", "Payment Successfully Received",
+			fmt.Sprintf("Thank you %s, your payment for order %s has been received and is being processed.", payment.Fullname, order.InvoiceNumber))
 	}
+	return nil
+}
 
-	if payment.Status == "success" {
-		if err := s.orderRepo.UpdateOrder(&models.Order{
-			ID:     payment.Order.ID,
-			Status: "pending",
-		}); err != nil {
-			return fmt.Errorf("failed to update order: %w", err)
+// paymentSettled reports whether the notification has nothing left to change. Refunds are recorded
+// by the flow that asked for them, so the gateway's own refund webhook is only an echo.
+// ! pembayaran gagal pada order yang sudah dibatalkan tetap diproses kalau dananya ternyata masuk
+func paymentSettled(payment *models.Payment, notif *PaymentStatus) bool {
+	switch {
+	case payment.Status == "success" || payment.Status == "refunded":
+		return true
+	case notif.Status == "refunded":
+		return true
+	case payment.Status == "failed" && payment.Order.Status != OrderWaitingPayment:
+		return notif.Status != "success"
+	}
+	return false
+}
+
+// refundLatePayment sends back a payment that settled after the order stopped waiting for it.
+// Kalau refund gagal, admin diberi tahu dan webhook dikembalikan error supaya gateway mengirim ulang,
+// refund key yang sama membuat percobaan berikutnya tidak mengembalikan dana dua kali.
+func (s *paymentService) refundLatePayment(payment *models.Payment, order *models.Order, reason string) error {
+	orderID := order.ID.String()
+	amount := refundableAmount(order)
+
+	result, err := refundPayment(s.gateway, payment, "late-"+orderID, int64(amount), reason+" after order was "+order.Status)
+	if err != nil {
+		s.alertAdmins("Late Payment Needs Refund",
+			fmt.Sprintf("Payment for order %s settled while the order is %s and the refund failed: %v. Please refund it manually.", order.InvoiceNumber, order.Status, err))
+		return fmt.Errorf("failed to refund late payment for order %s: %w", orderID, err)
+	}
+
+	payment.Status = "refunded"
+	payment.PaidAt = time.Now()
+	payment.RefundedAmount += amount
+	err = s.orderRepo.WithTx(func(tx *gorm.DB) error {
+		if err := repositories.NewPaymentRepository(tx).UpdatePayment(payment); err != nil {
+			return err
 		}
+		return repositories.NewOrderRepository(tx).AddRefundedAmount(order.ID, amount)
+	})
+	if err != nil {
+		s.alertAdmins("Late Payment Refund Not Recorded",
+			fmt.Sprintf("Late payment for order %s was refunded (%s) but could not be recorded: %v.", order.InvoiceNumber, result.Reference, err))
+		return err
+	}
+
+	s.notifyPayment(payment.UserID.String(), "order_canceled", "Payment Refunded",
+		fmt.Sprintf("Your payment for order %s arrived after the order was %s and has been refunded.", order.InvoiceNumber, order.Status))
+	return nil
+}
+
+func (s *paymentService) alertAdmins(title, message string) {
+	log.Printf("%s: %s", title, message)
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+	if err := s.notificationService.SendToAdmins(dto.NotificationEvent{
+		Type:    "payment_alert",
+		Title:   title,
+		Message: message,
+	}); err != nil {
+		log.Printf("Failed sending payment alert to admins: %v", err)
+	}
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+}
+
+func (s *paymentService) UploadTransferReceipt(userID, orderID string, req dto.UploadReceiptRequest) error {
+	payment, err := s.paymentRepo.GetPaymentByOrderID(orderID)
+	if err != nil || payment.UserID.String() != userID {
+		return errors.New("payment not found")
+	}
+
+	if payment.Method != MethodManualTransfer {
+		return errors.New("order is not paid by bank transfer")
+	}
+	if payment.Status != "pending" || payment.Order.Status != OrderWaitingPayment {
+		return errors.New("payment is no longer waiting for a transfer")
+	}
 
-		notification := dto.NotificationEvent{
-			UserID: payment.UserID.String(),
-			Type:   "order_processed",
-			Title:  "Payment Successfully Received",
-			Message: fmt.Sprintf("Thank you %s, your payment for order %s has been received and is being processed.",
-				payment.Fullname, payment.Order.InvoiceNumber),
+	return s.paymentRepo.UpdateReceipt(payment.OrderID, &req.ImageURL, nil)
+}
+
+// VerifyTransfer lets an admin accept or reject an uploaded transfer receipt.
+// A rejected receipt is cleared so the customer can upload a new one before the order expires.
+func (s *paymentService) VerifyTransfer(adminID, orderID string, req dto.VerifyTransferRequest) error {
+	aid, _ := uuid.Parse(adminID)
+
+	payment, err := s.paymentRepo.GetPaymentByOrderID(orderID)
+	if err != nil {
+		return errors.New("payment not found")
+	}
+	if payment.Method != MethodManualTransfer {
+		return errors.New("order is not paid by bank transfer")
+	}
+
+	order := payment.Order
+	var note *string
+	if req.Note != "" {
+		note = &req.Note
+	}
+
+	if !*req.Approved {
+		if payment.Status != "pending" || payment.ReceiptImage == nil {
+			return errors.New("no transfer receipt waiting for verification")
 		}
-		if err := s.notificationService.SendToUser(notification); err != nil {
-			log.Printf("Failed sending notification to user %s: %v", notification.UserID, err)
-		} else {
-			log.Println("Notification sent to user")
+		if err := s.paymentRepo.UpdateReceipt(payment.OrderID, nil, note); err != nil {
+			return err
+		}
+
+		s.notifyPayment(payment.UserID.String(), "pending_payment", "Transfer Receipt Rejected",
+			fmt.Sprintf("Your transfer receipt for order %s was rejected: %s. Please upload a valid receipt.", order.InvoiceNumber, req.Note))
+		return nil
+	}
+
+	err = s.orderRepo.WithTx(func(tx *gorm.DB) error {
+		if err := repositories.NewPaymentRepository(tx).MarkTransferVerified(payment.OrderID, aid, note); err != nil {
+			return err
+		}
+		return TransitionOrder(repositories.NewOrderRepository(tx), &order, OrderPending, AdminActor(aid), "bank transfer verified")
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyPayment(payment.UserID.String(), "order_processed", "Payment Successfully Received",
+		fmt.Sprintf("Thank you %s, your payment for order %s has been received and is being processed.", payment.Fullname, order.InvoiceNumber))
+	return nil
+}
+
+func (s *paymentService) notifyPayment(userID, notifType, title, message string) {
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+	notification := dto.NotificationEvent{
+		UserID:  userID,
+		Type:    notifType,
+		Title:   title,
+		Message: message,
+	}
+	if err := s.notificationService.SendToUser(notification); err != nil {
+		log.Printf("Failed sending notification to user %s: %v", notification.UserID, err)
+	}
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+}
+
+// GetFakePayment returns what the local pay page of the fake gateway shows
+func (s *paymentService) GetFakePayment(orderID, token string) (*dto.FakePaymentResponse, error) {
+	fake, ok := s.gateway.(*FakeGateway)
+	if !ok {
+		return nil, ErrFakeGatewayDisabled
+	}
+	if !fake.VerifyPayToken(orderID, token) {
+		return nil, ErrInvalidSignature
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByOrderID(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found for orderID: %s", orderID)
+	}
+
+	return &dto.FakePaymentResponse{
+		OrderID:       orderID,
+		InvoiceNumber: payment.Order.InvoiceNumber,
+		Amount:        int64(payment.Order.AmountToPay),
+		Status:        payment.Status,
+	}, nil
+}
+
+// SimulateFakePayment makes the fake gateway send a webhook for the order
+func (s *paymentService) SimulateFakePayment(orderID, token, transactionStatus string) error {
+	fake, ok := s.gateway.(*FakeGateway)
+	if !ok {
+		return ErrFakeGatewayDisabled
+	}
+	if !fake.VerifyPayToken(orderID, token) {
+		return ErrInvalidSignature
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByOrderID(orderID)
+	if err != nil {
+		return fmt.Errorf("payment not found for orderID: %s", orderID)
+	}
+
+	body, err := fake.Notify(orderID, transactionStatus, int64(payment.Order.AmountToPay))
+	if err != nil {
+		return err
+	}
+
+	return s.HandlePaymentNotification(body)
+}
+
+// failUnpaidOrder fails the pending payment and releases the order in one transaction
+func failUnpaidOrder(orderRepo repositories.OrderRepository, order *models.Order, actor OrderActor, reason string) error {
+	return orderRepo.WithTx(func(tx *gorm.DB) error {
+		if err := repositories.NewPaymentRepository(tx).UpdatePaymentStatus(order.ID, "pending", "failed"); err != nil {
+			return err
+		}
+		return releaseOrder(tx, order, actor, reason)
+	})
+}
+
+// releaseOrder cancels the order and gives its stock and voucher back, tx must already be open
+func releaseOrder(tx *gorm.DB, order *models.Order, actor OrderActor, reason string) error {
+	if err := TransitionOrder(repositories.NewOrderRepository(tx), order, OrderCanceled, actor, reason); err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	if err := moveOrderStock(repositories.NewProductRepository(tx), order, order.Items, StockCancel, actor, reason); err != nil {
+		return fmt.Errorf("failed to restore stock for order %s: %w", order.ID, err)
+	}
+
+	if order.VoucherCode != nil && *order.VoucherCode != "" {
+		voucherService := NewVoucherService(repositories.NewVoucherRepository(tx))
+		if err := voucherService.RestoreQuota(order.UserID, *order.VoucherCode); err != nil {
+			return fmt.Errorf("failed to restore voucher %s for order %s: %w", *order.VoucherCode, order.ID, err)
 		}
 	}
 
@@ -145,6 +352,7 @@ func (s *paymentService) GetAllUserPayments(param dto.PaymentQueryParam) ([]dto.
 			Method:        p.Method,
 			Status:        p.Status,
 			PaidAt:        p.PaidAt.Format("2006-01-02"),
+			ReceiptImage:  p.ReceiptImage,
 		})
 	}
 	totalPages := int((total + int64(param.Limit) - 1) / int64(param.Limit))
@@ -165,29 +373,14 @@ func (s *paymentService) ExpireOldPendingPayments() error {
 	}
 
 	if len(payments) == 0 {
-		log.Println("✅ No expired pending payments found")
+		log.Println("✅ No expired manual transfers found")
 		return nil
 	}
 
 	for _, p := range payments {
-		p.Status = "failed"
-
-		err := s.paymentRepo.UpdatePayment(&p)
-		if err != nil {
-			return fmt.Errorf("failed to update payment %s: %w", p.ID, err)
-		}
-
-		err = s.productRepo.RestoreStockOnPaymentFailure(&p.Order)
-		if err != nil {
-			return fmt.Errorf("failed to restore stock for order %s: %w", p.OrderID, err)
-		}
-
-		err = s.orderRepo.UpdateOrder(&models.Order{
-			ID:     p.Order.ID,
-			Status: "canceled",
-		})
-		if err != nil {
-			return fmt.Errorf("failed to update order status: %w", err)
+		order := p.Order
+		if err := failUnpaidOrder(s.orderRepo, &order, SystemActor(), "payment expired"); err != nil {
+			log.Printf("Failed to cancel expired order %s: %v", order.ID, err)
 		}
 	}
 
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"log"
+	"server/internal/config"
+	"server/internal/models"
+	"server/internal/repositories"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// productViewsKey is the Redis hash of product ID -> views not yet written to the database
+const productViewsKey = "ecommerce_app:product_views"
+
+// ? daftar produk yang terakhir dilihat user, dipakai sebagai sinyal rekomendasi
+const (
+	recentViewsLimit = 50
+	recentViewsTTL   = 30 * 24 * time.Hour
+)
+
+// ** skor trending: satu view bernilai 1, satu unit terjual setara beberapa view,
+// ** lalu skornya meluruh setengah setiap trendingHalfLife
+const (
+	trendingHalfLife   = 3 * 24 * time.Hour
+	trendingViewWeight = 1.0
+	trendingSaleWeight = 10.0
+)
+
+// syncSoldCount keeps Product.Sold in step with the order status, it runs inside the transition's tx.
+// An order counts once it is paid: online payments move it to pending, COD is paid on delivery.
+// Canceled and refunded orders give back whatever they still count.
+func syncSoldCount(tx *gorm.DB, orderID uuid.UUID, to string) error {
+	switch to {
+	case OrderPending, OrderDelivered, OrderCanceled, OrderRefunded:
+	default:
+		return nil
+	}
+
+	orderRepo := repositories.NewOrderRepository(tx)
+	productRepo := repositories.NewProductRepository(tx)
+
+	items, err := orderRepo.GetOrderItems(orderID)
+	if err != nil {
+		return err
+	}
+	if to == OrderCanceled || to == OrderRefunded {
+		return uncountSold(orderRepo, productRepo, items, nil)
+	}
+
+	for _, item := range items {
+		// ? order prepaid sudah terhitung saat pending, delivered tidak menambah lagi
+		delta := item.Quantity - item.SoldQuantity
+		if delta <= 0 {
+			continue
+		}
+		if err := productRepo.AddSold(item.ProductID, delta, float64(delta)*trendingSaleWeight); err != nil {
+			return err
+		}
+		if err := orderRepo.SetItemSoldQuantity(item.ID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uncountSold takes units back out of Sold. quantities maps order item ID to the units
+// to take back, nil takes back everything the items still count.
+func uncountSold(orderRepo repositories.OrderRepository, productRepo repositories.ProductRepository, items []models.OrderItem, quantities map[uuid.UUID]int) error {
+	for _, item := range items {
+		n := item.SoldQuantity
+		if quantities != nil {
+			n = min(n, quantities[item.ID])
+		}
+		if n <= 0 {
+			continue
+		}
+		if err := productRepo.AddSold(item.ProductID, -n, 0); err != nil {
+			return err
+		}
+		if err := orderRepo.SetItemSoldQuantity(item.ID, item.SoldQuantity-n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordProductView counts a detail page view in Redis, the cron writes the counts to the database.
+// Signed-in viewers also get the product on their recently viewed list for recommendations.
+func recordProductView(productID uuid.UUID, viewerID string) {
+	if err := config.RedisClient.HIncrBy(config.Ctx, productViewsKey, productID.String(), 1).Err(); err != nil {
+		log.Printf("Failed to record view of product %s: %v\n", productID, err)
+	}
+	if viewerID == "" {
+		return
+	}
+
+	// ** produk yang dilihat ulang dipindah ke depan, bukan dicatat dua kali
+	key := recentViewsKey(viewerID)
+	pipe := config.RedisClient.TxPipeline()
+	pipe.LRem(config.Ctx, key, 0, productID.String())
+	pipe.LPush(config.Ctx, key, productID.String())
+	pipe.LTrim(config.Ctx, key, 0, recentViewsLimit-1)
+	pipe.Expire(config.Ctx, key, recentViewsTTL)
+	if _, err := pipe.Exec(config.Ctx); err != nil {
+		log.Printf("Failed to record recent view of user %s: %v\n", viewerID, err)
+	}
+}
+
+func recentViewsKey(userID string) string {
+	return "ecommerce_app:recent_views:" + userID
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"math"
+	"server/internal/models"
+	"server/internal/repositories"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestDecayTrendingScores(t *testing.T) {
+	db := testDB(t)
+	productRepo := repositories.NewProductRepository(db)
+
+	backdate := func(t *testing.T, product *models.Product, score float64, trendingAt interface{}) time.Time {
+		t.Helper()
+		updatedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+		if err := db.Model(product).UpdateColumns(map[string]interface{}{
+			"trending_score": score,
+			"trending_at":    trendingAt,
+			"updated_at":     updatedAt,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+		return updatedAt
+	}
+	reload := func(t *testing.T, product *models.Product) *models.Product {
+		t.Helper()
+		var got models.Product
+		if err := db.First(&got, "id = ?", product.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &got
+	}
+
+	tests := []struct {
+		name       string
+		score      float64
+		trendingAt interface{}
+		views      int64
+		wantScore  float64
+		wantAt     bool
+	}{
+		{"halves once per half life", 8, gorm.Expr("NOW() - INTERVAL 3 DAY"), 0, 4, true},
+		{"tiny score drops to zero", 0.005, gorm.Expr("NOW()"), 0, 0, false},
+		{"not trending is left alone", 0, nil, 0, 0, false},
+		{"new views are not decayed from a stale timestamp", 0, gorm.Expr("NOW() - INTERVAL 30 DAY"), 5, 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, product := seedCustomer(t, db, 1, 1)
+			updatedAt := backdate(t, product, tt.score, tt.trendingAt)
+
+			if err := productRepo.DecayTrendingScores(trendingHalfLife); err != nil {
+				t.Fatal(err)
+			}
+			if tt.views > 0 {
+				if err := productRepo.AddViews(product.ID, tt.views, float64(tt.views)*trendingViewWeight); err != nil {
+					t.Fatal(err)
+				}
+				if err := productRepo.DecayTrendingScores(trendingHalfLife); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := reload(t, product)
+			if math.Abs(got.TrendingScore-tt.wantScore) > 0.01 {
+				t.Errorf("trending score = %v, want %v", got.TrendingScore, tt.wantScore)
+			}
+			if (got.TrendingAt != nil) != tt.wantAt {
+				t.Errorf("trending at = %v, want set %v", got.TrendingAt, tt.wantAt)
+			}
+			if !got.UpdatedAt.Equal(updatedAt) {
+				t.Errorf("updated at = %v, want %v untouched", got.UpdatedAt, updatedAt)
+			}
+		})
+	}
+}
@@ -2,31 +2,48 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"server/internal/config"
 	"server/internal/dto"
 	"server/internal/models"
+	"server/internal/pricing"
 	"server/internal/repositories"
+	"server/internal/search"
 	"server/internal/utils"
+	"strconv"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ProductService interface {
 	DeleteProduct(productID string) error
-	CreateProduct(req dto.CreateProductRequest) error
-	UpdateProduct(productID string, req dto.UpdateProductRequest) error
-	GetProductBySlug(slug string) (*dto.ProductDetailResponse, error)
-	SearchProducts(param dto.GetAllProductsRequest) ([]dto.ProductListResponse, *dto.PaginationResponse, error)
+	CreateProduct(adminID string, req dto.CreateProductRequest) error
+	UpdateProduct(adminID, productID string, req dto.UpdateProductRequest) error
+	GetProductBySlug(slug, viewerID string) (*dto.ProductDetailResponse, error)
+	SearchProducts(param dto.GetAllProductsRequest) ([]dto.ProductListResponse, *dto.PaginationResponse, *dto.SearchFacetsResponse, error)
+	ReindexProducts() (int, error)
+	FlushProductViews() (int, error)
 }
 
 type productService struct {
-	productRepo repositories.ProductRepository
+	productRepo     repositories.ProductRepository
+	reservationRepo repositories.ReservationRepository
+	wishlistRepo    repositories.WishlistRepository
+	categoryRepo    repositories.CategoryRepository
+	searchIndex     search.SearchIndex
+	suggester       *search.Suggester
+	searchQueryRepo repositories.SearchQueryRepository
 }
 
-func NewProductService(productRepo repositories.ProductRepository) ProductService {
-	return &productService{productRepo}
+func NewProductService(productRepo repositories.ProductRepository, reservationRepo repositories.ReservationRepository, wishlistRepo repositories.WishlistRepository, categoryRepo repositories.CategoryRepository, searchIndex search.SearchIndex, suggester *search.Suggester, searchQueryRepo repositories.SearchQueryRepository) ProductService {
+	return &productService{productRepo, reservationRepo, wishlistRepo, categoryRepo, searchIndex, suggester, searchQueryRepo}
 }
 
-func (s *productService) CreateProduct(req dto.CreateProductRequest) error {
+func (s *productService) CreateProduct(adminID string, req dto.CreateProductRequest) error {
+	aid, _ := uuid.Parse(adminID)
+
 	categoryID, err := uuid.Parse(req.CategoryID)
 	if err != nil {
 		return errors.New("invalid category ID")
@@ -37,31 +54,60 @@ func (s *productService) CreateProduct(req dto.CreateProductRequest) error {
 		Slug:        utils.GenerateSlug(req.Name),
 		Description: req.Description,
 		Price:       req.Price,
-		Stock:       req.Stock,
 		IsActive:    req.IsActive,
 		IsFeatured:  req.IsFeatured,
 		CategoryID:  categoryID,
 		Discount:    req.Discount,
-	}
 
-	if err := s.productRepo.CreateProduct(&product); err != nil {
-		return err
+		LowStockThreshold: req.LowStockThreshold,
 	}
 
-	for _, url := range req.ImageURLs {
-		img := models.ProductGallery{
-			ProductID: product.ID,
-			Image:     url,
-		}
-		if err := s.productRepo.CreateProductGallery(&img); err != nil {
+	err = s.productRepo.WithTx(func(tx *gorm.DB) error {
+		productRepo := repositories.NewProductRepository(tx)
+
+		if err := productRepo.CreateProduct(&product); err != nil {
 			return err
 		}
+
+		for _, url := range req.ImageURLs {
+			img := models.ProductGallery{
+				ProductID: product.ID,
+				Image:     url,
+			}
+			if err := productRepo.CreateProductGallery(&img); err != nil {
+				return err
+			}
+		}
+
+		if req.Variants != "" {
+			if err := saveVariants(productRepo, &product, req.ParsedOptions, req.ParsedVariants, req.ImageURLs, AdminActor(aid)); err != nil {
+				return err
+			}
+			// stok produk bervarian dihitung dari stok varian
+			if len(req.ParsedVariants) > 0 {
+				return nil
+			}
+		}
+		return productRepo.MoveStock(&models.StockMovement{
+			ProductID: product.ID,
+			Type:      StockInitial,
+			Quantity:  req.Stock,
+			ActorType: ActorAdmin,
+			ActorID:   &aid,
+			Reason:    "product created",
+		})
+	})
+	if err != nil {
+		return err
 	}
 
+	s.indexProduct(product.ID)
 	return nil
 }
 
-func (s *productService) UpdateProduct(productID string, req dto.UpdateProductRequest) error {
+func (s *productService) UpdateProduct(adminID, productID string, req dto.UpdateProductRequest) error {
+	aid, _ := uuid.Parse(adminID)
+
 	id, err := uuid.Parse(productID)
 	if err != nil {
 		return errors.New("invalid product ID")
@@ -72,47 +118,106 @@ func (s *productService) UpdateProduct(productID string, req dto.UpdateProductRe
 		return err
 	}
 
-	// Handle image update
-	if len(req.ImageURLs) > 0 {
-		for _, img := range existingProduct.ProductGallery {
-			utils.CleanupImageOnError(img.Image)
+	categoryID, err := uuid.Parse(req.CategoryID)
+	if err != nil {
+		return errors.New("invalid category ID")
+	}
+
+	// ** gambar lama baru dihapus dari storage setelah transaksi berhasil
+	oldImages := existingProduct.ProductGallery
+	images := req.ImageURLs
+	if len(images) == 0 {
+		for _, img := range oldImages {
+			images = append(images, img.Image)
+		}
+	}
+
+	err = s.productRepo.WithTx(func(tx *gorm.DB) error {
+		productRepo := repositories.NewProductRepository(tx)
+
+		if len(req.ImageURLs) > 0 {
+			if err := productRepo.DeleteProductGalleryByProductID(id); err != nil {
+				return err
+			}
+
+			for _, url := range req.ImageURLs {
+				if err := productRepo.CreateProductGallery(&models.ProductGallery{
+					ProductID: id,
+					Image:     url,
+				}); err != nil {
+					return err
+				}
+			}
+
+			// gambar varian menunjuk ke galeri lama
+			if err := productRepo.DeleteVariantImagesByProductID(id); err != nil {
+				return err
+			}
 		}
 
-		if err := s.productRepo.DeleteProductGalleryByProductID(id); err != nil {
+		existingProduct.Name = req.Name
+		existingProduct.Slug = utils.GenerateSlug(req.Name)
+		existingProduct.Description = req.Description
+		existingProduct.Price = req.Price
+		existingProduct.Weight = req.Weight
+		existingProduct.Length = req.Length
+		existingProduct.Width = req.Width
+		existingProduct.Height = req.Height
+		existingProduct.Discount = req.Discount
+		existingProduct.IsActive = req.IsActive
+		existingProduct.IsFeatured = req.IsFeatured
+		existingProduct.CategoryID = categoryID
+		existingProduct.LowStockThreshold = req.LowStockThreshold
+		// threshold baru dievaluasi ulang oleh pengecekan stok berikutnya
+		existingProduct.LowStockAlertedAt = nil
+
+		if err := productRepo.UpdateProduct(existingProduct); err != nil {
 			return err
 		}
 
-		for _, url := range req.ImageURLs {
-			if err := s.productRepo.CreateProductGallery(&models.ProductGallery{
-				ProductID: id,
-				Image:     url,
-			}); err != nil {
+		variantCount := len(existingProduct.Variants)
+		if req.Variants != "" {
+			if err := saveVariants(productRepo, existingProduct, req.ParsedOptions, req.ParsedVariants, images, AdminActor(aid)); err != nil {
 				return err
 			}
+			variantCount = len(req.ParsedVariants)
 		}
-	}
 
-	// Update fields
-	categoryID, err := uuid.Parse(req.CategoryID)
+		// stok produk bervarian dihitung dari stok varian
+		if variantCount > 0 {
+			return nil
+		}
+		return setProductStock(productRepo, id, req.Stock, AdminActor(aid))
+	})
 	if err != nil {
-		return errors.New("invalid category ID")
+		return err
 	}
 
-	existingProduct.Name = req.Name
-	existingProduct.Slug = utils.GenerateSlug(req.Name)
-	existingProduct.Description = req.Description
-	existingProduct.Price = req.Price
-	existingProduct.Stock = req.Stock
-	existingProduct.Weight = req.Weight
-	existingProduct.Length = req.Length
-	existingProduct.Width = req.Width
-	existingProduct.Height = req.Height
-	existingProduct.Discount = req.Discount
-	existingProduct.IsActive = req.IsActive
-	existingProduct.IsFeatured = req.IsFeatured
-	existingProduct.CategoryID = categoryID
-
-	return s.productRepo.UpdateProduct(existingProduct)
+	if len(req.ImageURLs) > 0 {
+		for _, img := range oldImages {
+			utils.CleanupImageOnError(img.Image)
+		}
+	}
+
+	s.indexProduct(id)
+	return nil
+}
+
+// setProductStock records the stock typed in the product form as a stock take
+func setProductStock(productRepo repositories.ProductRepository, productID uuid.UUID, stock int, actor OrderActor) error {
+	locked, err := productRepo.LockProductsForUpdate([]uuid.UUID{productID})
+	if err != nil || len(locked) == 0 {
+		return errors.New("product not found")
+	}
+
+	return productRepo.MoveStock(&models.StockMovement{
+		ProductID: productID,
+		Type:      StockTake,
+		Quantity:  stock - locked[0].Stock,
+		ActorType: actor.Type,
+		ActorID:   actor.ID,
+		Reason:    "stock updated from product form",
+	})
 }
 
 func (s *productService) DeleteProduct(productID string) error {
@@ -130,10 +235,14 @@ func (s *productService) DeleteProduct(productID string) error {
 		utils.CleanupImageOnError(img.Image)
 	}
 
-	return s.productRepo.DeleteProduct(id)
+	if err := s.productRepo.DeleteProduct(id); err != nil {
+		return err
+	}
+	s.suggester.RemoveProduct(id)
+	return s.searchIndex.Remove(id)
 }
 
-func (s *productService) GetProductBySlug(slug string) (*dto.ProductDetailResponse, error) {
+func (s *productService) GetProductBySlug(slug, viewerID string) (*dto.ProductDetailResponse, error) {
 	product, err := s.productRepo.GetProductBySlug(slug)
 	if err != nil {
 		return nil, err
@@ -142,33 +251,121 @@ func (s *productService) GetProductBySlug(slug string) (*dto.ProductDetailRespon
 	for _, img := range product.ProductGallery {
 		images = append(images, img.Image)
 	}
+	reservedByProduct, reservedByVariant, err := s.reservationRepo.GetReservedQuantities([]uuid.UUID{product.ID})
+	if err != nil {
+		return nil, err
+	}
+	wishlistCounts, err := s.wishlistRepo.CountByProducts([]uuid.UUID{product.ID})
+	if err != nil {
+		return nil, err
+	}
+	breadcrumbs, err := categoryBreadcrumbs(s.categoryRepo, product.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	recordProductView(product.ID, viewerID)
+
 	return &dto.ProductDetailResponse{
 		ID:            product.ID.String(),
 		Name:          product.Name,
 		Slug:          product.Slug,
 		Description:   product.Description,
 		Price:         product.Price,
+		FinalPrice:    pricing.PriceUnit(product.Price, product.Discount).Net.Float(),
 		Stock:         product.Stock,
+		Available:     availableStock(product.Stock, reservedByProduct[product.ID]),
+		WishlistCount: wishlistCounts[product.ID],
+		Sold:          product.Sold,
+		ViewCount:     product.ViewCount,
 		Weight:        product.Weight,
 		Height:        product.Height,
 		Width:         product.Width,
 		Length:        product.Length,
 		AverageRating: product.AverageRating,
+		ReviewCount:   product.ReviewCount,
 		Discount:      product.Discount,
 		CategoryID:    product.CategoryID.String(),
 		Category:      product.Category.Name,
 		Images:        images,
+		Options:       toOptionResponses(product.Options),
+		Variants:      toVariantResponses(product, reservedByVariant),
+
+		RatingHistogram: ratingHistogram(product),
+		Breadcrumbs:     breadcrumbs,
 	}, nil
 }
 
-func (s *productService) SearchProducts(params dto.GetAllProductsRequest) ([]dto.ProductListResponse, *dto.PaginationResponse, error) {
-	products, total, err := s.productRepo.SearchProducts(params)
+func (s *productService) SearchProducts(params dto.GetAllProductsRequest) ([]dto.ProductListResponse, *dto.PaginationResponse, *dto.SearchFacetsResponse, error) {
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.Limit <= 0 {
+		params.Limit = 10
+	}
+
+	// ** kategori induk ikut menampilkan produk dari semua subkategorinya
+	var categories []string
+	if params.Category != "" {
+		slugs, err := categorySubtreeSlugs(s.categoryRepo, params.Category)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		categories = slugs
+	}
+
+	found, err := s.searchIndex.Search(search.Query{
+		Text:       params.Search,
+		Categories: categories,
+		Status:     params.Status,
+		MinPrice:   params.MinPrice,
+		MaxPrice:   params.MaxPrice,
+		Rating:     params.Rating,
+		InStock:    params.InStock,
+		Sort:       params.Sort,
+		Page:       params.Page,
+		Limit:      params.Limit,
+	})
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	var result []dto.ProductListResponse
+	// ? halaman berikutnya dari pencarian yang sama tidak dicatat lagi
+	if params.Page == 1 && search.Normalize(params.Search) != "" {
+		go s.logSearch(params.Search, found.Total)
+	}
+
+	loaded, err := s.productRepo.GetProductsByIDs(found.IDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// ** urutan hasil mengikuti index pencarian, produk yang baru dihapus dilewati
+	byID := make(map[uuid.UUID]models.Product, len(loaded))
+	for _, p := range loaded {
+		byID[p.ID] = p
+	}
+	products := make([]models.Product, 0, len(found.IDs))
+	for _, id := range found.IDs {
+		if p, ok := byID[id]; ok {
+			products = append(products, p)
+		}
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(products))
 	for _, p := range products {
+		productIDs = append(productIDs, p.ID)
+	}
+	reservedByProduct, reservedByVariant, err := s.reservationRepo.GetReservedQuantities(productIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	wishlistCounts, err := s.wishlistRepo.CountByProducts(productIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var result []dto.ProductListResponse
+	for i := range products {
+		p := &products[i]
 		var imageURLs []string
 		for _, g := range p.ProductGallery {
 			imageURLs = append(imageURLs, g.Image)
@@ -179,7 +376,12 @@ func (s *productService) SearchProducts(params dto.GetAllProductsRequest) ([]dto
 			Name:          p.Name,
 			Slug:          p.Slug,
 			Stock:         p.Stock,
+			Available:     availableStock(p.Stock, reservedByProduct[p.ID]),
+			WishlistCount: wishlistCounts[p.ID],
+			Sold:          p.Sold,
+			ViewCount:     p.ViewCount,
 			Price:         p.Price,
+			FinalPrice:    pricing.PriceUnit(p.Price, p.Discount).Net.Float(),
 			Description:   p.Description,
 			Discount:      p.Discount,
 			IsActive:      p.IsActive,
@@ -188,24 +390,158 @@ func (s *productService) SearchProducts(params dto.GetAllProductsRequest) ([]dto
 			Width:         p.Width,
 			Length:        p.Length,
 			AverageRating: p.AverageRating,
+			ReviewCount:   p.ReviewCount,
 			CategoryID:    p.Category.ID.String(),
 			Category:      p.Category.Name,
 			IsFeatured:    p.IsFeatured,
 			Images:        imageURLs,
-		})
-	}
+			Options:       toOptionResponses(p.Options),
+			Variants:      toVariantResponses(p, reservedByVariant),
 
-	limit := params.Limit
-	if limit <= 0 {
-		limit = 10
+			LowStockThreshold: p.LowStockThreshold,
+		})
 	}
 
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	totalPages := int((found.Total + int64(params.Limit) - 1) / int64(params.Limit))
 
 	return result, &dto.PaginationResponse{
 		Page:       params.Page,
 		Limit:      params.Limit,
-		TotalRows:  int(total),
+		TotalRows:  int(found.Total),
 		TotalPages: totalPages,
-	}, nil
+	}, toSearchFacetsResponse(found.Facets), nil
+}
+
+// ReindexProducts rebuilds the search index from the database
+func (s *productService) ReindexProducts() (int, error) {
+	products, err := s.productRepo.GetSearchableProducts()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch products: %w", err)
+	}
+
+	docs := make([]search.Document, 0, len(products))
+	for i := range products {
+		docs = append(docs, search.NewDocument(&products[i]))
+	}
+	if err := s.searchIndex.Rebuild(docs); err != nil {
+		return 0, err
+	}
+	s.suggester.SetProducts(docs)
+	return len(docs), nil
+}
+
+// FlushProductViews writes the view counts buffered in Redis to the products and decays the trending scores.
+// Hash di-RENAME dulu, view yang masuk selama flush ditampung di hash baru untuk flush berikutnya.
+func (s *productService) FlushProductViews() (int, error) {
+	flushing := productViewsKey + ":flushing"
+
+	// ? hash :flushing yang masih ada berarti flush sebelumnya gagal di tengah, selesaikan dulu
+	leftover, err := config.RedisClient.Exists(config.Ctx, flushing).Result()
+	if err != nil {
+		return 0, err
+	}
+	if leftover == 0 {
+		pending, err := config.RedisClient.Exists(config.Ctx, productViewsKey).Result()
+		if err != nil {
+			return 0, err
+		}
+		if pending > 0 {
+			if err := config.RedisClient.Rename(config.Ctx, productViewsKey, flushing).Err(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	views, err := config.RedisClient.HGetAll(config.Ctx, flushing).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// skor lama diluruhkan dulu supaya view baru masuk dengan bobot penuh
+	if err := s.productRepo.DecayTrendingScores(trendingHalfLife); err != nil {
+		return 0, err
+	}
+
+	flushed := 0
+	for id, count := range views {
+		productID, err := uuid.Parse(id)
+		n, convErr := strconv.ParseInt(count, 10, 64)
+		if err == nil && convErr == nil && n > 0 {
+			if err := s.productRepo.AddViews(productID, n, float64(n)*trendingViewWeight); err != nil {
+				return flushed, err
+			}
+			flushed++
+		}
+		// ! hapus per field, kalau flush berhenti di tengah view yang sudah tercatat tidak dihitung dua kali
+		if err := config.RedisClient.HDel(config.Ctx, flushing, id).Err(); err != nil {
+			return flushed, err
+		}
+	}
+	return flushed, nil
+}
+
+// indexProduct refreshes the search copy of a saved product, on failure
+// search stays stale until the next reindex instead of failing the save
+func (s *productService) indexProduct(id uuid.UUID) {
+	product, err := s.productRepo.GetProductByID(id)
+	if err == nil {
+		doc := search.NewDocument(product)
+		s.suggester.IndexProduct(doc)
+		err = s.searchIndex.Index(doc)
+	}
+	if err != nil {
+		log.Printf("Failed to index product %s: %v", id, err)
+	}
+}
+
+// logSearch records a storefront search for popular query suggestions and the zero-result report
+func (s *productService) logSearch(query string, total int64) {
+	query = search.Normalize(query)
+	if runes := []rune(query); len(runes) > 255 {
+		query = string(runes[:255])
+	}
+	if err := s.searchQueryRepo.Create(&models.SearchQuery{Query: query, ResultCount: int(total)}); err != nil {
+		log.Printf("Failed to log search query %q: %v", query, err)
+	}
+}
+
+func toSearchFacetsResponse(facets search.Facets) *dto.SearchFacetsResponse {
+	response := &dto.SearchFacetsResponse{
+		Categories:  make([]dto.CategoryFacetResponse, 0, len(facets.Categories)),
+		PriceRanges: make([]dto.PriceFacetResponse, 0, len(facets.Prices)),
+		Ratings:     make([]dto.RatingFacetResponse, 0, len(facets.Ratings)),
+		Availability: dto.AvailabilityFacet{
+			InStock:    facets.InStock,
+			OutOfStock: facets.OutOfStock,
+		},
+	}
+	for _, c := range facets.Categories {
+		response.Categories = append(response.Categories, dto.CategoryFacetResponse{Slug: c.Slug, Name: c.Name, Count: c.Count})
+	}
+	for _, p := range facets.Prices {
+		price := dto.PriceFacetResponse{Min: p.Min, Count: p.Count}
+		if p.Max > 0 {
+			upper := p.Max
+			price.Max = &upper
+		}
+		response.PriceRanges = append(response.PriceRanges, price)
+	}
+	for _, r := range facets.Ratings {
+		response.Ratings = append(response.Ratings, dto.RatingFacetResponse{MinRating: r.MinRating, Count: r.Count})
+	}
+	return response
+}
+
+// ratingHistogram maps every star from 1 to 5 to its review count, missing stars count as zero
+func ratingHistogram(product *models.Product) map[int]int {
+	histogram := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	if len(product.RatingHistogram) == 0 {
+		return histogram
+	}
+	for i, count := range utils.ParseJSONToIntSlice(string(product.RatingHistogram)) {
+		if i < 5 {
+			histogram[i+1] = count
+		}
+	}
+	return histogram
 }
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/pricing"
+	"server/internal/repositories"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrVariantRequired    = errors.New("please choose a product variant")
+	ErrVariantUnavailable = errors.New("product variant is not available")
+	ErrOutOfStock         = errors.New("product is out of stock")
+	ErrProductUnavailable = errors.New("product is no longer available")
+)
+
+// parseVariantID treats an empty string as "no variant"
+func parseVariantID(raw string) (*uuid.UUID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, errors.New("invalid variant ID")
+	}
+	return &id, nil
+}
+
+// findVariant checks the variant choice against the product.
+// Products with variants must be bought through one of their active variants.
+func findVariant(product *models.Product, variantID *uuid.UUID) (*models.ProductVariant, error) {
+	if len(product.Variants) == 0 {
+		if variantID != nil {
+			return nil, ErrVariantUnavailable
+		}
+		return nil, nil
+	}
+	if variantID == nil {
+		return nil, ErrVariantRequired
+	}
+
+	for i := range product.Variants {
+		v := &product.Variants[i]
+		if v.ID == *variantID {
+			if !v.IsActive {
+				return nil, ErrVariantUnavailable
+			}
+			return v, nil
+		}
+	}
+	return nil, ErrVariantUnavailable
+}
+
+func variantPrice(product *models.Product, variant *models.ProductVariant) float64 {
+	if variant != nil && variant.Price != nil {
+		return *variant.Price
+	}
+	return product.Price
+}
+
+func variantWeight(product *models.Product, variant *models.ProductVariant) float64 {
+	if variant != nil && variant.Weight != nil {
+		return *variant.Weight
+	}
+	return product.Weight
+}
+
+func variantStock(product *models.Product, variant *models.ProductVariant) int {
+	if variant != nil {
+		return variant.Stock
+	}
+	return product.Stock
+}
+
+func variantImage(product *models.Product, variant *models.ProductVariant) string {
+	if variant != nil && len(variant.Images) > 0 {
+		return variant.Images[0].Image
+	}
+	if len(product.ProductGallery) > 0 {
+		return product.ProductGallery[0].Image
+	}
+	return ""
+}
+
+// variantLabel renders the option values in option order, e.g. "Size: M, Color: Black"
+func variantLabel(variant *models.ProductVariant) string {
+	if variant == nil {
+		return ""
+	}
+
+	values := append([]models.ProductVariantValue(nil), variant.Values...)
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].OptionValue.Option.Position < values[j].OptionValue.Option.Position
+	})
+
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, fmt.Sprintf("%s: %s", v.OptionValue.Option.Name, v.OptionValue.Value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func toOptionResponses(options []models.ProductOption) []dto.ProductOptionResponse {
+	result := make([]dto.ProductOptionResponse, 0, len(options))
+	for _, o := range options {
+		values := make([]string, 0, len(o.Values))
+		for _, v := range o.Values {
+			values = append(values, v.Value)
+		}
+		result = append(result, dto.ProductOptionResponse{Name: o.Name, Values: values})
+	}
+	return result
+}
+
+func toVariantResponses(product *models.Product, reserved map[uuid.UUID]int) []dto.ProductVariantResponse {
+	result := make([]dto.ProductVariantResponse, 0, len(product.Variants))
+	for i := range product.Variants {
+		v := &product.Variants[i]
+
+		options := make(map[string]string, len(v.Values))
+		for _, val := range v.Values {
+			options[val.OptionValue.Option.Name] = val.OptionValue.Value
+		}
+
+		images := make([]string, 0, len(v.Images))
+		for _, img := range v.Images {
+			images = append(images, img.Image)
+		}
+
+		result = append(result, dto.ProductVariantResponse{
+			ID:         v.ID.String(),
+			SKU:        v.SKU,
+			Options:    options,
+			Price:      variantPrice(product, v),
+			FinalPrice: pricing.PriceUnit(variantPrice(product, v), product.Discount).Net.Float(),
+			Stock:      v.Stock,
+			Available:  availableStock(v.Stock, reserved[v.ID]),
+			Weight:     variantWeight(product, v),
+			IsActive:   v.IsActive,
+			Images:     images,
+		})
+	}
+	return result
+}
+
+func validateVariants(options []dto.ProductOptionRequest, variants []dto.ProductVariantRequest, imageCount int) error {
+	if len(options) > 0 && len(variants) == 0 {
+		return errors.New("options need at least one variant")
+	}
+	if len(variants) > 0 && len(options) == 0 {
+		return errors.New("variants need at least one option")
+	}
+
+	valuesByOption := make(map[string]map[string]bool, len(options))
+	for _, o := range options {
+		name := strings.TrimSpace(o.Name)
+		if name == "" || len(o.Values) == 0 {
+			return errors.New("option name and values are required")
+		}
+		if valuesByOption[name] != nil {
+			return fmt.Errorf("duplicate option %s", name)
+		}
+		valuesByOption[name] = make(map[string]bool, len(o.Values))
+		for _, v := range o.Values {
+			v = strings.TrimSpace(v)
+			if v == "" || valuesByOption[name][v] {
+				return fmt.Errorf("invalid or duplicate value for option %s", name)
+			}
+			valuesByOption[name][v] = true
+		}
+	}
+
+	skus := make(map[string]bool, len(variants))
+	combinations := make(map[string]bool, len(variants))
+	for _, v := range variants {
+		sku := strings.TrimSpace(v.SKU)
+		if sku == "" {
+			return errors.New("variant SKU is required")
+		}
+		if skus[sku] {
+			return fmt.Errorf("duplicate SKU %s", sku)
+		}
+		skus[sku] = true
+
+		if v.Stock < 0 {
+			return fmt.Errorf("stock of %s cannot be negative", sku)
+		}
+		if v.Price != nil && *v.Price <= 0 {
+			return fmt.Errorf("price of %s must be greater than zero", sku)
+		}
+		if v.Weight != nil && *v.Weight <= 0 {
+			return fmt.Errorf("weight of %s must be greater than zero", sku)
+		}
+
+		if len(v.Options) != len(options) {
+			return fmt.Errorf("variant %s must pick one value for every option", sku)
+		}
+		key := make([]string, 0, len(options))
+		for _, o := range options {
+			name := strings.TrimSpace(o.Name)
+			value, ok := v.Options[name]
+			if !ok || !valuesByOption[name][strings.TrimSpace(value)] {
+				return fmt.Errorf("variant %s has an invalid value for %s", sku, name)
+			}
+			key = append(key, strings.TrimSpace(value))
+		}
+		combination := strings.Join(key, "\x00")
+		if combinations[combination] {
+			return fmt.Errorf("variant %s duplicates another variant", sku)
+		}
+		combinations[combination] = true
+
+		for _, idx := range v.ImageIndexes {
+			if idx < 0 || idx >= imageCount {
+				return fmt.Errorf("variant %s points at an unknown image", sku)
+			}
+		}
+	}
+
+	return nil
+}
+
+// saveVariants replaces the option list and upserts variants by SKU so carts keep
+// pointing at the same rows. Variants missing from the request are soft deleted.
+// Stock changes go through the ledger. Must run inside a transaction.
+func saveVariants(productRepo repositories.ProductRepository, product *models.Product, options []dto.ProductOptionRequest, variants []dto.ProductVariantRequest, images []string, actor OrderActor) error {
+	if err := validateVariants(options, variants, len(images)); err != nil {
+		return err
+	}
+
+	locked, err := productRepo.LockProductsForUpdate([]uuid.UUID{product.ID})
+	if err != nil || len(locked) == 0 {
+		return errors.New("product not found")
+	}
+	productStock := locked[0].Stock
+
+	if err := productRepo.DeleteProductOptions(product.ID); err != nil {
+		return err
+	}
+
+	optionModels := make([]models.ProductOption, 0, len(options))
+	valueIDs := make(map[string]map[string]uuid.UUID, len(options))
+	for i, o := range options {
+		name := strings.TrimSpace(o.Name)
+		option := models.ProductOption{ID: uuid.New(), ProductID: product.ID, Name: name, Position: i}
+		valueIDs[name] = make(map[string]uuid.UUID, len(o.Values))
+		for j, v := range o.Values {
+			value := models.ProductOptionValue{ID: uuid.New(), OptionID: option.ID, Value: strings.TrimSpace(v), Position: j}
+			valueIDs[name][value.Value] = value.ID
+			option.Values = append(option.Values, value)
+		}
+		optionModels = append(optionModels, option)
+	}
+	if err := productRepo.CreateProductOptions(optionModels); err != nil {
+		return err
+	}
+
+	skus := make([]string, 0, len(variants))
+	for _, v := range variants {
+		skus = append(skus, strings.TrimSpace(v.SKU))
+	}
+	existing, err := productRepo.GetVariantsBySKU(skus)
+	if err != nil {
+		return err
+	}
+	existingBySKU := make(map[string]models.ProductVariant, len(existing))
+	for _, v := range existing {
+		if v.ProductID != product.ID {
+			return fmt.Errorf("SKU %s is already used by another product", v.SKU)
+		}
+		existingBySKU[v.SKU] = v
+	}
+
+	ids := make([]uuid.UUID, 0, len(product.Variants))
+	for _, v := range product.Variants {
+		ids = append(ids, v.ID)
+	}
+	lockedVariants, err := productRepo.LockVariantsForUpdate(ids)
+	if err != nil {
+		return err
+	}
+	currentStock := make(map[uuid.UUID]int, len(lockedVariants))
+	for _, v := range lockedVariants {
+		currentStock[v.ID] = v.Stock
+	}
+
+	moved := 0
+	move := func(variantID *uuid.UUID, movementType string, qty int, reason string) error {
+		moved += qty
+		return productRepo.MoveStock(&models.StockMovement{
+			ProductID: product.ID,
+			VariantID: variantID,
+			Type:      movementType,
+			Quantity:  qty,
+			ActorType: actor.Type,
+			ActorID:   actor.ID,
+			Reason:    reason,
+		})
+	}
+
+	kept := make(map[uuid.UUID]bool, len(variants))
+	total := 0
+	for _, v := range variants {
+		variant := models.ProductVariant{
+			ProductID: product.ID,
+			SKU:       strings.TrimSpace(v.SKU),
+			Price:     v.Price,
+			Weight:    v.Weight,
+			IsActive:  v.IsActive == nil || *v.IsActive,
+		}
+		for _, o := range options {
+			name := strings.TrimSpace(o.Name)
+			variant.Values = append(variant.Values, models.ProductVariantValue{
+				OptionValueID: valueIDs[name][strings.TrimSpace(v.Options[name])],
+			})
+		}
+		for _, idx := range v.ImageIndexes {
+			variant.Images = append(variant.Images, models.ProductVariantImage{Image: images[idx]})
+		}
+
+		movementType, reason := StockInitial, "variant created"
+		delta := v.Stock
+		if old, ok := existingBySKU[variant.SKU]; ok {
+			variant.ID = old.ID
+			variant.CreatedAt = old.CreatedAt
+			if err := productRepo.UpdateVariant(&variant); err != nil {
+				return err
+			}
+
+			stock, ok := currentStock[old.ID]
+			if !ok {
+				stock = old.Stock // varian yang dihapus lalu dipakai lagi
+			}
+			movementType, reason = StockTake, "stock updated from product form"
+			delta = v.Stock - stock
+		} else if err := productRepo.CreateVariant(&variant); err != nil {
+			return err
+		}
+
+		if err := move(&variant.ID, movementType, delta, reason); err != nil {
+			return err
+		}
+		kept[variant.ID] = true
+		total += v.Stock
+	}
+
+	var removed []uuid.UUID
+	for _, v := range product.Variants {
+		if kept[v.ID] {
+			continue
+		}
+		if err := move(&v.ID, StockAdjustment, -currentStock[v.ID], "variant removed"); err != nil {
+			return err
+		}
+		removed = append(removed, v.ID)
+	}
+	if err := productRepo.DeleteVariants(removed); err != nil {
+		return err
+	}
+
+	if len(variants) == 0 {
+		return nil
+	}
+
+	// ** stok produk harus sama dengan total stok varian, sisa stok lama tanpa varian dikeluarkan
+	if leftover := productStock + moved - total; leftover != 0 {
+		return move(nil, StockTake, -leftover, "stock moved to variants")
+	}
+	return nil
+}
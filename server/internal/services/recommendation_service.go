@@ -0,0 +1,297 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"server/internal/config"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/pricing"
+	"server/internal/repositories"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrProductNotFound = errors.New("product not found")
+
+const (
+	// ** pasangan produk dihitung dari order 180 hari terakhir
+	affinityWindow       = 180 * 24 * time.Hour
+	affinitiesPerProduct = 20
+	minCoPurchaseOrders  = 2 // satu order bersama masih bisa kebetulan
+
+	defaultRecommendationLimit = 10
+	personalSeedLimit          = 20
+	viewedSeedWeight           = 0.5 // produk yang hanya dilihat bobotnya separuh produk yang dibeli
+)
+
+type RecommendationService interface {
+	GetRelated(slug string, param dto.RecommendationQueryParam) ([]dto.RecommendedProductResponse, error)
+	GetBoughtTogether(slug string, param dto.RecommendationQueryParam) ([]dto.RecommendedProductResponse, error)
+	GetRecommendedForYou(userID string, param dto.RecommendationQueryParam) ([]dto.RecommendedProductResponse, error)
+	RebuildAffinities() (int, error)
+}
+
+type recommendationService struct {
+	recommendationRepo repositories.RecommendationRepository
+	productRepo        repositories.ProductRepository
+}
+
+func NewRecommendationService(recommendationRepo repositories.RecommendationRepository, productRepo repositories.ProductRepository) RecommendationService {
+	return &recommendationService{recommendationRepo, productRepo}
+}
+
+// RebuildAffinities recomputes which products are bought together from the order history.
+// Skor memakai cosine similarity, produk terlaris tidak otomatis jadi pasangan semua produk.
+func (s *recommendationService) RebuildAffinities() (int, error) {
+	since := time.Now().Add(-affinityWindow)
+	pairs, err := s.recommendationRepo.GetCoPurchases(since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count co-purchases: %w", err)
+	}
+	orderCounts, err := s.recommendationRepo.GetProductOrderCounts(since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count product orders: %w", err)
+	}
+
+	byProduct := make(map[uuid.UUID][]models.ProductAffinity)
+	for _, pair := range pairs {
+		if pair.Orders < minCoPurchaseOrders {
+			continue
+		}
+		pair.Score = float64(pair.Orders) / math.Sqrt(float64(orderCounts[pair.ProductID]*orderCounts[pair.RelatedProductID]))
+		byProduct[pair.ProductID] = append(byProduct[pair.ProductID], pair)
+	}
+
+	var affinities []models.ProductAffinity
+	for _, related := range byProduct {
+		sort.Slice(related, func(i, j int) bool {
+			if related[i].Score != related[j].Score {
+				return related[i].Score > related[j].Score
+			}
+			return related[i].Orders > related[j].Orders
+		})
+		affinities = append(affinities, related[:min(len(related), affinitiesPerProduct)]...)
+	}
+
+	if err := s.recommendationRepo.ReplaceAffinities(affinities); err != nil {
+		return 0, fmt.Errorf("failed to save affinities: %w", err)
+	}
+	return len(affinities), nil
+}
+
+// GetRelated returns the best rated products of the same category
+func (s *recommendationService) GetRelated(slug string, param dto.RecommendationQueryParam) ([]dto.RecommendedProductResponse, error) {
+	product, err := s.productRepo.GetProductBySlug(slug)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+
+	related, err := s.recommendationRepo.GetTopRatedProducts([]uuid.UUID{product.CategoryID}, []uuid.UUID{product.ID}, recommendationLimit(param))
+	if err != nil {
+		return nil, err
+	}
+	return toRecommendedProducts(related), nil
+}
+
+// GetBoughtTogether returns what other customers bought with the product,
+// topped up with related products while there is not enough order history
+func (s *recommendationService) GetBoughtTogether(slug string, param dto.RecommendationQueryParam) ([]dto.RecommendedProductResponse, error) {
+	product, err := s.productRepo.GetProductBySlug(slug)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+	limit := recommendationLimit(param)
+
+	affinities, err := s.recommendationRepo.GetAffinities([]uuid.UUID{product.ID})
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[uuid.UUID]float64, len(affinities))
+	for _, a := range affinities {
+		scores[a.RelatedProductID] = a.Score
+	}
+
+	products, err := s.rankedProducts(scores, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) < limit {
+		fallback, err := s.recommendationRepo.GetTopRatedProducts([]uuid.UUID{product.CategoryID}, append(productIDs(products), product.ID), limit-len(products))
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, fallback...)
+	}
+	return toRecommendedProducts(products), nil
+}
+
+// GetRecommendedForYou ranks products bought together with what the user bought and recently viewed.
+// Produk yang sudah dibeli tidak direkomendasikan lagi. Kalau riwayatnya belum cukup, diisi
+// produk terbaik dari kategori yang sama lalu produk yang sedang trending.
+func (s *recommendationService) GetRecommendedForYou(userID string, param dto.RecommendationQueryParam) ([]dto.RecommendedProductResponse, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+	limit := recommendationLimit(param)
+
+	purchased, err := s.recommendationRepo.GetPurchasedProductIDs(uid, personalSeedLimit)
+	if err != nil {
+		return nil, err
+	}
+	seeds := make(map[uuid.UUID]float64)
+	for _, id := range purchased {
+		seeds[id] = 1
+	}
+	for _, id := range recentlyViewed(userID) {
+		if _, ok := seeds[id]; !ok {
+			seeds[id] = viewedSeedWeight
+		}
+	}
+
+	seedIDs := make([]uuid.UUID, 0, len(seeds))
+	for id := range seeds {
+		seedIDs = append(seedIDs, id)
+	}
+	affinities, err := s.recommendationRepo.GetAffinities(seedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[uuid.UUID]float64)
+	for _, a := range affinities {
+		scores[a.RelatedProductID] += seeds[a.ProductID] * a.Score
+	}
+	for _, id := range purchased {
+		delete(scores, id)
+	}
+
+	products, err := s.rankedProducts(scores, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := append(productIDs(products), purchased...)
+	if len(products) < limit && len(seedIDs) > 0 {
+		seedProducts, err := s.productRepo.GetProductsByIDs(seedIDs)
+		if err != nil {
+			return nil, err
+		}
+		var categoryIDs []uuid.UUID
+		for _, p := range seedProducts {
+			categoryIDs = append(categoryIDs, p.CategoryID)
+		}
+
+		fallback, err := s.recommendationRepo.GetTopRatedProducts(categoryIDs, exclude, limit-len(products))
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, fallback...)
+		exclude = append(exclude, productIDs(fallback)...)
+	}
+	if len(products) < limit {
+		trending, err := s.recommendationRepo.GetTrendingProducts(exclude, limit-len(products))
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, trending...)
+	}
+	return toRecommendedProducts(products), nil
+}
+
+// rankedProducts loads the highest scored products that can still be bought
+func (s *recommendationService) rankedProducts(scores map[uuid.UUID]float64, limit int) ([]models.Product, error) {
+	ids := make([]uuid.UUID, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i].String() < ids[j].String()
+	})
+
+	loaded, err := s.productRepo.GetProductsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.Product, len(loaded))
+	for _, p := range loaded {
+		byID[p.ID] = p
+	}
+
+	products := make([]models.Product, 0, limit)
+	for _, id := range ids {
+		if p, ok := byID[id]; ok && p.IsActive && p.Stock > 0 {
+			products = append(products, p)
+		}
+		if len(products) == limit {
+			break
+		}
+	}
+	return products, nil
+}
+
+// recentlyViewed reads the user's recently viewed products, without Redis the feed uses purchases only
+func recentlyViewed(userID string) []uuid.UUID {
+	values, err := config.RedisClient.LRange(config.Ctx, recentViewsKey(userID), 0, personalSeedLimit-1).Result()
+	if err != nil {
+		log.Printf("Failed to read recent views of user %s: %v\n", userID, err)
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(values))
+	for _, v := range values {
+		if id, err := uuid.Parse(v); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func recommendationLimit(param dto.RecommendationQueryParam) int {
+	if param.Limit <= 0 {
+		return defaultRecommendationLimit
+	}
+	return param.Limit
+}
+
+func productIDs(products []models.Product) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func toRecommendedProducts(products []models.Product) []dto.RecommendedProductResponse {
+	result := make([]dto.RecommendedProductResponse, 0, len(products))
+	for _, p := range products {
+		var image string
+		if len(p.ProductGallery) > 0 {
+			image = p.ProductGallery[0].Image
+		}
+
+		result = append(result, dto.RecommendedProductResponse{
+			ID:            p.ID.String(),
+			Name:          p.Name,
+			Slug:          p.Slug,
+			Image:         image,
+			Category:      p.Category.Name,
+			Price:         p.Price,
+			FinalPrice:    pricing.PriceUnit(p.Price, p.Discount).Net.Float(),
+			Discount:      p.Discount,
+			AverageRating: p.AverageRating,
+			ReviewCount:   p.ReviewCount,
+			Sold:          p.Sold,
+		})
+	}
+	return result
+}
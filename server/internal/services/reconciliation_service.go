@@ -0,0 +1,339 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/pricing"
+	"server/internal/repositories"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// beri waktu webhook untuk datang sebelum gateway ditanya
+	reconcileGracePeriod = 30 * time.Minute
+	pendingPaymentExpiry = 24 * time.Hour
+	failedLookback       = 72 * time.Hour
+)
+
+const (
+	ReconcileUnchanged      = "unchanged"
+	ReconcileMarkedPaid     = "marked_paid"
+	ReconcileMarkedFailed   = "marked_failed"
+	ReconcileRefunded       = "refunded"
+	ReconcileAmountMismatch = "amount_mismatch"
+	ReconcileManualReview   = "manual_review"
+	ReconcileError          = "error"
+)
+
+type ReconciliationService interface {
+	Reconcile() (*dto.ReconciliationSummary, error)
+	GetReports(param dto.ReconciliationQueryParam) ([]models.Reconciliation, *dto.PaginationResponse, error)
+	ExportReports(param dto.ReconciliationQueryParam) ([]byte, error)
+}
+
+type reconciliationService struct {
+	reconciliationRepo  repositories.ReconciliationRepository
+	paymentRepo         repositories.PaymentRepository
+	orderRepo           repositories.OrderRepository
+	notificationService NotificationService
+	gateway             PaymentGateway
+}
+
+func NewReconciliationService(
+	reconciliationRepo repositories.ReconciliationRepository,
+	paymentRepo repositories.PaymentRepository,
+	orderRepo repositories.OrderRepository,
+	notificationService NotificationService,
+	gateway PaymentGateway,
+) ReconciliationService {
+	return &reconciliationService{
+		reconciliationRepo:  reconciliationRepo,
+		paymentRepo:         paymentRepo,
+		orderRepo:           orderRepo,
+		notificationService: notificationService,
+		gateway:             gateway,
+	}
+}
+
+// Reconcile asks the gateway about every stale pending payment and every recently
+// failed one, fixes the local state when they disagree and stores a report row for every
+// payment that needed action. Unchanged payments only count towards the summary.
+func (s *reconciliationService) Reconcile() (*dto.ReconciliationSummary, error) {
+	now := time.Now()
+	runID := uuid.New()
+	summary := &dto.ReconciliationSummary{RunID: runID.String()}
+
+	pending, err := s.paymentRepo.GetStaleGatewayPayments("pending", time.Time{}, now.Add(-reconcileGracePeriod))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending payments: %w", err)
+	}
+
+	failed, err := s.paymentRepo.GetStaleGatewayPayments("failed", now.Add(-failedLookback), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch failed payments: %w", err)
+	}
+
+	var results []models.Reconciliation
+	for i := range pending {
+		results = append(results, s.reconcilePending(runID, &pending[i], now))
+	}
+	for i := range failed {
+		results = append(results, s.reconcileFailed(runID, &failed[i]))
+	}
+
+	reports := make([]models.Reconciliation, 0, len(results))
+	for _, r := range results {
+		summary.Checked++
+		switch r.Action {
+		case ReconcileMarkedPaid:
+			summary.MarkedPaid++
+		case ReconcileMarkedFailed:
+			summary.Failed++
+		case ReconcileRefunded:
+			summary.Refunded++
+		case ReconcileAmountMismatch, ReconcileManualReview:
+			summary.NeedReview++
+		case ReconcileError:
+			summary.Errors++
+		}
+
+		// ? hanya yang perlu diperhatikan admin yang disimpan, pembayaran yang tidak berubah cukup dihitung
+		if r.Action == ReconcileUnchanged {
+			continue
+		}
+		// ? selisih nominal yang sama cukup dilaporkan sekali, bukan di setiap run cron
+		if r.Action == ReconcileAmountMismatch {
+			known, err := s.reconciliationRepo.HasReport(r.PaymentID, r.Action, r.GatewayAmount)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check earlier reports: %w", err)
+			}
+			if known {
+				continue
+			}
+		}
+		reports = append(reports, r)
+	}
+
+	if err := s.reconciliationRepo.CreateReports(reports); err != nil {
+		return nil, fmt.Errorf("failed to save reconciliation report: %w", err)
+	}
+
+	return summary, nil
+}
+
+func (s *reconciliationService) reconcilePending(runID uuid.UUID, p *models.Payment, now time.Time) models.Reconciliation {
+	report := newReconciliation(runID, p)
+	order := p.Order
+	expired := now.Sub(p.PaidAt) > pendingPaymentExpiry
+
+	status, err := s.gateway.QueryStatus(p.OrderID.String())
+	switch {
+	case errors.Is(err, ErrTransactionNotFound):
+		// customer never picked a payment method on the gateway page
+		if !expired {
+			return withAction(report, ReconcileUnchanged, "not found at gateway yet")
+		}
+		return s.expire(report, &order, "not found at gateway and expired")
+	case err != nil:
+		return withAction(report, ReconcileError, err.Error())
+	}
+
+	report.GatewayStatus = status.Status
+	report.GatewayRawStatus = status.RawStatus
+	report.GatewayAmount = status.GrossAmount
+
+	switch status.Status {
+	case "success":
+		// ! jangan tandai lunas kalau nominal berbeda, bisa jadi pembayaran sebagian
+		if err := checkGrossAmount(status.GrossAmount, &order); err != nil {
+			return withAction(report, ReconcileAmountMismatch, err.Error())
+		}
+		err := s.orderRepo.WithTx(func(tx *gorm.DB) error {
+			if err := repositories.NewPaymentRepository(tx).UpdatePaymentStatus(p.OrderID, "pending", "success"); err != nil {
+				return err
+			}
+			return TransitionOrder(repositories.NewOrderRepository(tx), &order, OrderPending, SystemActor(), "reconciled: payment "+status.RawStatus)
+		})
+		if errors.Is(err, ErrIllegalTransition) {
+			return withAction(report, ReconcileManualReview, "payment settled but order could not be moved: "+err.Error())
+		}
+		if err != nil {
+			return withAction(report, ReconcileError, err.Error())
+		}
+
+		s.notify(p.UserID.String(), "order_processed", "Payment Successfully Received",
+			fmt.Sprintf("Thank you %s, your payment for order %s has been received and is being processed.", p.Fullname, order.InvoiceNumber))
+		return withAction(report, ReconcileMarkedPaid, "webhook missed, settled at gateway")
+
+	case "failed":
+		if err := failUnpaidOrder(s.orderRepo, &order, SystemActor(), "reconciled: payment "+status.RawStatus); err != nil {
+			return withAction(report, ReconcileError, err.Error())
+		}
+		return withAction(report, ReconcileMarkedFailed, "gateway reported "+status.RawStatus)
+
+	case "refunded":
+		// ? dana sudah dibayar lalu dikembalikan di luar aplikasi, admin yang memutuskan nasib order
+		return withAction(report, ReconcileManualReview, "refunded at gateway while still pending here")
+
+	default:
+		if !expired {
+			return withAction(report, ReconcileUnchanged, "still pending at gateway")
+		}
+		if err := s.gateway.CancelCharge(p.OrderID.String()); err != nil {
+			// transaksi masih bisa dibayar, jangan batalkan order dulu
+			return withAction(report, ReconcileError, "failed to cancel expired charge: "+err.Error())
+		}
+		return s.expire(report, &order, "pending too long, charge canceled")
+	}
+}
+
+func (s *reconciliationService) expire(report models.Reconciliation, order *models.Order, note string) models.Reconciliation {
+	if err := failUnpaidOrder(s.orderRepo, order, SystemActor(), "payment expired"); err != nil {
+		return withAction(report, ReconcileError, err.Error())
+	}
+	return withAction(report, ReconcileMarkedFailed, note)
+}
+
+// reconcileFailed looks for money that reached the gateway after we already gave up on the order
+func (s *reconciliationService) reconcileFailed(runID uuid.UUID, p *models.Payment) models.Reconciliation {
+	report := newReconciliation(runID, p)
+
+	status, err := s.gateway.QueryStatus(p.OrderID.String())
+	switch {
+	case errors.Is(err, ErrTransactionNotFound):
+		return withAction(report, ReconcileUnchanged, "not found at gateway")
+	case err != nil:
+		return withAction(report, ReconcileError, err.Error())
+	}
+
+	report.GatewayStatus = status.Status
+	report.GatewayRawStatus = status.RawStatus
+	report.GatewayAmount = status.GrossAmount
+
+	if status.Status != "success" {
+		return withAction(report, ReconcileUnchanged, "")
+	}
+
+	// stok dan voucher order ini sudah dikembalikan, jadi dana harus direfund
+	amount, err := strconv.ParseFloat(status.GrossAmount, 64)
+	if err != nil || checkGrossAmount(status.GrossAmount, &p.Order) != nil {
+		return withAction(report, ReconcileAmountMismatch, "settled on a failed order with an unexpected amount")
+	}
+
+	result, err := s.gateway.Refund(p.OrderID.String(), "reconcile-"+p.OrderID.String(), int64(amount), "order was canceled before payment settled")
+	if err != nil {
+		return withAction(report, ReconcileManualReview, "settled on a failed order, refund failed: "+err.Error())
+	}
+
+	err = s.orderRepo.WithTx(func(tx *gorm.DB) error {
+		paymentRepo := repositories.NewPaymentRepository(tx)
+		if err := paymentRepo.UpdatePaymentStatus(p.OrderID, "failed", "success"); err != nil {
+			return err
+		}
+		if err := paymentRepo.AddRefundedAmount(p.OrderID, pricing.FromFloat(amount)); err != nil {
+			return err
+		}
+		return repositories.NewOrderRepository(tx).AddRefundedAmount(p.OrderID, amount)
+	})
+	if err != nil {
+		return withAction(report, ReconcileManualReview, fmt.Sprintf("refund %s sent but not recorded: %v", result.Reference, err))
+	}
+
+	s.notify(p.UserID.String(), "order_canceled", "Payment Refunded",
+		fmt.Sprintf("Your payment for canceled order %s arrived late and has been refunded.", p.Order.InvoiceNumber))
+	return withAction(report, ReconcileRefunded, "settled on a failed order, refund "+result.Reference)
+}
+
+func (s *reconciliationService) GetReports(param dto.ReconciliationQueryParam) ([]models.Reconciliation, *dto.PaginationResponse, error) {
+	if param.Page <= 0 {
+		param.Page = 1
+	}
+	if param.Limit <= 0 {
+		param.Limit = 10
+	}
+
+	reports, total, err := s.reconciliationRepo.GetReports(param)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPages := int((total + int64(param.Limit) - 1) / int64(param.Limit))
+	pagination := &dto.PaginationResponse{
+		Page:       param.Page,
+		Limit:      param.Limit,
+		TotalRows:  int(total),
+		TotalPages: totalPages,
+	}
+
+	return reports, pagination, nil
+}
+
+func (s *reconciliationService) ExportReports(param dto.ReconciliationQueryParam) ([]byte, error) {
+	reports, err := s.reconciliationRepo.GetAllReports(param)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"run_id", "created_at", "payment_id", "order_id", "method", "local_status", "gateway_status", "gateway_raw_status", "amount", "gateway_amount", "action", "note"})
+	for _, r := range reports {
+		w.Write([]string{
+			r.RunID.String(),
+			r.CreatedAt.Format(time.RFC3339),
+			r.PaymentID.String(),
+			r.OrderID.String(),
+			r.Method,
+			r.LocalStatus,
+			r.GatewayStatus,
+			r.GatewayRawStatus,
+			strconv.FormatFloat(r.Amount, 'f', 2, 64),
+			r.GatewayAmount,
+			r.Action,
+			r.Note,
+		})
+	}
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+func (s *reconciliationService) notify(userID, notifType, title, message string) {
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+	notification := dto.NotificationEvent{
+		UserID:  userID,
+		Type:    notifType,
+		Title:   title,
+		Message: message,
+	}
+	if err := s.notificationService.SendToUser(notification); err != nil {
+		log.Printf("Failed sending notification to user %s: %v", notification.UserID, err)
+	}
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+}
+
+func newReconciliation(runID uuid.UUID, p *models.Payment) models.Reconciliation {
+	return models.Reconciliation{
+		RunID:       runID,
+		PaymentID:   p.ID,
+		OrderID:     p.OrderID,
+		Method:      p.Method,
+		LocalStatus: p.Status,
+		Amount:      p.Total,
+	}
+}
+
+func withAction(report models.Reconciliation, action, note string) models.Reconciliation {
+	report.Action = action
+	report.Note = note
+	return report
+}
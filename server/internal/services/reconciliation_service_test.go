@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"server/internal/models"
+	"server/internal/repositories"
+	"testing"
+	"time"
+)
+
+// settledGateway reports one order as settled for amount, without an amount or for any other order it knows nothing
+type settledGateway struct {
+	*FakeGateway
+	orderID string
+	amount  string
+}
+
+func (g *settledGateway) QueryStatus(orderID string) (*PaymentStatus, error) {
+	if orderID != g.orderID || g.amount == "" {
+		return nil, ErrTransactionNotFound
+	}
+	return midtransStatus(orderID, "settlement", "", "bank_transfer", g.amount), nil
+}
+
+func TestReconcilePendingPayment(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("QUOTE_SECRET", "reconcile-test-secret")
+
+	gateway := &settledGateway{FakeGateway: NewFakeGateway("http://localhost:5002", "fake-secret")}
+	svc := newCheckoutServices(db, gateway)
+	reconciliation := NewReconciliationService(repositories.NewReconciliationRepository(db), svc.repo.PaymentRepository, svc.repo.OrderRepository, svc.notifications, gateway)
+
+	user, _ := seedCustomer(t, db, 5, 2)
+	_, payment := svc.checkout(t, db, user.ID.String())
+	gateway.orderID = payment.OrderID.String()
+	// ? webhook tidak pernah datang, pembayaran sudah melewati masa tunggu rekonsiliasi
+	if err := db.Model(&models.Payment{}).Where("id = ?", payment.ID).Update("paid_at", time.Now().Add(-time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	paid := fmt.Sprintf("%d.00", int64(payment.Order.AmountToPay))
+
+	tests := []struct {
+		name        string
+		amount      string
+		wantReports map[string]int64
+		wantPayment string
+		wantOrder   string
+	}{
+		{"not at the gateway yet is not stored", "", map[string]int64{ReconcileUnchanged: 0, ReconcileAmountMismatch: 0}, "pending", OrderWaitingPayment},
+		{"partial payment is reported", "1000.00", map[string]int64{ReconcileAmountMismatch: 1}, "pending", OrderWaitingPayment},
+		{"same mismatch is not reported again", "1000.00", map[string]int64{ReconcileAmountMismatch: 1}, "pending", OrderWaitingPayment},
+		{"full payment settles the order", paid, map[string]int64{ReconcileAmountMismatch: 1, ReconcileMarkedPaid: 1}, "success", OrderPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway.amount = tt.amount
+			if _, err := reconciliation.Reconcile(); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			for action, want := range tt.wantReports {
+				var count int64
+				if err := db.Model(&models.Reconciliation{}).Where("payment_id = ? AND action = ?", payment.ID, action).Count(&count).Error; err != nil {
+					t.Fatal(err)
+				}
+				if count != want {
+					t.Errorf("%d %s reports, want %d", count, action, want)
+				}
+			}
+
+			var p models.Payment
+			var o models.Order
+			if err := db.First(&p, "id = ?", payment.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.First(&o, "id = ?", payment.OrderID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if p.Status != tt.wantPayment || o.Status != tt.wantOrder {
+				t.Errorf("payment %s order %s, want %s and %s", p.Status, o.Status, tt.wantPayment, tt.wantOrder)
+			}
+		})
+	}
+}
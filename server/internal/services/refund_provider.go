@@ -0,0 +1,12 @@
+package services
+
+type RefundResult struct {
+	Reference string
+	Amount    int64
+}
+
+// RefundProvider sends money back to the customer for a paid order.
+// refundKey must be unique per refund so retries are not executed twice.
+type RefundProvider interface {
+	Refund(orderID, refundKey string, amount int64, reason string) (*RefundResult, error)
+}
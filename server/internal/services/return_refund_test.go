@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"server/internal/dto"
+	"server/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeRefundProvider is the fake gateway with refunds that can be made to fail
+type fakeRefundProvider struct {
+	*FakeGateway
+	err error
+}
+
+func (p *fakeRefundProvider) Refund(orderID, refundKey string, amount int64, reason string) (*RefundResult, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.FakeGateway.Refund(orderID, refundKey, amount, reason)
+}
+
+// deliveredOrder checks out and pays for quantity units, then marks the order delivered
+func deliveredOrder(t *testing.T, db *gorm.DB, svc *checkoutServices, gateway *FakeGateway, quantity int) *models.Order {
+	t.Helper()
+	user, _ := seedCustomer(t, db, 10, quantity)
+	_, payment := svc.checkout(t, db, user.ID.String())
+
+	orderID := payment.OrderID.String()
+	body, err := gateway.Notify(orderID, "settlement", int64(payment.Order.AmountToPay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.payment.HandlePaymentNotification(body); err != nil {
+		t.Fatalf("HandlePaymentNotification() error = %v", err)
+	}
+	if err := db.Model(&models.Order{}).Where("id = ?", orderID).Update("status", OrderDelivered).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	order := payment.Order
+	order.Status = OrderDelivered
+	return &order
+}
+
+// receivedReturn creates a return of one unit that the warehouse has already received
+func receivedReturn(t *testing.T, db *gorm.DB, order *models.Order, amount float64) string {
+	t.Helper()
+	item := order.Items[0]
+	ret := &models.ReturnRequest{
+		ID:              uuid.New(),
+		OrderID:         order.ID,
+		UserID:          order.UserID,
+		Status:          ReturnReceived,
+		Reason:          "wrong size",
+		RequestedAmount: amount,
+		Items: []models.ReturnItem{{
+			ID:          uuid.New(),
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			Quantity:    1,
+			Amount:      amount,
+		}},
+	}
+	if err := db.Create(ret).Error; err != nil {
+		t.Fatalf("failed to seed return: %v", err)
+	}
+	return ret.ID.String()
+}
+
+func TestRefundReturnPartialRefundsAddUp(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("QUOTE_SECRET", "refund-test-secret")
+
+	provider := &fakeRefundProvider{FakeGateway: NewFakeGateway("http://localhost:5002", "fake-secret")}
+	svc := newCheckoutServices(db, provider)
+	returns := NewReturnService(svc.repo.ReturnRepository, svc.repo.OrderRepository, svc.repo.PaymentRepository, provider, svc.notifications)
+	adminID := uuid.NewString()
+
+	order := deliveredOrder(t, db, svc, provider.FakeGateway, 2)
+	paid := float64(int64(order.AmountToPay))
+	first := 60000.0
+
+	state := func() (models.Order, models.Payment) {
+		t.Helper()
+		var o models.Order
+		var p models.Payment
+		if err := db.First(&o, "id = ?", order.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.First(&p, "order_id = ?", order.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return o, p
+	}
+
+	// ? refund yang ditolak gateway tidak boleh tercatat
+	provider.err = errors.New("gateway unavailable")
+	failedID := receivedReturn(t, db, order, first)
+	if _, err := returns.RefundReturn(adminID, failedID, dto.RefundReturnRequest{}); err == nil {
+		t.Fatal("RefundReturn() succeeded while the gateway refused the refund")
+	}
+	provider.err = nil
+	if o, p := state(); o.RefundedAmount != 0 || p.RefundedAmount != 0 || p.Status != "success" {
+		t.Fatalf("failed refund recorded: order refunded %.2f, payment %s refunded %.2f", o.RefundedAmount, p.Status, p.RefundedAmount)
+	}
+
+	tests := []struct {
+		name          string
+		amount        float64
+		wantErr       bool
+		wantRefunded  float64
+		wantPayment   string
+		wantOrder     string
+		wantProviders int
+	}{
+		{"first partial refund", first, false, first, "success", OrderDelivered, 1},
+		{"more than what is left", paid - first + 1, true, first, "success", OrderDelivered, 1},
+		{"rest of the payment", paid - first, false, paid, "refunded", OrderRefunded, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount := tt.amount
+			_, err := returns.RefundReturn(adminID, receivedReturn(t, db, order, amount), dto.RefundReturnRequest{Amount: &amount})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RefundReturn(%.0f) error = %v, wantErr %v", amount, err, tt.wantErr)
+			}
+
+			o, p := state()
+			if o.RefundedAmount != tt.wantRefunded || p.RefundedAmount != tt.wantRefunded {
+				t.Errorf("refunded order %.2f payment %.2f, want %.2f", o.RefundedAmount, p.RefundedAmount, tt.wantRefunded)
+			}
+			if p.Status != tt.wantPayment || o.Status != tt.wantOrder {
+				t.Errorf("payment %s order %s, want %s and %s", p.Status, o.Status, tt.wantPayment, tt.wantOrder)
+			}
+			if len(provider.Refunds) != tt.wantProviders {
+				t.Errorf("gateway received %d refunds, want %d", len(provider.Refunds), tt.wantProviders)
+			}
+		})
+	}
+
+	var sent int64
+	for _, r := range provider.Refunds {
+		sent += r.Amount
+	}
+	if sent != int64(paid) {
+		t.Errorf("gateway refunded %d in total, want %d", sent, int64(paid))
+	}
+}
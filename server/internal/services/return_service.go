@@ -0,0 +1,457 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/pricing"
+	"server/internal/repositories"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnReceived  = "received"
+	ReturnRefunded  = "refunded"
+)
+
+type ReturnService interface {
+	CreateReturn(userID string, req dto.CreateReturnRequest) (*dto.ReturnResponse, error)
+	GetReturns(userID, role string, param dto.ReturnQueryParam) ([]dto.ReturnResponse, *dto.PaginationResponse, error)
+	GetReturnDetail(userID, role, returnID string) (*dto.ReturnResponse, error)
+	ApproveReturn(adminID, returnID string, req dto.ReviewReturnRequest) (*dto.ReturnResponse, error)
+	RejectReturn(adminID, returnID string, req dto.ReviewReturnRequest) (*dto.ReturnResponse, error)
+	ReceiveReturn(adminID, returnID string, req dto.ReceiveReturnRequest) (*dto.ReturnResponse, error)
+	RefundReturn(adminID, returnID string, req dto.RefundReturnRequest) (*dto.ReturnResponse, error)
+}
+
+type returnService struct {
+	returnRepo          repositories.ReturnRepository
+	orderRepo           repositories.OrderRepository
+	paymentRepo         repositories.PaymentRepository
+	gateway             PaymentGateway
+	notificationService NotificationService
+}
+
+func NewReturnService(returnRepo repositories.ReturnRepository, orderRepo repositories.OrderRepository, paymentRepo repositories.PaymentRepository, gateway PaymentGateway, notificationService NotificationService) ReturnService {
+	return &returnService{returnRepo, orderRepo, paymentRepo, gateway, notificationService}
+}
+
+func (s *returnService) CreateReturn(userID string, req dto.CreateReturnRequest) (*dto.ReturnResponse, error) {
+	uid, _ := uuid.Parse(userID)
+
+	if len(req.ParsedItems) == 0 {
+		return nil, errors.New("at least one item must be returned")
+	}
+
+	order, err := s.orderRepo.GetOrderDetail(req.OrderID)
+	if err != nil || order.UserID != uid {
+		return nil, errors.New("order not found")
+	}
+
+	if order.Status != OrderDelivered && order.Status != OrderCompleted {
+		return nil, errors.New("only delivered or completed orders can be returned")
+	}
+
+	returned, err := s.returnRepo.GetReturnedQuantities(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	orderItems := make(map[uuid.UUID]models.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		orderItems[item.ID] = item
+	}
+	paid := itemPaidAmounts(order)
+
+	ret := &models.ReturnRequest{
+		OrderID: order.ID,
+		UserID:  uid,
+		Status:  ReturnRequested,
+		Reason:  req.Reason,
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, ri := range req.ParsedItems {
+		itemID, err := uuid.Parse(ri.OrderItemID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid order item ID: %s", ri.OrderItemID)
+		}
+
+		item, ok := orderItems[itemID]
+		if !ok {
+			return nil, fmt.Errorf("item %s does not belong to this order", ri.OrderItemID)
+		}
+		if seen[itemID] {
+			return nil, fmt.Errorf("item %s is listed more than once", ri.OrderItemID)
+		}
+		seen[itemID] = true
+
+		if ri.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for %s", item.ProductName)
+		}
+		if ri.Quantity > item.Quantity-returned[itemID] {
+			return nil, fmt.Errorf("return quantity for %s exceeds the returnable quantity", item.ProductName)
+		}
+
+		amount := pricing.Prorate(paid[item.ID], int64(ri.Quantity), int64(item.Quantity)).Float()
+		ret.RequestedAmount += amount
+		ret.Items = append(ret.Items, models.ReturnItem{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			Quantity:    ri.Quantity,
+			Amount:      amount,
+		})
+	}
+
+	if remaining := refundableAmount(order); ret.RequestedAmount > remaining {
+		ret.RequestedAmount = remaining
+	}
+
+	for _, url := range req.ImageURLs {
+		ret.Images = append(ret.Images, models.ReturnImage{Image: url})
+	}
+
+	if err := s.returnRepo.CreateReturn(ret); err != nil {
+		return nil, fmt.Errorf("failed to create return request: %v", err)
+	}
+
+	s.notify(order, fmt.Sprintf("Your return request for order #%s has been submitted.", order.InvoiceNumber))
+
+	return s.GetReturnDetail(userID, "customer", ret.ID.String())
+}
+
+func (s *returnService) GetReturns(userID, role string, param dto.ReturnQueryParam) ([]dto.ReturnResponse, *dto.PaginationResponse, error) {
+	var filter *uuid.UUID
+	switch role {
+	case "admin":
+	case "customer":
+		uid, _ := uuid.Parse(userID)
+		filter = &uid
+	default:
+		return nil, nil, errors.New("unauthorized role")
+	}
+
+	if param.Page <= 0 {
+		param.Page = 1
+	}
+	if param.Limit <= 0 {
+		param.Limit = 10
+	}
+
+	returns, total, err := s.returnRepo.GetReturns(filter, param)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result []dto.ReturnResponse
+	for _, r := range returns {
+		result = append(result, *toReturnResponse(&r))
+	}
+
+	totalPages := int((total + int64(param.Limit) - 1) / int64(param.Limit))
+	pagination := &dto.PaginationResponse{
+		Page:       param.Page,
+		Limit:      param.Limit,
+		TotalRows:  int(total),
+		TotalPages: totalPages,
+	}
+
+	return result, pagination, nil
+}
+
+func (s *returnService) GetReturnDetail(userID, role, returnID string) (*dto.ReturnResponse, error) {
+	ret, err := s.returnRepo.GetReturnByID(returnID)
+	if err != nil {
+		return nil, errors.New("return request not found")
+	}
+
+	if role == "customer" && ret.UserID.String() != userID {
+		return nil, errors.New("return request not found")
+	}
+
+	return toReturnResponse(ret), nil
+}
+
+func (s *returnService) ApproveReturn(adminID, returnID string, req dto.ReviewReturnRequest) (*dto.ReturnResponse, error) {
+	return s.review(returnID, ReturnApproved, req.Note, "has been approved. Please send the items back to us.")
+}
+
+func (s *returnService) RejectReturn(adminID, returnID string, req dto.ReviewReturnRequest) (*dto.ReturnResponse, error) {
+	if req.Note == "" {
+		return nil, errors.New("a note is required when rejecting a return")
+	}
+	return s.review(returnID, ReturnRejected, req.Note, "has been rejected.")
+}
+
+func (s *returnService) review(returnID, to, note, message string) (*dto.ReturnResponse, error) {
+	ret, err := s.returnRepo.GetReturnByID(returnID)
+	if err != nil {
+		return nil, errors.New("return request not found")
+	}
+
+	if ret.Status != ReturnRequested {
+		return nil, fmt.Errorf("return request is already %s", ret.Status)
+	}
+
+	fields := map[string]interface{}{}
+	if note != "" {
+		fields["admin_note"] = note
+	}
+
+	if err := s.returnRepo.UpdateReturnStatus(ret.ID, ReturnRequested, to, fields); err != nil {
+		return nil, err
+	}
+
+	s.notify(&ret.Order, fmt.Sprintf("Your return request for order #%s %s", ret.Order.InvoiceNumber, message))
+
+	return s.GetReturnDetail("", "admin", returnID)
+}
+
+func (s *returnService) ReceiveReturn(adminID, returnID string, req dto.ReceiveReturnRequest) (*dto.ReturnResponse, error) {
+	aid, _ := uuid.Parse(adminID)
+
+	ret, err := s.returnRepo.GetReturnByID(returnID)
+	if err != nil {
+		return nil, errors.New("return request not found")
+	}
+
+	if ret.Status != ReturnApproved {
+		return nil, errors.New("only approved returns can be received")
+	}
+
+	restock := req.Restock == nil || *req.Restock
+
+	order, err := s.orderRepo.GetOrderDetail(ret.OrderID.String())
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	err = s.returnRepo.WithTx(func(tx *gorm.DB) error {
+		txReturnRepo := repositories.NewReturnRepository(tx)
+		txOrderRepo := repositories.NewOrderRepository(tx)
+		txProductRepo := repositories.NewProductRepository(tx)
+
+		now := time.Now()
+		if err := txReturnRepo.UpdateReturnStatus(ret.ID, ReturnApproved, ReturnReceived, map[string]interface{}{
+			"received_at": now,
+		}); err != nil {
+			return err
+		}
+
+		if restock {
+			for _, item := range ret.Items {
+				if err := txProductRepo.MoveStock(&models.StockMovement{
+					ProductID:     item.ProductID,
+					VariantID:     item.OrderItem.VariantID,
+					Type:          StockReturn,
+					Quantity:      item.Quantity,
+					ReferenceType: "return",
+					ReferenceID:   &ret.ID,
+					ActorType:     ActorAdmin,
+					ActorID:       &aid,
+					Reason:        "returned item received",
+				}); err != nil {
+					return fmt.Errorf("failed to restock product %s: %w", item.ProductID, err)
+				}
+			}
+		}
+
+		// ** shipment dianggap returned kalau semua item order sudah kembali
+		returned, err := txReturnRepo.GetReturnedQuantities(order.ID)
+		if err != nil {
+			return err
+		}
+		for _, item := range order.Items {
+			if returned[item.ID] < item.Quantity {
+				return nil
+			}
+		}
+		return txOrderRepo.MarkShipmentReturned(order.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(order, fmt.Sprintf("We have received the returned items for order #%s.", order.InvoiceNumber))
+
+	return s.GetReturnDetail("", "admin", returnID)
+}
+
+func (s *returnService) RefundReturn(adminID, returnID string, req dto.RefundReturnRequest) (*dto.ReturnResponse, error) {
+	aid, _ := uuid.Parse(adminID)
+
+	ret, err := s.returnRepo.GetReturnByID(returnID)
+	if err != nil {
+		return nil, errors.New("return request not found")
+	}
+
+	if ret.Status != ReturnReceived {
+		return nil, errors.New("only received returns can be refunded")
+	}
+
+	order, err := s.orderRepo.GetOrderDetail(ret.OrderID.String())
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByOrderID(order.ID.String())
+	if err != nil {
+		return nil, errors.New("payment not found")
+	}
+	if payment.Status != "success" {
+		return nil, errors.New("order payment cannot be refunded")
+	}
+
+	amount := ret.RequestedAmount
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	// midtrans hanya menerima nominal bulat
+	amount = math.Floor(amount)
+
+	remaining := refundableAmount(order)
+	if amount <= 0 || amount > remaining {
+		return nil, fmt.Errorf("refund amount must be between 1 and %.0f", remaining)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = ret.Reason
+	}
+
+	// refund key dibuat dari ID retur, jadi retry tidak mengembalikan dana dua kali
+	result, err := refundPayment(s.gateway, payment, "rma-"+ret.ID.String(), int64(amount), reason)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.returnRepo.WithTx(func(tx *gorm.DB) error {
+		txReturnRepo := repositories.NewReturnRepository(tx)
+		txOrderRepo := repositories.NewOrderRepository(tx)
+		txPaymentRepo := repositories.NewPaymentRepository(tx)
+
+		now := time.Now()
+		if err := txReturnRepo.UpdateReturnStatus(ret.ID, ReturnReceived, ReturnRefunded, map[string]interface{}{
+			"refund_amount":    amount,
+			"refund_reference": result.Reference,
+			"refunded_at":      now,
+		}); err != nil {
+			return err
+		}
+
+		if err := txOrderRepo.AddRefundedAmount(order.ID, amount); err != nil {
+			return err
+		}
+		if err := txPaymentRepo.AddRefundedAmount(order.ID, pricing.FromFloat(amount)); err != nil {
+			return err
+		}
+
+		// unit yang dikembalikan tidak lagi dihitung terjual
+		items := make([]models.OrderItem, 0, len(ret.Items))
+		quantities := make(map[uuid.UUID]int, len(ret.Items))
+		for _, item := range ret.Items {
+			if _, ok := quantities[item.OrderItemID]; !ok {
+				items = append(items, item.OrderItem)
+			}
+			quantities[item.OrderItemID] += item.Quantity
+		}
+		if err := uncountSold(txOrderRepo, repositories.NewProductRepository(tx), items, quantities); err != nil {
+			return err
+		}
+
+		if amount < remaining {
+			return nil
+		}
+		return TransitionOrder(txOrderRepo, order, OrderRefunded, AdminActor(aid), reason)
+	})
+	if err != nil {
+		log.Printf("Refund %s for order %s was sent but could not be recorded: %v\n", result.Reference, order.ID, err)
+		return nil, err
+	}
+
+	s.notify(order, fmt.Sprintf("A refund of Rp%.0f for order #%s has been issued.", amount, order.InvoiceNumber))
+
+	return s.GetReturnDetail("", "admin", returnID)
+}
+
+func (s *returnService) notify(order *models.Order, message string) {
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+	payload := dto.NotificationEvent{
+		UserID:  order.UserID.String(),
+		Type:    "return_update",
+		Message: message,
+	}
+
+	if err := s.notificationService.SendToUser(payload); err != nil {
+		log.Printf("Fail to send notification to user %s: %v\n", payload.UserID, err)
+	}
+	// TODO: Replace with RabbitMQ for async notification dispatch ---
+}
+
+// itemPaidAmounts is each item's share of what the customer paid: voucher discount
+// is spread over the items and tax is added back, shipping is not refunded.
+func itemPaidAmounts(order *models.Order) map[uuid.UUID]pricing.Money {
+	weights := make([]pricing.Money, len(order.Items))
+	for i, item := range order.Items {
+		weights[i] = pricing.FromFloat(item.Subtotal)
+	}
+
+	shares := pricing.Allocate(pricing.FromFloat(order.Total+order.Tax), weights)
+	paid := make(map[uuid.UUID]pricing.Money, len(order.Items))
+	for i, item := range order.Items {
+		paid[item.ID] = shares[i]
+	}
+	return paid
+}
+
+// ** gross amount di midtrans dibulatkan ke bawah, jadi batas refund juga
+func refundableAmount(order *models.Order) float64 {
+	return float64(int64(order.AmountToPay)) - order.RefundedAmount
+}
+
+func toReturnResponse(r *models.ReturnRequest) *dto.ReturnResponse {
+	items := make([]dto.ReturnItemResponse, 0, len(r.Items))
+	for _, i := range r.Items {
+		items = append(items, dto.ReturnItemResponse{
+			OrderItemID: i.OrderItemID.String(),
+			ProductID:   i.ProductID.String(),
+			ProductName: i.OrderItem.ProductName,
+			Variant:     i.OrderItem.VariantName,
+			Image:       i.OrderItem.Image,
+			Quantity:    i.Quantity,
+			Amount:      i.Amount,
+		})
+	}
+
+	images := make([]string, 0, len(r.Images))
+	for _, img := range r.Images {
+		images = append(images, img.Image)
+	}
+
+	return &dto.ReturnResponse{
+		ID:              r.ID.String(),
+		OrderID:         r.OrderID.String(),
+		InvoiceNumber:   r.Order.InvoiceNumber,
+		UserID:          r.UserID.String(),
+		Status:          r.Status,
+		Reason:          r.Reason,
+		AdminNote:       r.AdminNote,
+		RequestedAmount: r.RequestedAmount,
+		RefundAmount:    r.RefundAmount,
+		RefundReference: r.RefundReference,
+		Images:          images,
+		Items:           items,
+		ReceivedAt:      r.ReceivedAt,
+		RefundedAt:      r.RefundedAt,
+		CreatedAt:       r.CreatedAt,
+	}
+}
@@ -3,16 +3,46 @@ package services
 import (
 	"errors"
 	"fmt"
+	"server/internal/config"
 	"server/internal/dto"
 	"server/internal/models"
 	"server/internal/repositories"
+	"server/internal/utils"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ** ulasan masih bisa diubah atau dihapus selama 30 hari setelah dibuat
+const reviewEditWindow = 30 * 24 * time.Hour
+
+var (
+	ErrReviewNotAllowed   = errors.New("you can only review items from your own delivered orders")
+	ErrAlreadyReviewed    = errors.New("this item has already been reviewed")
+	ErrReviewNotFound     = errors.New("review not found")
+	ErrReviewEditWindow   = errors.New("reviews can only be changed within 30 days")
+	ErrReviewItemNotFound = errors.New("order item not found")
+	ErrOwnReviewVote      = errors.New("you cannot vote on your own review")
+	ErrAlreadyVoted       = errors.New("you already marked this review as helpful")
+	ErrVoteNotFound       = errors.New("you have not marked this review as helpful")
+	ErrReplyNotFound      = errors.New("review has no reply")
 )
 
 type ReviewService interface {
 	CreateReview(userID, productID string, req dto.CreateReviewRequest) error
+	UpdateReview(userID, reviewID string, req dto.UpdateReviewRequest) error
+	DeleteReview(userID, reviewID string) error
 	GetReviewsByProductID(productID string, param dto.ReviewQueryParam) ([]dto.ReviewResponse, *dto.PaginationResponse, error)
+
+	VoteHelpful(userID, reviewID string) error
+	UnvoteHelpful(userID, reviewID string) error
+
+	GetModerationQueue(param dto.ReviewModerationQueryParam) ([]dto.ReviewResponse, *dto.PaginationResponse, error)
+	ModerateReview(adminID, reviewID string, req dto.ModerateReviewRequest) error
+	ReplyToReview(adminID, reviewID string, req dto.ReviewReplyRequest) error
+	DeleteReply(reviewID string) error
 }
 
 type reviewService struct {
@@ -24,34 +54,131 @@ func NewReviewService(reviewRepo repositories.ReviewRepository, orderRepo reposi
 	return &reviewService{reviewRepo, orderRepo}
 }
 
+// CreateReview only accepts items of the caller's own delivered or completed orders, once per item
 func (s *reviewService) CreateReview(userID, itemID string, req dto.CreateReviewRequest) error {
 	uid, _ := uuid.Parse(userID)
 
 	item, err := s.reviewRepo.GetOrderItemByID(itemID)
 	if err != nil {
-		return fmt.Errorf("item not found: %v", err)
+		return ErrReviewItemNotFound
 	}
 
+	order, err := s.orderRepo.GetOrderDetail(item.OrderID.String())
+	if err != nil || order.UserID != uid {
+		return ErrReviewItemNotFound
+	}
+	if order.Status != OrderDelivered && order.Status != OrderCompleted {
+		return ErrReviewNotAllowed
+	}
+	if item.IsReviewed {
+		return ErrAlreadyReviewed
+	}
+
+	status, reason := moderationStatus(req.Comment)
 	review := &models.Review{
-		ID:        uuid.New(),
-		UserID:    uid,
-		ProductID: item.ProductID,
-		Rating:    req.Rating,
-		Comment:   req.Comment,
-		Image:     &req.ImageURL,
+		ID:            uuid.New(),
+		UserID:        uid,
+		ProductID:     item.ProductID,
+		OrderItemID:   &item.ID,
+		Rating:        req.Rating,
+		Comment:       req.Comment,
+		Status:        status,
+		FlaggedReason: reason,
+	}
+
+	return s.reviewRepo.WithTx(func(tx *gorm.DB) error {
+		reviewRepo := repositories.NewReviewRepository(tx)
+
+		if err := reviewRepo.MarkItemAsReviewed(item.ID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAlreadyReviewed
+			}
+			return fmt.Errorf("failed to update OrderItem review status: %v", err)
+		}
+		if err := reviewRepo.CreateReview(review); err != nil {
+			return fmt.Errorf("failed to create review: %v", err)
+		}
+		if err := reviewRepo.ReplaceReviewImages(review.ID, req.ImageURLs); err != nil {
+			return fmt.Errorf("failed to save review images: %v", err)
+		}
+		return reviewRepo.RecalculateProductRating(item.ProductID)
+	})
+}
+
+func (s *reviewService) UpdateReview(userID, reviewID string, req dto.UpdateReviewRequest) error {
+	review, err := s.getEditableReview(userID, reviewID)
+	if err != nil {
+		return err
 	}
 
-	if err := s.reviewRepo.CreateReview(review); err != nil {
-		return fmt.Errorf("failed to create review: %v", err)
+	// ** ulasan yang diubah dimoderasi ulang
+	review.Rating = req.Rating
+	review.Comment = req.Comment
+	review.Status, review.FlaggedReason = moderationStatus(req.Comment)
+
+	var oldImages []string
+	if len(req.ImageURLs) > 0 {
+		oldImages = reviewImageURLs(review)
 	}
 
-	if err := s.reviewRepo.MarkItemAsReviewed(item.ID); err != nil {
-		return fmt.Errorf("failed to update OrderItem review status: %v", err)
+	err = s.reviewRepo.WithTx(func(tx *gorm.DB) error {
+		reviewRepo := repositories.NewReviewRepository(tx)
+		if err := reviewRepo.UpdateReview(review); err != nil {
+			return err
+		}
+		if len(req.ImageURLs) > 0 {
+			if err := reviewRepo.ReplaceReviewImages(review.ID, req.ImageURLs); err != nil {
+				return err
+			}
+		}
+		return reviewRepo.RecalculateProductRating(review.ProductID)
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.CleanupImagesOnError(oldImages)
+	return nil
+}
+
+// DeleteReview frees the order item so it can be reviewed again
+func (s *reviewService) DeleteReview(userID, reviewID string) error {
+	review, err := s.getEditableReview(userID, reviewID)
+	if err != nil {
+		return err
+	}
+
+	err = s.reviewRepo.WithTx(func(tx *gorm.DB) error {
+		reviewRepo := repositories.NewReviewRepository(tx)
+		if err := reviewRepo.DeleteReview(review.ID); err != nil {
+			return err
+		}
+		if review.OrderItemID != nil {
+			if err := reviewRepo.UnmarkItemAsReviewed(*review.OrderItemID); err != nil {
+				return err
+			}
+		}
+		return reviewRepo.RecalculateProductRating(review.ProductID)
+	})
+	if err != nil {
+		return err
 	}
 
+	utils.CleanupImagesOnError(reviewImageURLs(review))
 	return nil
 }
 
+func (s *reviewService) getEditableReview(userID, reviewID string) (*models.Review, error) {
+	uid, _ := uuid.Parse(userID)
+	review, err := s.getReview(reviewID)
+	if err != nil || review.UserID != uid {
+		return nil, ErrReviewNotFound
+	}
+	if time.Since(review.CreatedAt) > reviewEditWindow {
+		return nil, ErrReviewEditWindow
+	}
+	return review, nil
+}
 
 func (s *reviewService) GetReviewsByProductID(productID string, param dto.ReviewQueryParam) ([]dto.ReviewResponse, *dto.PaginationResponse, error) {
 	pid, err := uuid.Parse(productID)
@@ -65,27 +192,211 @@ func (s *reviewService) GetReviewsByProductID(productID string, param dto.Review
 	}
 
 	var result []dto.ReviewResponse
-	for _, r := range reviews {
-		result = append(result, dto.ReviewResponse{
-			ID:        r.ID.String(),
-			UserID:    r.UserID.String(),
-			Fullname:  r.User.Profile.Fullname,
-			Avatar:    r.User.Profile.Avatar,
-			ProductID: r.ProductID.String(),
-			Rating:    r.Rating,
-			Comment:   r.Comment,
-			Image:     r.Image,
-			CreatedAt: r.CreatedAt,
-		})
-	}
-
-	totalPages := int((total + int64(param.Limit) - 1) / int64(param.Limit))
-	pagination := &dto.PaginationResponse{
-		Page:       param.Page,
-		Limit:      param.Limit,
-		TotalRows:  int(total),
-		TotalPages: totalPages,
+	for i := range reviews {
+		result = append(result, toReviewResponse(&reviews[i]))
+	}
+
+	return result, reviewPagination(param.Page, param.Limit, total), nil
+}
+
+// VoteHelpful counts one helpful vote per customer on someone else's approved review
+func (s *reviewService) VoteHelpful(userID, reviewID string) error {
+	uid, _ := uuid.Parse(userID)
+	review, err := s.getVotableReview(uid, reviewID)
+	if err != nil {
+		return err
+	}
+
+	voted, err := s.reviewRepo.HasVoted(review.ID, uid)
+	if err != nil {
+		return err
+	}
+	if voted {
+		return ErrAlreadyVoted
 	}
 
-	return result, pagination, nil
+	return s.reviewRepo.WithTx(func(tx *gorm.DB) error {
+		reviewRepo := repositories.NewReviewRepository(tx)
+		if err := reviewRepo.CreateVote(&models.ReviewVote{ReviewID: review.ID, UserID: uid}); err != nil {
+			return err
+		}
+		return reviewRepo.RefreshHelpfulCount(review.ID)
+	})
+}
+
+func (s *reviewService) UnvoteHelpful(userID, reviewID string) error {
+	uid, _ := uuid.Parse(userID)
+	review, err := s.getVotableReview(uid, reviewID)
+	if err != nil {
+		return err
+	}
+
+	return s.reviewRepo.WithTx(func(tx *gorm.DB) error {
+		reviewRepo := repositories.NewReviewRepository(tx)
+		if err := reviewRepo.DeleteVote(review.ID, uid); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVoteNotFound
+			}
+			return err
+		}
+		return reviewRepo.RefreshHelpfulCount(review.ID)
+	})
+}
+
+func (s *reviewService) getVotableReview(userID uuid.UUID, reviewID string) (*models.Review, error) {
+	review, err := s.getReview(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != "approved" {
+		return nil, ErrReviewNotFound
+	}
+	if review.UserID == userID {
+		return nil, ErrOwnReviewVote
+	}
+	return review, nil
+}
+
+// GetModerationQueue lists pending reviews by default, oldest first
+func (s *reviewService) GetModerationQueue(param dto.ReviewModerationQueryParam) ([]dto.ReviewResponse, *dto.PaginationResponse, error) {
+	if param.Status == "" {
+		param.Status = "pending"
+	}
+
+	reviews, total, err := s.reviewRepo.GetModerationQueue(param)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]dto.ReviewResponse, 0, len(reviews))
+	for i := range reviews {
+		response := toReviewResponse(&reviews[i])
+		response.ProductName = reviews[i].Product.Name
+		response.Status = reviews[i].Status
+		response.FlaggedReason = reviews[i].FlaggedReason
+		result = append(result, response)
+	}
+
+	return result, reviewPagination(param.Page, param.Limit, total), nil
+}
+
+// ModerateReview approves or rejects a review, the product rating follows the approved set
+func (s *reviewService) ModerateReview(adminID, reviewID string, req dto.ModerateReviewRequest) error {
+	aid, _ := uuid.Parse(adminID)
+	review, err := s.getReview(reviewID)
+	if err != nil {
+		return err
+	}
+
+	reason := req.Reason
+	if reason == "" && req.Status != "approved" {
+		reason = review.FlaggedReason
+	}
+
+	return s.reviewRepo.WithTx(func(tx *gorm.DB) error {
+		reviewRepo := repositories.NewReviewRepository(tx)
+		if err := reviewRepo.UpdateModeration(review.ID, req.Status, aid, reason); err != nil {
+			return err
+		}
+		return reviewRepo.RecalculateProductRating(review.ProductID)
+	})
+}
+
+// ReplyToReview posts the merchant reply, replying again overwrites it
+func (s *reviewService) ReplyToReview(adminID, reviewID string, req dto.ReviewReplyRequest) error {
+	aid, _ := uuid.Parse(adminID)
+	review, err := s.getReview(reviewID)
+	if err != nil {
+		return err
+	}
+
+	return s.reviewRepo.SaveReply(&models.ReviewReply{
+		ReviewID: review.ID,
+		AdminID:  aid,
+		Body:     strings.TrimSpace(req.Body),
+	})
+}
+
+func (s *reviewService) DeleteReply(reviewID string) error {
+	review, err := s.getReview(reviewID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.reviewRepo.DeleteReply(review.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReplyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *reviewService) getReview(reviewID string) (*models.Review, error) {
+	id, err := uuid.Parse(reviewID)
+	if err != nil {
+		return nil, ErrReviewNotFound
+	}
+	review, err := s.reviewRepo.GetReviewByID(id)
+	if err != nil {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// moderationStatus flags comments with a banned word for the admin queue,
+// the rest go live unless auto approve is turned off
+func moderationStatus(comment string) (string, string) {
+	lower := strings.ToLower(comment)
+	for _, word := range config.ReviewBannedWords {
+		if strings.Contains(lower, word) {
+			return "pending", fmt.Sprintf("contains banned word %q", word)
+		}
+	}
+	if !config.ReviewAutoApprove {
+		return "pending", ""
+	}
+	return "approved", ""
+}
+
+func reviewImageURLs(review *models.Review) []string {
+	urls := make([]string, 0, len(review.Images))
+	for _, image := range review.Images {
+		urls = append(urls, image.Image)
+	}
+	return urls
+}
+
+func toReviewResponse(r *models.Review) dto.ReviewResponse {
+	response := dto.ReviewResponse{
+		ID:           r.ID.String(),
+		UserID:       r.UserID.String(),
+		Fullname:     r.User.Profile.Fullname,
+		Avatar:       r.User.Profile.Avatar,
+		ProductID:    r.ProductID.String(),
+		Rating:       r.Rating,
+		Comment:      r.Comment,
+		Images:       reviewImageURLs(r),
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+		Verified:     r.OrderItemID != nil,
+		HelpfulCount: r.HelpfulCount,
+	}
+	if r.Reply != nil {
+		response.Reply = &dto.ReviewReplyResponse{
+			Body:      r.Reply.Body,
+			CreatedAt: r.Reply.CreatedAt,
+			UpdatedAt: r.Reply.UpdatedAt,
+		}
+	}
+	return response
+}
+
+func reviewPagination(page, limit int, total int64) *dto.PaginationResponse {
+	return &dto.PaginationResponse{
+		Page:       page,
+		Limit:      limit,
+		TotalRows:  int(total),
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
 }
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"server/internal/dto"
+	"server/internal/repositories"
+	"server/internal/search"
+	"time"
+)
+
+const (
+	// ** query populer diambil dari 30 hari terakhir, log disimpan 90 hari
+	popularQueryWindow = 30 * 24 * time.Hour
+	popularQueryLimit  = 1000
+	searchLogRetention = 90 * 24 * time.Hour
+
+	defaultSuggestLimit = 5
+)
+
+type SearchService interface {
+	Suggest(param dto.SuggestQueryParam) *dto.SuggestResponse
+	RefreshPopularQueries() (int, error)
+	GetZeroResultQueries(param dto.ZeroResultQueryParam) ([]dto.ZeroResultQueryResponse, *dto.PaginationResponse, error)
+	PurgeQueryLog() (int64, error)
+}
+
+type searchService struct {
+	searchQueryRepo repositories.SearchQueryRepository
+	suggester       *search.Suggester
+}
+
+func NewSearchService(searchQueryRepo repositories.SearchQueryRepository, suggester *search.Suggester) SearchService {
+	return &searchService{searchQueryRepo, suggester}
+}
+
+// Suggest only reads the in-memory tries, it never touches the database
+func (s *searchService) Suggest(param dto.SuggestQueryParam) *dto.SuggestResponse {
+	limit := param.Limit
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+
+	found := s.suggester.Suggest(param.Query, limit)
+	response := &dto.SuggestResponse{
+		Products:   make([]dto.ProductSuggestion, 0, len(found.Products)),
+		Categories: make([]dto.CategorySuggestion, 0, len(found.Categories)),
+		Queries:    found.Queries,
+	}
+	for _, p := range found.Products {
+		response.Products = append(response.Products, dto.ProductSuggestion{ID: p.ID.String(), Name: p.Name, Slug: p.Slug})
+	}
+	for _, c := range found.Categories {
+		response.Categories = append(response.Categories, dto.CategorySuggestion{Name: c.Name, Slug: c.Slug})
+	}
+	return response
+}
+
+// RefreshPopularQueries reloads the past queries suggested in the search box
+func (s *searchService) RefreshPopularQueries() (int, error) {
+	queries, err := s.searchQueryRepo.GetPopularQueries(time.Now().Add(-popularQueryWindow), popularQueryLimit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch popular queries: %w", err)
+	}
+	s.suggester.SetPopularQueries(queries)
+	return len(queries), nil
+}
+
+// GetZeroResultQueries reports what customers searched for without finding anything, most frequent first
+func (s *searchService) GetZeroResultQueries(param dto.ZeroResultQueryParam) ([]dto.ZeroResultQueryResponse, *dto.PaginationResponse, error) {
+	days := param.Days
+	if days <= 0 {
+		days = 30
+	}
+
+	rows, total, err := s.searchQueryRepo.GetZeroResultQueries(time.Now().AddDate(0, 0, -days), param)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPages := int((total + int64(param.Limit) - 1) / int64(param.Limit))
+	return rows, &dto.PaginationResponse{
+		Page:       param.Page,
+		Limit:      param.Limit,
+		TotalRows:  int(total),
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *searchService) PurgeQueryLog() (int64, error) {
+	return s.searchQueryRepo.DeleteOlderThan(time.Now().Add(-searchLogRetention))
+}
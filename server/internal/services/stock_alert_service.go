@@ -0,0 +1,183 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StockAlertService interface {
+	Subscribe(userID string, req dto.RestockSubscriptionRequest) (*dto.RestockSubscriptionResponse, error)
+	Unsubscribe(userID, subscriptionID string) error
+	GetSubscriptions(userID string) ([]dto.RestockSubscriptionResponse, error)
+	CheckAlerts() error
+}
+
+type stockAlertService struct {
+	alertRepo           repositories.StockAlertRepository
+	productRepo         repositories.ProductRepository
+	notificationService NotificationService
+}
+
+func NewStockAlertService(alertRepo repositories.StockAlertRepository, productRepo repositories.ProductRepository, notificationService NotificationService) StockAlertService {
+	return &stockAlertService{alertRepo, productRepo, notificationService}
+}
+
+// Subscribe is only allowed while the product or chosen variant is out of stock
+func (s *stockAlertService) Subscribe(userID string, req dto.RestockSubscriptionRequest) (*dto.RestockSubscriptionResponse, error) {
+	uid, _ := uuid.Parse(userID)
+	pid, _ := uuid.Parse(req.ProductID)
+
+	var variantID *uuid.UUID
+	if req.VariantID != nil {
+		id, err := parseVariantID(*req.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		variantID = id
+	}
+
+	product, err := s.productRepo.GetProductByID(pid)
+	if err != nil || !product.IsActive {
+		return nil, errors.New("product not found")
+	}
+	variant, err := findVariant(product, variantID)
+	if err != nil {
+		return nil, err
+	}
+	if variantStock(product, variant) > 0 {
+		return nil, errors.New("product is still in stock")
+	}
+
+	if _, err := s.alertRepo.GetPendingSubscription(uid, pid, variantID); err == nil {
+		return nil, errors.New("you are already subscribed to this product")
+	}
+
+	sub := &models.RestockSubscription{
+		UserID:    uid,
+		ProductID: pid,
+		VariantID: variantID,
+	}
+	if err := s.alertRepo.CreateSubscription(sub); err != nil {
+		return nil, err
+	}
+	sub.Product = *product
+	sub.Variant = variant
+
+	response := toRestockSubscriptionResponse(sub)
+	return &response, nil
+}
+
+func (s *stockAlertService) Unsubscribe(userID, subscriptionID string) error {
+	uid, _ := uuid.Parse(userID)
+	id, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		return errors.New("invalid subscription ID")
+	}
+
+	if err := s.alertRepo.DeleteSubscription(uid, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("subscription not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *stockAlertService) GetSubscriptions(userID string) ([]dto.RestockSubscriptionResponse, error) {
+	uid, _ := uuid.Parse(userID)
+
+	subs, err := s.alertRepo.GetUserSubscriptions(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.RestockSubscriptionResponse, 0, len(subs))
+	for i := range subs {
+		result = append(result, toRestockSubscriptionResponse(&subs[i]))
+	}
+	return result, nil
+}
+
+// CheckAlerts tells admins about products that fell to their low-stock threshold and
+// tells subscribers their product is back. Both are sent once per occurrence.
+func (s *stockAlertService) CheckAlerts() error {
+	if err := s.alertRepo.ResetRecoveredLowStock(); err != nil {
+		return fmt.Errorf("failed to reset low stock alerts: %w", err)
+	}
+
+	lowStock, err := s.alertRepo.GetLowStockProducts()
+	if err != nil {
+		return fmt.Errorf("failed to fetch low stock products: %w", err)
+	}
+
+	var alerted []uuid.UUID
+	for _, p := range lowStock {
+		// TODO: Replace with RabbitMQ for async notification dispatch ---
+		if err := s.notificationService.SendToAdmins(dto.NotificationEvent{
+			Type:    "low_stock",
+			Title:   "Low Stock Alert",
+			Message: fmt.Sprintf("%s has %d left in stock, at or below its threshold of %d.", p.Name, p.Stock, *p.LowStockThreshold),
+		}); err != nil {
+			log.Printf("Failed sending low stock notification for product %s: %v", p.ID, err)
+			continue
+		}
+		// TODO: Replace with RabbitMQ for async notification dispatch ---
+		alerted = append(alerted, p.ID)
+	}
+	if err := s.alertRepo.MarkLowStockAlerted(alerted); err != nil {
+		return err
+	}
+
+	restocked, err := s.alertRepo.GetRestockedSubscriptions()
+	if err != nil {
+		return fmt.Errorf("failed to fetch restock subscriptions: %w", err)
+	}
+
+	var notified []uuid.UUID
+	for _, sub := range restocked {
+		name := sub.Product.Name
+		if label := variantLabel(sub.Variant); label != "" {
+			name += " (" + label + ")"
+		}
+
+		// TODO: Replace with RabbitMQ for async notification dispatch ---
+		if err := s.notificationService.SendToUser(dto.NotificationEvent{
+			UserID:  sub.UserID.String(),
+			Type:    "restock_alert",
+			Title:   "Back in Stock",
+			Message: fmt.Sprintf("Good news, %s is back in stock. Get it before it runs out again!", name),
+		}); err != nil {
+			log.Printf("Failed sending restock notification to user %s: %v", sub.UserID, err)
+			continue
+		}
+		// TODO: Replace with RabbitMQ for async notification dispatch ---
+		notified = append(notified, sub.ID)
+	}
+
+	return s.alertRepo.MarkSubscriptionsNotified(notified)
+}
+
+func toRestockSubscriptionResponse(sub *models.RestockSubscription) dto.RestockSubscriptionResponse {
+	response := dto.RestockSubscriptionResponse{
+		ID:          sub.ID.String(),
+		ProductID:   sub.ProductID.String(),
+		ProductName: sub.Product.Name,
+		ProductSlug: sub.Product.Slug,
+		Variant:     variantLabel(sub.Variant),
+		Image:       variantImage(&sub.Product, sub.Variant),
+		InStock:     variantStock(&sub.Product, sub.Variant) > 0,
+		NotifiedAt:  sub.NotifiedAt,
+		CreatedAt:   sub.CreatedAt,
+	}
+	if sub.VariantID != nil {
+		response.VariantID = sub.VariantID.String()
+	}
+	return response
+}
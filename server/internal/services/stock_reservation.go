@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+	"server/internal/config"
+	"server/internal/models"
+	"server/internal/repositories"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reserveStock holds quantity units of a cart line for the user until the reservation TTL runs out.
+// Harus dipanggil di dalam transaksi, lock baris produk membuat reservasi paralel antri.
+func reserveStock(productRepo repositories.ProductRepository, reservationRepo repositories.ReservationRepository, userID uuid.UUID, product *models.Product, variant *models.ProductVariant, quantity int) error {
+	locked, err := productRepo.LockProductsForUpdate([]uuid.UUID{product.ID})
+	if err != nil {
+		return err
+	}
+	if len(locked) == 0 {
+		return fmt.Errorf("product %s not found", product.Name)
+	}
+
+	stock := locked[0].Stock
+	var variantID *uuid.UUID
+	if variant != nil {
+		lockedVariants, err := productRepo.LockVariantsForUpdate([]uuid.UUID{variant.ID})
+		if err != nil {
+			return err
+		}
+		if len(lockedVariants) == 0 {
+			return ErrVariantUnavailable
+		}
+		stock = lockedVariants[0].Stock
+		variantID = &variant.ID
+	}
+
+	reserved, err := reservationRepo.GetReservedByOthers(userID, product.ID, variantID)
+	if err != nil {
+		return err
+	}
+	if available := stock - reserved; quantity > available {
+		if available <= 0 {
+			return ErrOutOfStock
+		}
+		return fmt.Errorf("only %d left for %s", available, product.Name)
+	}
+
+	return reservationRepo.Reserve(&models.StockReservation{
+		UserID:    userID,
+		ProductID: product.ID,
+		VariantID: variantID,
+		Quantity:  quantity,
+		ExpiresAt: time.Now().Add(config.CartReservationTTL),
+	})
+}
+
+// availableStock is stock minus what other carts hold, or plain stock when reservations are off
+func availableStock(stock, reserved int) int {
+	if !config.CartReservationEnabled {
+		return stock
+	}
+	if stock -= reserved; stock < 0 {
+		return 0
+	}
+	return stock
+}
+
+// reserveCartLines refreshes the holds on the checked cart lines when checkout starts
+func reserveCartLines(productRepo repositories.ProductRepository, reservationRepo repositories.ReservationRepository, userID uuid.UUID, carts []models.Cart) error {
+	for _, c := range carts {
+		if c.VariantID != nil && c.Variant == nil {
+			return fmt.Errorf("stock not enough for product: %s", c.Product.Name)
+		}
+		if err := reserveStock(productRepo, reservationRepo, userID, &c.Product, c.Variant, c.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"server/internal/dto"
 	"server/internal/models"
+	"server/internal/pricing"
 	"server/internal/repositories"
 	"strings"
 	"time"
@@ -14,11 +15,13 @@ import (
 
 type VoucherService interface {
 	DecreaseQuota(userID uuid.UUID, code string) error
+	RestoreQuota(userID uuid.UUID, code string) error
 	CreateVoucher(dto.CreateVoucherRequest) error
 	GetAllVouchers() ([]dto.VoucherResponse, error)
 	DeleteVoucher(id string) error
 	UpdateVoucher(id string, req dto.UpdateVoucherRequest) error
 	ApplyVoucher(userID string, req dto.ApplyVoucherRequest) (*dto.ApplyVoucherResponse, error)
+	GetVoucherAdjustment(userID, code string) (*pricing.Adjustment, error)
 }
 
 type voucherService struct {
@@ -86,7 +89,37 @@ func (s *voucherService) GetAllVouchers() ([]dto.VoucherResponse, error) {
 }
 
 func (s *voucherService) ApplyVoucher(userID string, req dto.ApplyVoucherRequest) (*dto.ApplyVoucherResponse, error) {
-	voucher, err := s.repo.GetValidVoucherByCode(req.Code)
+	voucher, err := s.validVoucher(userID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	total := pricing.FromFloat(req.Total)
+	discountValue := voucherAdjustment(voucher).Amount(total)
+	final := total - discountValue
+
+	return &dto.ApplyVoucherResponse{
+		Code:          voucher.Code,
+		DiscountType:  voucher.DiscountType,
+		Discount:      voucher.Discount,
+		MaxDiscount:   voucher.MaxDiscount,
+		DiscountValue: discountValue.Float(),
+		FinalTotal:    final.Float(),
+	}, nil
+}
+
+// GetVoucherAdjustment validates the voucher for the user and returns it for the pricing engine
+func (s *voucherService) GetVoucherAdjustment(userID, code string) (*pricing.Adjustment, error) {
+	voucher, err := s.validVoucher(userID, code)
+	if err != nil {
+		return nil, err
+	}
+	adjustment := voucherAdjustment(voucher)
+	return &adjustment, nil
+}
+
+func (s *voucherService) validVoucher(userID, code string) (*models.Voucher, error) {
+	voucher, err := s.repo.GetValidVoucherByCode(code)
 	if err != nil {
 		return nil, errors.New("invalid or expired voucher")
 	}
@@ -106,43 +139,39 @@ func (s *voucherService) ApplyVoucher(userID string, req dto.ApplyVoucherRequest
 		}
 	}
 
-	var discountValue float64
-	if voucher.DiscountType == "percentage" {
-		discountValue = req.Total * (voucher.Discount / 100)
-		if voucher.MaxDiscount != nil && discountValue > *voucher.MaxDiscount {
-			discountValue = *voucher.MaxDiscount
-		}
-	} else {
-		discountValue = min(voucher.Discount, req.Total)
+	return voucher, nil
+}
+
+func (s *voucherService) DecreaseQuota(userID uuid.UUID, code string) error {
+	voucher, err := s.repo.GetByCode(code)
+	if err != nil {
+		return err
 	}
 
-	final := req.Total - discountValue
+	if err := s.repo.DecreaseQuota(voucher.ID); err != nil {
+		return err
+	}
 
-	return &dto.ApplyVoucherResponse{
-		Code:          voucher.Code,
-		DiscountType:  voucher.DiscountType,
-		Discount:      voucher.Discount,
-		MaxDiscount:   voucher.MaxDiscount,
-		DiscountValue: discountValue,
-		FinalTotal:    final,
-	}, nil
+	if !voucher.IsReusable {
+		return s.repo.InsertUsedVoucher(userID, voucher.ID)
+	}
+	return nil
 }
 
-func (s *voucherService) DecreaseQuota(userID uuid.UUID, code string) error {
+func (s *voucherService) RestoreQuota(userID uuid.UUID, code string) error {
 	voucher, err := s.repo.GetByCode(code)
 	if err != nil {
 		return err
 	}
 
 	if !voucher.IsReusable {
-		_ = s.repo.InsertUsedVoucher(userID, voucher.ID)
+		if err := s.repo.DeleteUsedVoucher(userID, voucher.ID); err != nil {
+			return err
+		}
 	}
 
-	if voucher.Quota > 0 {
-		voucher.Quota -= 1
-		return s.repo.UpdateVoucher(voucher)
-	}
-	return nil
+	voucher.Quota += 1
+	return s.repo.UpdateVoucher(voucher)
 }
 
 func (s *voucherService) UpdateVoucher(id string, req dto.UpdateVoucherRequest) error {
@@ -179,3 +208,12 @@ func (s *voucherService) DeleteVoucher(id string) error {
 	}
 	return s.repo.DeleteByID(voucherID)
 }
+
+func voucherAdjustment(v *models.Voucher) pricing.Adjustment {
+	return pricing.Adjustment{
+		Label:       v.Code,
+		Percentage:  v.DiscountType == "percentage",
+		Value:       v.Discount,
+		MaxDiscount: v.MaxDiscount,
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"server/internal/dto"
+	"server/internal/models"
+	"server/internal/pricing"
+	"server/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WishlistService interface {
+	AddItem(userID string, req dto.WishlistRequest) (*dto.WishlistItemResponse, error)
+	RemoveItem(userID, itemID string) error
+	GetWishlist(userID string) ([]dto.WishlistItemResponse, error)
+	AddToCart(userID, itemID string, req dto.WishlistToCartRequest) error
+	CheckPriceDrops() (int, error)
+}
+
+type wishlistService struct {
+	wishlistRepo        repositories.WishlistRepository
+	productRepo         repositories.ProductRepository
+	cartService         CartService
+	notificationService NotificationService
+}
+
+func NewWishlistService(wishlistRepo repositories.WishlistRepository, productRepo repositories.ProductRepository, cartService CartService, notificationService NotificationService) WishlistService {
+	return &wishlistService{wishlistRepo, productRepo, cartService, notificationService}
+}
+
+// AddItem wishlists a product, a variant is optional even for products that have variants
+func (s *wishlistService) AddItem(userID string, req dto.WishlistRequest) (*dto.WishlistItemResponse, error) {
+	uid, _ := uuid.Parse(userID)
+	pid, _ := uuid.Parse(req.ProductID)
+
+	var variantID *uuid.UUID
+	if req.VariantID != nil {
+		id, err := parseVariantID(*req.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		variantID = id
+	}
+
+	product, err := s.productRepo.GetProductByID(pid)
+	if err != nil || !product.IsActive {
+		return nil, errors.New("product not found")
+	}
+	var variant *models.ProductVariant
+	if variantID != nil {
+		if variant, err = findVariant(product, variantID); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.wishlistRepo.GetItem(uid, pid, variantID); err == nil {
+		return nil, errors.New("product is already in your wishlist")
+	}
+
+	item := &models.WishlistItem{
+		UserID:     uid,
+		ProductID:  pid,
+		VariantID:  variantID,
+		KnownPrice: wishlistPrice(product, variant).Float(),
+	}
+	if err := s.wishlistRepo.Create(item); err != nil {
+		return nil, err
+	}
+	item.Product = *product
+	item.Variant = variant
+
+	response := toWishlistItemResponse(item)
+	return &response, nil
+}
+
+func (s *wishlistService) RemoveItem(userID, itemID string) error {
+	uid, _ := uuid.Parse(userID)
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return errors.New("invalid wishlist item ID")
+	}
+
+	if err := s.wishlistRepo.Delete(uid, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("wishlist item not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *wishlistService) GetWishlist(userID string) ([]dto.WishlistItemResponse, error) {
+	uid, _ := uuid.Parse(userID)
+
+	items, err := s.wishlistRepo.GetByUserID(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.WishlistItemResponse, 0, len(items))
+	for i := range items {
+		// ? produk sudah dihapus admin
+		if items[i].Product.ID == uuid.Nil {
+			continue
+		}
+		result = append(result, toWishlistItemResponse(&items[i]))
+	}
+	return result, nil
+}
+
+// AddToCart puts the wishlisted product in the cart and keeps it on the wishlist
+func (s *wishlistService) AddToCart(userID, itemID string, req dto.WishlistToCartRequest) error {
+	uid, _ := uuid.Parse(userID)
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return errors.New("invalid wishlist item ID")
+	}
+
+	item, err := s.wishlistRepo.GetByID(uid, id)
+	if err != nil {
+		return errors.New("wishlist item not found")
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	cartReq := dto.CartItemRequest{
+		ProductID: item.ProductID.String(),
+		VariantID: req.VariantID,
+		Quantity:  quantity,
+	}
+	if cartReq.VariantID == nil && item.VariantID != nil {
+		variantID := item.VariantID.String()
+		cartReq.VariantID = &variantID
+	}
+
+	return s.cartService.AddToCart(userID, cartReq)
+}
+
+// CheckPriceDrops notifies users when a wishlisted product got cheaper than the price they last saw
+func (s *wishlistService) CheckPriceDrops() (int, error) {
+	items, err := s.wishlistRepo.GetAllWithProducts()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch wishlist items: %w", err)
+	}
+
+	notified := 0
+	for i := range items {
+		item := &items[i]
+		// ? varian sudah dihapus, harga tidak bisa dibandingkan lagi
+		if item.VariantID != nil && item.Variant == nil {
+			continue
+		}
+
+		current := wishlistPrice(&item.Product, item.Variant)
+		known := pricing.FromFloat(item.KnownPrice)
+		if current == known {
+			continue
+		}
+
+		if current < known {
+			name := item.Product.Name
+			if label := variantLabel(item.Variant); label != "" {
+				name += " (" + label + ")"
+			}
+
+			// TODO: Replace with RabbitMQ for async notification dispatch ---
+			if err := s.notificationService.SendToUser(dto.NotificationEvent{
+				UserID:  item.UserID.String(),
+				Type:    "price_drop",
+				Title:   "Wishlist Price Drop",
+				Message: fmt.Sprintf("%s on your wishlist dropped from Rp%.0f to Rp%.0f.", name, known.Float(), current.Float()),
+			}); err != nil {
+				log.Printf("Failed sending price drop notification to user %s: %v", item.UserID, err)
+				continue
+			}
+			// TODO: Replace with RabbitMQ for async notification dispatch ---
+			notified++
+		}
+
+		// ** harga naik juga dicatat supaya penurunan berikutnya terdeteksi dari harga terbaru
+		if err := s.wishlistRepo.UpdateKnownPrice(item.ID, current.Float()); err != nil {
+			return notified, err
+		}
+	}
+
+	return notified, nil
+}
+
+// wishlistPrice is the net unit price, discount included, of the wishlisted product or variant
+func wishlistPrice(product *models.Product, variant *models.ProductVariant) pricing.Money {
+	return pricing.PriceUnit(variantPrice(product, variant), product.Discount).Net
+}
+
+func toWishlistItemResponse(item *models.WishlistItem) dto.WishlistItemResponse {
+	unit := pricing.PriceUnit(variantPrice(&item.Product, item.Variant), item.Product.Discount)
+	response := dto.WishlistItemResponse{
+		ID:          item.ID.String(),
+		ProductID:   item.ProductID.String(),
+		ProductName: item.Product.Name,
+		ProductSlug: item.Product.Slug,
+		Variant:     variantLabel(item.Variant),
+		Image:       variantImage(&item.Product, item.Variant),
+		Price:       unit.List.Float(),
+		FinalPrice:  unit.Net.Float(),
+		InStock:     variantStock(&item.Product, item.Variant) > 0,
+		IsActive:    item.Product.IsActive && (item.VariantID == nil || (item.Variant != nil && item.Variant.IsActive)),
+		CreatedAt:   item.CreatedAt,
+	}
+	if item.VariantID != nil {
+		response.VariantID = item.VariantID.String()
+	}
+	return response
+}
@@ -1,12 +1,16 @@
 package utils
 
 import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -45,6 +49,18 @@ func MustGetRole(c *gin.Context) string {
 	return userRole
 }
 
+func MustGetGuestID(c *gin.Context) string {
+	guestID, exists := c.Get("guestID")
+	if !exists {
+		panic("guestID not found in context")
+	}
+	idStr, ok := guestID.(string)
+	if !ok {
+		panic("guestID in context is not a string")
+	}
+	return idStr
+}
+
 func BindAndValidateJSON[T any](c *gin.Context, req *T) bool {
 	if err := c.ShouldBindJSON(req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -138,3 +154,10 @@ func GetUptime() float64 {
 func NowISO() string {
 	return time.Now().Format(time.RFC3339)
 }
+
+// signature_key midtrans = SHA512(order_id + status_code + gross_amount + server_key)
+func VerifyMidtransSignature(orderID, statusCode, grossAmount, serverKey, signature string) bool {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	expected := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(signature))) == 1
+}
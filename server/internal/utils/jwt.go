@@ -12,6 +12,16 @@ import (
 var accessTokenSecret = []byte(os.Getenv("JWT_ACCESS_SECRET"))
 var refreshTokenSecret = []byte(os.Getenv("JWT_REFRESH_SECRET"))
 
+// ** quote dan keranjang tamu bisa memakai secret yang sama dengan access token,
+// audience membedakan jenis token supaya tidak bisa dipakai sebagai jenis lain
+const (
+	accessTokenAudience    = "access"
+	quoteTokenAudience     = "checkout-quote"
+	guestCartTokenAudience = "guest-cart"
+)
+
+var errWrongTokenType = errors.New("token is not meant for this use")
+
 type Claims struct {
 	UserID string `json:"userId"`
 	Role   string `json:"role"`
@@ -23,6 +33,7 @@ func GenerateAccessToken(userID, role string) (string, error) {
 		UserID: userID,
 		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{accessTokenAudience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(60 * time.Minute)),
 		},
 	}
@@ -50,6 +61,9 @@ func DecodeAccessToken(tokenStr string) (*Claims, error) {
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if !claims.VerifyAudience(accessTokenAudience, true) {
+			return nil, errWrongTokenType
+		}
 		return claims, nil
 	}
 	return nil, errors.New("invalid access token")
@@ -69,6 +83,58 @@ func DecodeRefreshToken(tokenStr string) (string, error) {
 	return "", errors.New("invalid refresh token")
 }
 
+// QuoteClaims pins a checkout quote to its inputs, Digest covers every priced line and amount
+type QuoteClaims struct {
+	UserID        string  `json:"userId"`
+	AddressID     string  `json:"addressId"`
+	Courier       string  `json:"courier"`
+	Service       string  `json:"service"`
+	VoucherCode   *string `json:"voucherCode,omitempty"`
+	PaymentMethod string  `json:"paymentMethod"`
+	Digest        string  `json:"digest"`
+	jwt.RegisteredClaims
+}
+
+func quoteTokenSecret() []byte {
+	if secret := os.Getenv("QUOTE_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	return []byte(os.Getenv("JWT_ACCESS_SECRET"))
+}
+
+func GenerateQuoteToken(claims QuoteClaims, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Subject:   claims.UserID,
+		Audience:  jwt.ClaimStrings{quoteTokenAudience},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(quoteTokenSecret())
+	return signed, expiresAt, err
+}
+
+func DecodeQuoteToken(tokenStr string) (*QuoteClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &QuoteClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return quoteTokenSecret(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*QuoteClaims); ok && token.Valid {
+		if !claims.VerifyAudience(quoteTokenAudience, true) {
+			return nil, errWrongTokenType
+		}
+		return claims, nil
+	}
+	return nil, errors.New("invalid quote token")
+}
+
 func SetRefreshTokenCookie(c *gin.Context, refreshToken string) {
 	domain := os.Getenv("COOKIE_DOMAIN")
 	c.SetCookie("refreshToken", refreshToken, 7*24*3600, "/", domain, true, true)
@@ -104,3 +170,54 @@ func ClearAccessTokenCookie(c *gin.Context) {
 		true,
 	)
 }
+
+// GuestCartTTL is how long an anonymous cart survives without activity
+const GuestCartTTL = 30 * 24 * time.Hour
+
+func guestCartSecret() []byte {
+	if secret := os.Getenv("GUEST_CART_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	return []byte(os.Getenv("JWT_ACCESS_SECRET"))
+}
+
+func GenerateGuestCartToken(guestID string) (string, error) {
+	claims := jwt.RegisteredClaims{
+		Subject:   guestID,
+		Audience:  jwt.ClaimStrings{guestCartTokenAudience},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(GuestCartTTL)),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(guestCartSecret())
+}
+
+func DecodeGuestCartToken(tokenStr string) (string, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return guestCartSecret(), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
+		if !claims.VerifyAudience(guestCartTokenAudience, true) {
+			return "", errWrongTokenType
+		}
+		return claims.Subject, nil
+	}
+	return "", errors.New("invalid guest cart token")
+}
+
+func SetGuestCartCookie(c *gin.Context, token string) {
+	domain := os.Getenv("COOKIE_DOMAIN")
+	c.SetCookie("guestCart", token, int(GuestCartTTL.Seconds()), "/", domain, true, true)
+}
+
+func ClearGuestCartCookie(c *gin.Context) {
+	domain := os.Getenv("COOKIE_DOMAIN")
+	c.SetCookie("guestCart", "", -1, "/", domain, true, true)
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// ? tanpa QUOTE_SECRET dan GUEST_CART_SECRET semua token memakai secret yang sama dengan access token
+func TestTokensOnlyDecodeAsTheirOwnKind(t *testing.T) {
+	t.Setenv("QUOTE_SECRET", "")
+	t.Setenv("GUEST_CART_SECRET", "")
+	userID := "6f1c2a9e-7d41-4c55-9a36-1f0e8b2d4c11"
+
+	access, err := GenerateAccessToken(userID, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quote, _, err := GenerateQuoteToken(QuoteClaims{UserID: userID, PaymentMethod: "cod"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guest, err := GenerateGuestCartToken(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoders := map[string]func(string) error{
+		"access": func(s string) error { _, err := DecodeAccessToken(s); return err },
+		"quote":  func(s string) error { _, err := DecodeQuoteToken(s); return err },
+		"guest":  func(s string) error { _, err := DecodeGuestCartToken(s); return err },
+	}
+	tokens := map[string]string{"access": access, "quote": quote, "guest": guest}
+
+	for kind, token := range tokens {
+		for as, decode := range decoders {
+			t.Run(kind+" as "+as, func(t *testing.T) {
+				err := decode(token)
+				if kind == as && err != nil {
+					t.Errorf("decoding a %s token failed: %v", kind, err)
+				}
+				if kind != as && err == nil {
+					t.Errorf("a %s token was accepted as a %s token", kind, as)
+				}
+			})
+		}
+	}
+}